@@ -19,8 +19,6 @@ package cmd
 import (
 	"errors"
 	"github.com/marcelblijleven/jira-helper/pkg"
-	"net/http"
-	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -30,17 +28,47 @@ var assignReleaseCmd = &cobra.Command{
 	Use:   "assignRelease",
 	Short: "Assigns a version to all provided issues in the release body",
 	Long: `Assigns a version to all provided issues. The issue numbers are retrieved from
-the provided release body.`,
+the provided release body.
+
+Additional fix versions and affects versions can be added to or removed from the issues
+in the same run, e.g. when a backport ships in multiple versions. With --replace all
+existing fix versions of the issues are replaced.
+
+With --journal every updated issue is recorded in a journal file. When the command is
+interrupted, it can be continued with --resume, which skips the issues that were updated.`,
+	PreRunE: requireFlags(hostFlagName, projectFlagName, versionFlagName),
 	Run: func(cmd *cobra.Command, args []string) {
 		if body == "" && (issues == nil || len(issues) == 0) {
 			cobra.CheckErr(errors.New("no issues provided. Provide issue through the issues and/or releaseBody flags"))
 		}
 
-		httpClient := http.DefaultClient
-		httpClient.Timeout = time.Second * 15
-		client, err := pkg.NewJiraClient(host, user, token, httpClient)
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		client, err := newJiraClient()
+		cobra.CheckErr(err)
+		fields, err := pkg.ParseFieldAssignments(setFields)
+		cobra.CheckErr(err)
+
+		update := pkg.IssueUpdate{
+			AffectsVersions: pkg.VersionChange{Add: affectsVersions, Remove: removeAffectsVersions},
+			Fields:          fields,
+		}
+
+		if replaceFixVersions {
+			update.FixVersions = pkg.VersionChange{Set: append([]string{version}, fixVersions...), Remove: removeFixVersions}
+		} else {
+			update.FixVersions = pkg.VersionChange{Add: append([]string{version}, fixVersions...), Remove: removeFixVersions}
+		}
+
+		steps, err := pkg.UpdateIssueSteps(ctx, client, body, issues, filter, update)
+		cobra.CheckErr(err)
+		operation, closeJournal, err := newOperation(client, false)
+		cobra.CheckErr(err)
+		defer closeJournal()
+		steps, err = validateIssues(ctx, client, operation, steps, []string{project})
 		cobra.CheckErr(err)
-		cobra.CheckErr(pkg.AssignVersions(body, version, client, issues, filter))
+		cobra.CheckErr(checkPermissions(ctx, client, pkg.RequiredPermissions(steps)))
+		cobra.CheckErr(runOperation(ctx, operation, steps))
 	},
 }
 
@@ -50,4 +78,16 @@ func init() {
 	assignReleaseCmd.Flags().StringVarP(&body, bodyFlagName, bodyShorthand, "", bodyUsage)
 	assignReleaseCmd.Flags().StringSliceVarP(&issues, issuesFlagName, issuesShorthand, []string{}, issuesUsage)
 	assignReleaseCmd.Flags().StringSliceVarP(&filter, filterFlagName, filterShorthand, []string{}, filterUsage)
+	assignReleaseCmd.Flags().StringArrayVar(&setFields, setFieldFlagName, []string{}, setFieldUsage)
+	assignReleaseCmd.Flags().StringSliceVar(&fixVersions, fixVersionFlagName, []string{}, fixVersionUsage)
+	assignReleaseCmd.Flags().StringSliceVar(&affectsVersions, affectsVersionFlagName, []string{}, affectsVersionUsage)
+	assignReleaseCmd.Flags().StringSliceVar(&removeFixVersions, removeFixVersionFlagName, []string{}, removeFixVersionUsage)
+	assignReleaseCmd.Flags().StringSliceVar(&removeAffectsVersions, removeAffectsVersionFlagName, []string{}, removeAffectsVersionUsage)
+	assignReleaseCmd.Flags().BoolVar(&replaceFixVersions, replaceFlagName, false, replaceUsage)
+	assignReleaseCmd.Flags().StringVar(&journalPath, journalFlagName, "", journalUsage)
+	assignReleaseCmd.Flags().StringVar(&resumePath, resumeFlagName, "", resumeUsage)
+	assignReleaseCmd.Flags().StringVar(&runId, runIdFlagName, "", runIdUsage)
+	assignReleaseCmd.Flags().BoolVar(&strict, strictFlagName, false, strictUsage)
+	assignReleaseCmd.Flags().StringSliceVar(&issueTypes, issueTypeFlagName, []string{}, issueTypeUsage)
+	assignReleaseCmd.Flags().BoolVar(&skipPermissionCheck, skipPermissionCheckFlagName, false, skipPermissionCheckUsage)
 }
@@ -0,0 +1,88 @@
+/*
+Copyright © 2022 Marcel Blijleven
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/marcelblijleven/jira-helper/pkg"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// checkPermissionsCmd represents the checkPermissions command
+var checkPermissionsCmd = &cobra.Command{
+	Use:   "checkPermissions",
+	Short: "Checks that the user has the permissions the commands need in the project",
+	Long: `Checks that the user has the permissions the commands need in the project, e.g.
+Administer Projects to create versions and Edit Issues and Resolve Issues to change the
+fix versions of issues. Every permission is listed with whether the user has it, and the
+command fails when one of them is missing.
+
+The commands which change Jira run the same check before any change is made, for the
+projects and issues they change. Use --skip-permission-check to turn it off.`,
+	Aliases: []string{"permissions"},
+	PreRunE: requireFlags(hostFlagName, projectFlagName),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		required, err := operationPermissions(operations)
+		cobra.CheckErr(err)
+		client, err := newJiraClient()
+		cobra.CheckErr(err)
+		check, err := client.CheckPermissionsContext(ctx, map[string][]string{project: required})
+		cobra.CheckErr(err)
+		cobra.CheckErr(printResult(&pkg.Result{Permissions: check.Permissions}))
+		cobra.CheckErr(check.Err())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkPermissionsCmd)
+	checkPermissionsCmd.Flags().StringSliceVar(&operations, operationFlagName, []string{}, operationUsage)
+}
+
+// operationPermissions returns the permissions the provided commands need, or the permissions all commands need when
+// no command is provided
+func operationPermissions(names []string) ([]string, error) {
+	var known []string
+
+	for name := range pkg.OperationPermissions {
+		known = append(known, name)
+	}
+
+	sort.Strings(known)
+
+	if len(names) == 0 {
+		names = known
+	}
+
+	var required []string
+
+	for _, name := range names {
+		permissions, ok := pkg.OperationPermissions[name]
+
+		if !ok {
+			return nil, fmt.Errorf("unknown operation %q, use %s", name, strings.Join(known, ", "))
+		}
+
+		required = append(required, permissions...)
+	}
+
+	return required, nil
+}
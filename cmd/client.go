@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/marcelblijleven/jira-helper/pkg"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// requestTimeout is the maximum duration of a single request, the duration of the whole command is limited by --timeout
+const requestTimeout = time.Second * 15
+
+// newJiraClient creates a Jira client with the authentication provided through the root flags, which logs to stderr
+// with the level set by --verbose and --quiet
+func newJiraClient() (*pkg.JiraClient, error) {
+	httpClient, err := newSessionHttpClient()
+
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newAuthenticatedJiraClient(httpClient)
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.SetLogger(pkg.NewTextLogger(os.Stderr, logLevel()))
+	client.SetIssuePattern(issuePattern)
+	tracer, err := newTracer()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tracer != nil {
+		client.SetTracer(tracer, token, clientSecret)
+	}
+
+	return client, nil
+}
+
+// newTracer creates the tracer set by --trace, --trace-file and --trace-format. Nil is returned when tracing is not
+// enabled.
+func newTracer() (pkg.Tracer, error) {
+	if !trace && traceFile == "" {
+		return nil, nil
+	}
+
+	switch traceFormat {
+	case traceFormatText:
+		if traceFile == "" {
+			return pkg.NewTextTracer(os.Stderr), nil
+		}
+
+		file, err := os.OpenFile(traceFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not open trace file: %w", err)
+		}
+
+		return pkg.NewTextTracer(file), nil
+	case traceFormatHAR:
+		if traceFile == "" {
+			return nil, fmt.Errorf("the %s trace format requires --%s", traceFormatHAR, traceFileFlagName)
+		}
+
+		return pkg.NewHARTracer(traceFile), nil
+	default:
+		return nil, fmt.Errorf("unknown trace format %q, supported formats are %s and %s", traceFormat, traceFormatText, traceFormatHAR)
+	}
+}
+
+// newSessionHttpClient creates the http client for the Jira client, which records the session in a cassette with
+// --record or replays a recorded session with --replay
+func newSessionHttpClient() (pkg.HttpClient, error) {
+	if recordPath != "" && replayPath != "" {
+		return nil, fmt.Errorf("the %s and %s flags cannot be combined", recordFlagName, replayFlagName)
+	}
+
+	if replayPath != "" {
+		cassette, err := pkg.LoadCassette(replayPath)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return pkg.NewReplayer(cassette), nil
+	}
+
+	httpClient, err := newHttpClient()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if recordPath != "" {
+		return pkg.NewRecorder(httpClient, recordPath, token, clientSecret), nil
+	}
+
+	return httpClient, nil
+}
+
+// newHttpClient creates an http client with the proxy and TLS settings provided through the root flags and profile
+func newHttpClient() (*http.Client, error) {
+	if insecure {
+		fmt.Fprintf(os.Stderr, "WARNING: --%s disables TLS certificate verification, the connection to Jira can be intercepted\n", insecureFlagName)
+	}
+
+	return pkg.NewHttpClient(transportConfig(), requestTimeout)
+}
+
+// transportConfig returns the transport config provided through the root flags and profile
+func transportConfig() pkg.TransportConfig {
+	return pkg.TransportConfig{
+		Proxy:         proxy,
+		CACert:        caCert,
+		ClientCert:    clientCert,
+		ClientKey:     clientKey,
+		MinTLSVersion: minTLSVersion,
+		Insecure:      insecure,
+	}
+}
+
+// logLevel returns the log level set by --verbose and --quiet. Warnings and errors are logged by default.
+func logLevel() pkg.LogLevel {
+	switch {
+	case quiet:
+		return pkg.LevelError
+	case verbosity >= 2:
+		return pkg.LevelDebug
+	case verbosity == 1:
+		return pkg.LevelInfo
+	default:
+		return pkg.LevelWarn
+	}
+}
+
+// newAuthenticatedJiraClient creates a Jira client with the authentication provided through the root flags
+func newAuthenticatedJiraClient(httpClient pkg.HttpClient) (*pkg.JiraClient, error) {
+	switch strings.ToLower(authType) {
+	case pkg.AuthTypeOAuth2, pkg.AuthTypeOAuth2ClientCredentials:
+		return newOAuth2JiraClient(httpClient)
+	case pkg.AuthTypeOAuth1:
+		return newOAuth1JiraClient(httpClient)
+	}
+
+	authenticator, err := pkg.NewAuthenticator(authType, user, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pkg.NewJiraClientWithAuthenticator(host, authenticator, httpClient)
+}
+
+// newOAuth2JiraClient creates a Jira client which authenticates with OAuth 2.0. The host is resolved to the
+// api.atlassian.com url of the site.
+func newOAuth2JiraClient(httpClient pkg.HttpClient) (*pkg.JiraClient, error) {
+	config := pkg.OAuth2Config{ClientId: clientId, ClientSecret: clientSecret}
+
+	var authenticator *pkg.OAuth2Authenticator
+	var err error
+
+	if strings.ToLower(authType) == pkg.AuthTypeOAuth2ClientCredentials {
+		authenticator, err = pkg.NewOAuth2ClientCredentialsAuthenticator(config, httpClient)
+	} else {
+		authenticator, err = newStoredTokenAuthenticator(config, httpClient)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL, err := authenticator.BaseURL(host)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pkg.NewJiraClientWithAuthenticator(baseURL, authenticator, httpClient)
+}
+
+// newStoredTokenAuthenticator creates an OAuth 2.0 authenticator with the token stored by the oauth2 login command.
+// Refreshed tokens are stored again.
+func newStoredTokenAuthenticator(config pkg.OAuth2Config, httpClient pkg.HttpClient) (*pkg.OAuth2Authenticator, error) {
+	path, err := oauth2TokenPath()
+
+	if err != nil {
+		return nil, err
+	}
+
+	storedToken, err := pkg.LoadOAuth2Token(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w, log in with the oauth2 login command first", err)
+	}
+
+	authenticator, err := pkg.NewOAuth2Authenticator(config, *storedToken, httpClient)
+
+	if err != nil {
+		return nil, err
+	}
+
+	authenticator.OnToken(func(token pkg.OAuth2Token) {
+		if saveErr := pkg.SaveOAuth2Token(path, token); saveErr != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", saveErr)
+		}
+	})
+
+	return authenticator, nil
+}
+
+// newOAuth1JiraClient creates a Jira client which signs its requests with the OAuth 1.0a credentials stored by the
+// oauth1 login command. The consumer key and private key can be overridden with flags.
+func newOAuth1JiraClient(httpClient pkg.HttpClient) (*pkg.JiraClient, error) {
+	path, err := configFilePath(oauth1CredentialsFile, "oauth1-credentials.json", oauth1CredentialsFileFlagName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := pkg.LoadOAuth1Credentials(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w, log in with the oauth1 login command first", err)
+	}
+
+	if consumerKey != "" {
+		credentials.ConsumerKey = consumerKey
+	}
+
+	if privateKeyFile != "" {
+		credentials.PrivateKeyFile = privateKeyFile
+	}
+
+	authenticator, err := credentials.Authenticator()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pkg.NewJiraClientWithAuthenticator(host, authenticator, httpClient)
+}
+
+// oauth2TokenPath returns the path of the file in which the OAuth 2.0 token is stored
+func oauth2TokenPath() (string, error) {
+	return configFilePath(oauth2TokenFile, "oauth2-token.json", oauth2TokenFileFlagName)
+}
+
+// configFilePath returns the provided path or, when it is empty, the path of the file with the provided name in the
+// jira-helper config directory
+func configFilePath(path, name, flagName string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+
+	dir, err := os.UserConfigDir()
+
+	if err != nil {
+		return "", fmt.Errorf("could not determine location of %s, provide it with --%s: %w", name, flagName, err)
+	}
+
+	return filepath.Join(dir, "jira-helper", name), nil
+}
@@ -0,0 +1,248 @@
+/*
+Copyright © 2022 Marcel Blijleven
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/marcelblijleven/jira-helper/pkg"
+	"os"
+	"regexp"
+
+	"github.com/spf13/cobra"
+)
+
+// configCmd groups the config commands
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the profiles in the jira-helper config file",
+	Long: `Manage the profiles in the jira-helper config file.
+
+The config is read from ~/.config/jira-helper/config.yaml and from .jira-helper.yaml in the
+current directory or one of its parents, which overrides the user config. Changes are written
+to the user config, or to the file provided with --config.`,
+	// Override the root PersistentPreRunE, the config commands must work with an invalid profile selected
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+}
+
+// configGetCmd represents the config get command
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a value of the selected profile",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, err := loadConfig()
+		cobra.CheckErr(err)
+		profile, err := config.Profile(profileName)
+		cobra.CheckErr(err)
+		value, err := profile.Get(args[0])
+		cobra.CheckErr(err)
+		fmt.Println(value)
+	},
+}
+
+// configSetCmd represents the config set command
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a value of the selected profile, the profile is created when it does not exist",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, config, err := loadWritableConfig()
+		cobra.CheckErr(err)
+
+		name := config.SelectedProfileName(profileName)
+		profile, ok := config.Profiles[name]
+
+		if !ok {
+			profile = &pkg.Profile{}
+			config.Profiles[name] = profile
+		}
+
+		cobra.CheckErr(profile.Set(args[0], args[1]))
+		cobra.CheckErr(config.Save(path))
+	},
+}
+
+// configListCmd represents the config list command
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the profiles, the current profile is marked with an asterisk",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		config, err := loadConfig()
+		cobra.CheckErr(err)
+		current := config.SelectedProfileName("")
+
+		for _, name := range config.ProfileNames() {
+			marker := " "
+
+			if name == current {
+				marker = "*"
+			}
+
+			profile := config.Profiles[name]
+			fmt.Printf("%s %s\thost=%s\tproject=%s\tauthType=%s\n", marker, name, profile.Host, profile.Project, profile.AuthType)
+		}
+	},
+}
+
+// configUseCmd represents the config use command
+var configUseCmd = &cobra.Command{
+	Use:   "use <profile>",
+	Short: "Select the profile used when no profile is provided with --profile",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		effective, err := loadConfig()
+		cobra.CheckErr(err)
+
+		if _, ok := effective.Profiles[args[0]]; !ok {
+			cobra.CheckErr(fmt.Errorf("profile %q does not exist", args[0]))
+		}
+
+		path, config, err := loadWritableConfig()
+		cobra.CheckErr(err)
+		config.CurrentProfile = args[0]
+		cobra.CheckErr(config.Save(path))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd, configSetCmd, configListCmd, configUseCmd)
+}
+
+// loadConfig loads the config provided with --config or, when it is not provided, the user config merged with the
+// repository config
+func loadConfig() (*pkg.Config, error) {
+	if configPath != "" {
+		return pkg.LoadConfig(configPath)
+	}
+
+	path, err := pkg.UserConfigPath()
+
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := pkg.LoadConfig(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	wd, err := os.Getwd()
+
+	if err != nil {
+		return config, nil
+	}
+
+	repoPath := pkg.FindRepoConfig(wd)
+
+	if repoPath == "" {
+		return config, nil
+	}
+
+	repoConfig, err := pkg.LoadConfig(repoPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, value := range repoConfig.UserOnlyValues() {
+		fmt.Fprintf(os.Stderr, "warning: ignoring %s in %s, it can only be set in the user config or with a flag\n", value, repoPath)
+	}
+
+	return config.Merge(repoConfig), nil
+}
+
+// loadWritableConfig loads the config file the config commands write to, which is the file provided with --config or
+// the user config
+func loadWritableConfig() (string, *pkg.Config, error) {
+	path := configPath
+
+	if path == "" {
+		var err error
+
+		if path, err = pkg.UserConfigPath(); err != nil {
+			return "", nil, err
+		}
+	}
+
+	config, err := pkg.LoadConfig(path)
+	return path, config, err
+}
+
+// applyProfile loads the selected profile and uses its values for the flags which were not provided
+func applyProfile(cmd *cobra.Command) error {
+	config, err := loadConfig()
+
+	if err != nil {
+		return err
+	}
+
+	profile, err := config.Profile(profileName)
+
+	if err != nil {
+		return err
+	}
+
+	loadedConfig = config
+	selectedProfile = profile
+	applyProfileValue(cmd, authTypeFlagName, &authType, profile.AuthType)
+	applyProfileValue(cmd, projectFlagName, &project, profile.Project)
+	applyProfileValue(cmd, tokenFileFlagName, &tokenFile, profile.TokenFile)
+	applyProfileValue(cmd, credentialHelperFlagName, &credentialHelper, profile.CredentialHelper)
+	applyProfileValue(cmd, proxyFlagName, &proxy, profile.Proxy)
+	applyProfileValue(cmd, caCertFlagName, &caCert, profile.CACert)
+	applyProfileValue(cmd, clientCertFlagName, &clientCert, profile.ClientCert)
+	applyProfileValue(cmd, clientKeyFlagName, &clientKey, profile.ClientKey)
+	applyProfileValue(cmd, minTLSVersionFlagName, &minTLSVersion, profile.MinTLSVersion)
+
+	if flag := cmd.Flags().Lookup(insecureFlagName); flag != nil && !flag.Changed && profile.Insecure {
+		insecure = true
+	}
+
+	if flag := cmd.Flags().Lookup(filterFlagName); flag != nil && !flag.Changed && len(profile.Filter) != 0 {
+		filter = profile.Filter
+	}
+
+	if profile.IssuePattern != "" {
+		pattern, err := regexp.Compile(profile.IssuePattern)
+
+		if err != nil {
+			return fmt.Errorf("invalid issue pattern in profile: %w", err)
+		}
+
+		issuePattern = pattern
+	}
+
+	return nil
+}
+
+// applyProfileValue sets the target to the value from the profile when the flag was not provided
+func applyProfileValue(cmd *cobra.Command, flagName string, target *string, value string) {
+	if value == "" {
+		return
+	}
+
+	if flag := cmd.Flags().Lookup(flagName); flag != nil && flag.Changed {
+		return
+	}
+
+	*target = value
+}
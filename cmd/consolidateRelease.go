@@ -0,0 +1,61 @@
+/*
+Copyright © 2022 Marcel Blijleven
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/marcelblijleven/jira-helper/pkg"
+
+	"github.com/spf13/cobra"
+)
+
+// consolidateReleaseCmd represents the consolidateRelease command
+var consolidateReleaseCmd = &cobra.Command{
+	Use:   "consolidateRelease",
+	Short: "Moves the issues of the pre-release versions onto the final version and releases it",
+	Long: `Looks up the pre-release versions of the final version in the project, e.g. "1.4.0-rc.1"
+and "1.4.0-rc.2" for "1.4.0", and moves their issues onto the final version. The final
+version is created when it does not exist and released when it is not released yet.
+
+By default the pre-release versions are merged into the final version, which deletes them.
+With --archive their issues are moved and the pre-release versions are archived instead.`,
+	Aliases: []string{"consolidateVersion", "consolidate"},
+	PreRunE: requireFlags(hostFlagName, projectFlagName, versionFlagName),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		client, err := newJiraClient()
+		cobra.CheckErr(err)
+		steps, err := pkg.ConsolidateSteps(ctx, client, project, version, archiveVersions)
+		cobra.CheckErr(err)
+		cobra.CheckErr(checkPermissions(ctx, client, pkg.RequiredPermissions(steps)))
+
+		operation, closeJournal, err := newOperation(client, transactional)
+		cobra.CheckErr(err)
+		defer closeJournal()
+		cobra.CheckErr(runOperation(ctx, operation, steps))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(consolidateReleaseCmd)
+	consolidateReleaseCmd.Flags().BoolVar(&archiveVersions, archiveFlagName, false, archiveUsage)
+	consolidateReleaseCmd.Flags().BoolVar(&transactional, transactionalFlagName, false, transactionalUsage)
+	consolidateReleaseCmd.Flags().StringVar(&journalPath, journalFlagName, "", journalUsage)
+	consolidateReleaseCmd.Flags().StringVar(&resumePath, resumeFlagName, "", resumeUsage)
+	consolidateReleaseCmd.Flags().StringVar(&runId, runIdFlagName, "", runIdUsage)
+	consolidateReleaseCmd.Flags().BoolVar(&skipPermissionCheck, skipPermissionCheckFlagName, false, skipPermissionCheckUsage)
+}
@@ -19,8 +19,6 @@ package cmd
 import (
 	"errors"
 	"github.com/marcelblijleven/jira-helper/pkg"
-	"net/http"
-	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -32,18 +30,62 @@ var createAndAssignCmd = &cobra.Command{
 	Long: `Creates a fix version in Jira and assigns it to the provided issues.
 
 The release state of the fix version will be set to "released" and the day will be set to 
-today.`,
+today. A pre-release, e.g. 1.4.0-rc.1, is created unreleased without a release date.
+
+With --transactional every change is recorded. When assigning the version to one of the
+issues fails, the version is removed from the issues it was already assigned to and the
+created version is deleted again.
+
+With --journal every completed step is recorded in a journal file. When the command is
+interrupted, it can be continued with --resume, which skips the steps that were completed.
+
+With --mapped the mappings of the repository config are used instead of --project, to
+create a version in the project of every mapping selected by --tag and --paths. Every issue
+is assigned to the version of its own project.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if mapped {
+			return requireFlags(hostFlagName)(cmd, args)
+		}
+
+		return requireFlags(hostFlagName, projectFlagName, versionFlagName)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if body == "" && (issues == nil || len(issues) == 0) {
 			cobra.CheckErr(errors.New("no issues provided. Provide issue through the issues and/or releaseBody flags"))
 		}
 
-		httpClient := http.DefaultClient
-		httpClient.Timeout = time.Second * 15
-		client, err := pkg.NewJiraClient(host, user, token, httpClient)
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		client, err := newJiraClient()
+		cobra.CheckErr(err)
+		fields, err := pkg.ParseFieldAssignments(setFields)
+		cobra.CheckErr(err)
+		values, err := client.ResolveFieldValuesContext(ctx, fields)
+		cobra.CheckErr(err)
+
+		var steps []pkg.Step
+		var skipped []string
+		projects := []string{project}
+		// The issues are extracted with the issue pattern of the profile
+		assigned := append(append([]string{}, issues...), client.ExtractIssues(body)...)
+
+		if mapped {
+			plan, planErr := pkg.PlanReleases(mappingsWithTemplate(), version, tag, stripPrefix, changedPaths, "", assigned, filter)
+			cobra.CheckErr(planErr)
+			// The plan assigns every issue to the version of its own project
+			steps, skipped, projects = plan.Steps(values), plan.Unmapped, nil
+		} else {
+			steps = pkg.CreateAndAssignSteps(version, project, "", assigned, filter, values)
+		}
+
+		operation, closeJournal, err := newOperation(client, transactional)
 		cobra.CheckErr(err)
-		cobra.CheckErr(client.CreateFixVersion(version, project))
-		cobra.CheckErr(pkg.AssignVersions(body, version, client, issues, filter))
+		defer closeJournal()
+		operation.SkipIssues("no mapping selected for the project of the issue", skipped...)
+		steps, err = validateIssues(ctx, client, operation, steps, projects)
+		cobra.CheckErr(err)
+		cobra.CheckErr(checkPermissions(ctx, client, pkg.RequiredPermissions(steps)))
+		cobra.CheckErr(runOperation(ctx, operation, steps))
 	},
 }
 
@@ -52,4 +94,35 @@ func init() {
 	createAndAssignCmd.Flags().StringVarP(&body, bodyFlagName, bodyShorthand, "", bodyUsage)
 	createAndAssignCmd.Flags().StringSliceVarP(&issues, issuesFlagName, issuesShorthand, []string{}, issuesUsage)
 	createAndAssignCmd.Flags().StringSliceVarP(&filter, filterFlagName, filterShorthand, []string{}, filterUsage)
+	createAndAssignCmd.Flags().StringArrayVar(&setFields, setFieldFlagName, []string{}, setFieldUsage)
+	createAndAssignCmd.Flags().BoolVar(&transactional, transactionalFlagName, false, transactionalUsage)
+	createAndAssignCmd.Flags().StringVar(&journalPath, journalFlagName, "", journalUsage)
+	createAndAssignCmd.Flags().StringVar(&resumePath, resumeFlagName, "", resumeUsage)
+	createAndAssignCmd.Flags().StringVar(&runId, runIdFlagName, "", runIdUsage)
+	createAndAssignCmd.Flags().BoolVar(&strict, strictFlagName, false, strictUsage)
+	createAndAssignCmd.Flags().StringSliceVar(&issueTypes, issueTypeFlagName, []string{}, issueTypeUsage)
+	createAndAssignCmd.Flags().BoolVar(&skipPermissionCheck, skipPermissionCheckFlagName, false, skipPermissionCheckUsage)
+	createAndAssignCmd.Flags().BoolVar(&mapped, mappedFlagName, false, mappedUsage)
+	createAndAssignCmd.Flags().StringVar(&tag, tagFlagName, "", tagUsage)
+	createAndAssignCmd.Flags().StringSliceVar(&changedPaths, pathsFlagName, []string{}, pathsUsage)
+}
+
+// mappingsWithTemplate returns the configured mappings, in which the version template provided with --version-template
+// is used for the mappings without a template of their own
+func mappingsWithTemplate() []pkg.Mapping {
+	mappings := make([]pkg.Mapping, len(loadedConfig.Mappings))
+	copy(mappings, loadedConfig.Mappings)
+	defaultTemplate := versionTemplate
+
+	if defaultTemplate == "" && validateSemVer {
+		defaultTemplate = "{{.Version}}"
+	}
+
+	for i := range mappings {
+		if mappings[i].VersionTemplate == "" {
+			mappings[i].VersionTemplate = defaultTemplate
+		}
+	}
+
+	return mappings
 }
@@ -18,8 +18,6 @@ package cmd
 
 import (
 	"github.com/marcelblijleven/jira-helper/pkg"
-	"net/http"
-	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -31,17 +29,22 @@ var createReleaseCmd = &cobra.Command{
 	Long: `Create a fix version in Jira for the project with the provided name.
 
 The release state of the fix version will be set to "released" and the day will be set to 
-today.`,
+today. A pre-release, e.g. 1.4.0-rc.1, is created unreleased without a release date.`,
+	PreRunE: requireFlags(hostFlagName, projectFlagName, versionFlagName),
 	Run: func(cmd *cobra.Command, args []string) {
-		httpClient := http.DefaultClient
-		httpClient.Timeout = time.Second * 15
-		client, err := pkg.NewJiraClient(host, user, token, httpClient)
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		client, err := newJiraClient()
 		cobra.CheckErr(err)
-		cobra.CheckErr(client.CreateFixVersion(version, project))
+		cobra.CheckErr(checkPermissions(ctx, client, map[string][]string{project: pkg.OperationPermissions["createRelease"]}))
+		created, err := client.CreateFixVersionContext(ctx, version, project)
+		cobra.CheckErr(err)
+		cobra.CheckErr(printResult(&pkg.Result{Versions: []pkg.Version{*created}}))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(createReleaseCmd)
 	createReleaseCmd.Aliases = []string{"createVersion"}
+	createReleaseCmd.Flags().BoolVar(&skipPermissionCheck, skipPermissionCheckFlagName, false, skipPermissionCheckUsage)
 }
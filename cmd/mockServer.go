@@ -0,0 +1,97 @@
+/*
+Copyright © 2022 Marcel Blijleven
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/marcelblijleven/jira-helper/pkg/jiratest"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// mockServerCmd represents the mock-server command
+var mockServerCmd = &cobra.Command{
+	Use:   "mock-server",
+	Short: "Serve a fake Jira API to rehearse release pipelines locally",
+	Long: `Serve an in-memory fake of the Jira REST API, to rehearse release pipelines without
+touching a real Jira instance. The fake starts with the projects, versions, issues and
+fields from the --seed file and keeps the changes until it is stopped.
+
+Point the other commands at it with --host http://localhost:<port>. The fake does not
+check credentials, so any token works, or use --auth-type anonymous. The versions which
+were created or changed, the updated issues and all received requests are shown at
+http://localhost:<port>` + jiratest.InspectPath + `.`,
+	Example: `  jira-helper mock-server --port 8080 --seed fixtures.yaml
+  jira-helper createAndAssign --host http://localhost:8080 --auth-type anonymous -p MB -v 1.0.0 -i MB-1
+  curl http://localhost:8080` + jiratest.InspectPath,
+	// Override the root PersistentPreRunE, the fake requires no host or credentials
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		jira := jiratest.New()
+
+		if seedPath != "" {
+			fixtures, err := jiratest.LoadFixtures(seedPath)
+			cobra.CheckErr(err)
+			cobra.CheckErr(jira.Seed(*fixtures))
+		}
+
+		listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", mockPort))
+		cobra.CheckErr(err)
+
+		server := &http.Server{Handler: logRequests(jira)}
+		errs := make(chan error, 1)
+		go func() { errs <- server.Serve(listener) }()
+
+		url := fmt.Sprintf("http://localhost:%d", mockPort)
+		fmt.Printf("serving the fake Jira API on %s, inspect the changes at %s%s\n", url, url, jiratest.InspectPath)
+
+		select {
+		case err = <-errs:
+		case <-cmd.Context().Done():
+			err = server.Shutdown(context.Background())
+		}
+
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			cobra.CheckErr(err)
+		}
+	},
+}
+
+// logRequests logs every request to stderr when --verbose is provided
+func logRequests(handler http.Handler) http.Handler {
+	if verbosity == 0 {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(os.Stderr, "%s %s\n", r.Method, r.URL.RequestURI())
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(mockServerCmd)
+	mockServerCmd.Flags().IntVar(&mockPort, portFlagName, 8080, portUsage)
+	mockServerCmd.Flags().StringVar(&seedPath, seedFlagName, "", seedUsage)
+}
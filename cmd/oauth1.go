@@ -0,0 +1,85 @@
+/*
+Copyright © 2022 Marcel Blijleven
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/marcelblijleven/jira-helper/pkg"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// oauth1Cmd groups the OAuth 1.0a commands
+var oauth1Cmd = &cobra.Command{
+	Use:   "oauth1",
+	Short: "Manage the OAuth 1.0a authentication with Jira Server application links",
+}
+
+// oauth1LoginCmd represents the oauth1 login command
+var oauth1LoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Obtain an OAuth 1.0a access token for an application link and store it",
+	Long: `Obtain an OAuth 1.0a access token for a Jira Server application link.
+
+A request token is requested with the consumer key and private key of the application
+link. Open the printed url in a browser, authorize the request and enter the verification
+code. The access token is then stored, so other commands can use it with --auth-type oauth1.`,
+	PreRunE: requireFlags(hostFlagName, consumerKeyFlagName, privateKeyFlagName),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := configFilePath(oauth1CredentialsFile, "oauth1-credentials.json", oauth1CredentialsFileFlagName)
+		cobra.CheckErr(err)
+
+		keyFile, err := filepath.Abs(privateKeyFile)
+		cobra.CheckErr(err)
+		key, err := pkg.LoadRSAPrivateKey(keyFile)
+		cobra.CheckErr(err)
+
+		httpClient, err := newHttpClient()
+		cobra.CheckErr(err)
+		flow, err := pkg.NewOAuth1Flow(host, consumerKey, key, httpClient)
+		cobra.CheckErr(err)
+
+		requestToken, err := flow.RequestToken("oob")
+		cobra.CheckErr(err)
+
+		fmt.Printf("Open the following url in your browser to authorize jira-helper:\n\n%s\n\n", flow.AuthorizationURL(requestToken))
+		fmt.Print("Enter the verification code: ")
+
+		verifier, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+
+		if verifier = strings.TrimSpace(verifier); verifier == "" {
+			cobra.CheckErr(errors.New("no verification code entered"))
+		}
+
+		accessToken, err := flow.AccessToken(requestToken, verifier)
+		cobra.CheckErr(err)
+
+		credentials := pkg.OAuth1Credentials{ConsumerKey: consumerKey, PrivateKeyFile: keyFile, AccessToken: accessToken}
+		cobra.CheckErr(pkg.SaveOAuth1Credentials(path, credentials))
+		fmt.Printf("stored oauth1 credentials in %s\n", path)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(oauth1Cmd)
+	oauth1Cmd.AddCommand(oauth1LoginCmd)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright © 2022 Marcel Blijleven
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/marcelblijleven/jira-helper/pkg"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// oauth2Cmd groups the OAuth 2.0 commands
+var oauth2Cmd = &cobra.Command{
+	Use:   "oauth2",
+	Short: "Manage the OAuth 2.0 authentication with Jira Cloud",
+}
+
+// oauth2LoginCmd represents the oauth2 login command
+var oauth2LoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Log in to Jira Cloud with OAuth 2.0 and store the token",
+	Long: `Log in to Jira Cloud with the OAuth 2.0 authorization code grant with PKCE.
+
+Open the printed url in a browser and grant access. The token is received on a local
+redirect url and stored, so other commands can use it with --auth-type oauth2. The
+token is refreshed automatically when it expires.
+
+The redirect url, http://localhost:<port>/callback, must be configured as callback url
+of the OAuth 2.0 app.`,
+	PreRunE: requireFlags(clientIdFlagName),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := oauth2TokenPath()
+		cobra.CheckErr(err)
+
+		listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", redirectPort))
+		cobra.CheckErr(err)
+
+		verifier, err := pkg.NewPKCEVerifier()
+		cobra.CheckErr(err)
+		state, err := pkg.NewPKCEVerifier()
+		cobra.CheckErr(err)
+
+		config := pkg.OAuth2Config{
+			ClientId:     clientId,
+			ClientSecret: clientSecret,
+			Scopes:       oauth2Scopes,
+			RedirectURL:  fmt.Sprintf("http://localhost:%d/callback", redirectPort),
+		}
+
+		codes := make(chan string, 1)
+		errs := make(chan error, 1)
+		server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/callback" {
+				http.NotFound(w, r)
+				return
+			}
+
+			if r.URL.Query().Get("state") != state {
+				http.Error(w, "invalid state", http.StatusBadRequest)
+				sendCallbackError(errs, errors.New("received callback with an invalid state"))
+				return
+			}
+
+			if e := r.URL.Query().Get("error"); e != "" {
+				http.Error(w, e, http.StatusBadRequest)
+				sendCallbackError(errs, fmt.Errorf("authorization failed: %s %s", e, r.URL.Query().Get("error_description")))
+				return
+			}
+
+			_, _ = fmt.Fprintln(w, "Logged in, you can close this window.")
+
+			// Only the first callback is handled, e.g. a reload of the page must not block the handler
+			select {
+			case codes <- r.URL.Query().Get("code"):
+			default:
+			}
+		})}
+
+		go func() { _ = server.Serve(listener) }()
+		defer shutdownCallbackServer(server)
+
+		fmt.Printf("Open the following url in your browser to log in:\n\n%s\n\n", config.AuthorizationURL(state, verifier))
+
+		var code string
+
+		select {
+		case code = <-codes:
+		case err = <-errs:
+			cobra.CheckErr(err)
+		case <-time.After(5 * time.Minute):
+			cobra.CheckErr(errors.New("timed out waiting for the authorization"))
+		}
+
+		httpClient, err := newHttpClient()
+		cobra.CheckErr(err)
+		token, err := pkg.ExchangeAuthorizationCode(config, code, verifier, httpClient)
+		cobra.CheckErr(err)
+		cobra.CheckErr(pkg.SaveOAuth2Token(path, *token))
+		fmt.Printf("stored oauth2 token in %s\n", path)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(oauth2Cmd)
+	oauth2Cmd.AddCommand(oauth2LoginCmd)
+	oauth2LoginCmd.Flags().StringSliceVar(&oauth2Scopes, scopesFlagName, []string{"read:jira-work", "write:jira-work", "manage:jira-project", "offline_access"}, scopesUsage)
+	oauth2LoginCmd.Flags().IntVar(&redirectPort, redirectPortFlagName, 8085, redirectPortUsage)
+}
+
+// callbackShutdownTimeout is the maximum duration to wait for the callback requests in progress when logging in
+const callbackShutdownTimeout = 5 * time.Second
+
+// sendCallbackError passes the error of a callback to the login, unless an earlier error is still pending
+func sendCallbackError(errs chan<- error, err error) {
+	select {
+	case errs <- err:
+	default:
+	}
+}
+
+// shutdownCallbackServer stops the callback server, without waiting longer than callbackShutdownTimeout for the
+// requests in progress
+func shutdownCallbackServer(server *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), callbackShutdownTimeout)
+	defer cancel()
+	_ = server.Shutdown(ctx)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/marcelblijleven/jira-helper/pkg"
+	"os"
+)
+
+// newOperation creates an operation for the client. When the journal or resume flag is provided, the journal is opened
+// and set on the operation. The returned function closes the journal.
+func newOperation(client *pkg.JiraClient, transactional bool) (*pkg.Operation, func(), error) {
+	operation := pkg.NewOperation(client, transactional)
+
+	if journalPath != "" && resumePath != "" {
+		return nil, nil, fmt.Errorf("the %s and %s flags cannot be combined", journalFlagName, resumeFlagName)
+	}
+
+	var journal *pkg.Journal
+	var err error
+
+	switch {
+	case resumePath != "":
+		journal, err = pkg.ResumeJournal(resumePath, runId)
+	case journalPath != "":
+		journal, err = pkg.OpenJournal(journalPath, runId)
+	default:
+		return operation, func() {}, nil
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	operation.SetJournal(journal)
+	return operation, func() { _ = journal.Close() }, nil
+}
+
+// runOperation runs the steps and prints the result, which includes the outcome of every step when one of the steps
+// failed or the command was cancelled. The report of a rollback is printed to stderr, so it does not end up in the
+// json or yaml output.
+func runOperation(ctx context.Context, operation *pkg.Operation, steps []pkg.Step) error {
+	err := operation.RunContext(ctx, steps)
+
+	if printErr := printResult(operation.Result()); printErr != nil {
+		return printErr
+	}
+
+	var rollbackErr *pkg.RollbackError
+
+	if errors.As(err, &rollbackErr) {
+		fmt.Fprint(os.Stderr, rollbackErr.Report())
+	}
+
+	return err
+}
+
+// validateIssues validates the issues of the steps before any change is made. With --strict an invalid issue aborts
+// the command, otherwise invalid issues are skipped and moved issues are changed under their current key.
+func validateIssues(ctx context.Context, client *pkg.JiraClient, operation *pkg.Operation, steps []pkg.Step, projects []string) ([]pkg.Step, error) {
+	validation, err := client.ValidateIssuesContext(ctx, pkg.StepIssues(steps), pkg.IssueValidationOptions{Projects: projects, Types: issueTypes})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = validation.Err(); err != nil && strict {
+		return nil, err
+	}
+
+	for _, issue := range validation.Invalid() {
+		operation.SkipIssues(issue.Problem, issue.Key)
+	}
+
+	return validation.Apply(steps), nil
+}
+
+// checkPermissions checks that the user has the required permissions, by project key, before any change is made,
+// unless --skip-permission-check is provided
+func checkPermissions(ctx context.Context, client *pkg.JiraClient, required map[string][]string) error {
+	if skipPermissionCheck {
+		return nil
+	}
+
+	check, err := client.CheckPermissionsContext(ctx, required)
+
+	if err != nil {
+		return err
+	}
+
+	return check.Err()
+}
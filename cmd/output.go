@@ -0,0 +1,12 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/marcelblijleven/jira-helper/pkg"
+)
+
+// printResult writes the result to stdout in the format provided with --output
+func printResult(result *pkg.Result) error {
+	return pkg.WriteResult(os.Stdout, output, result)
+}
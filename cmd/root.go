@@ -17,6 +17,11 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"github.com/marcelblijleven/jira-helper/pkg"
+	"strings"
+
 	"github.com/spf13/cobra"
 )
 
@@ -25,26 +30,130 @@ var rootCmd = &cobra.Command{
 	Use:   "jira-helper",
 	Short: "Helper tool to create and assign version in Jira from the CLI and CI/CD",
 	Long: `Helper tool to interact with Jira from CI/CD scripts. Its main purpose is to create and assign version
-based on Github releases to Jira tickets.`,
+based on Github releases to Jira tickets.
+
+Flags which are not provided are taken from the selected profile in the config file. The host,
+user and token are resolved in the following order: flags, the JIRA_HOST, JIRA_USER and JIRA_TOKEN
+environment variables, the profile, the token file, ~/.netrc and the credential helper.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := pkg.ValidateOutputFormat(output); err != nil {
+			return err
+		}
+
+		if quiet && verbosity != 0 {
+			return fmt.Errorf("the %s and %s flags cannot be combined", verboseFlagName, quietFlagName)
+		}
+
+		if err := applyProfile(cmd); err != nil {
+			return err
+		}
+
+		if err := resolveVersion(); err != nil {
+			return err
+		}
+
+		return resolveCredentials()
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	cobra.CheckErr(rootCmd.Execute())
+	ctx, cancel := signalContext(context.Background())
+	defer cancel()
+	cobra.CheckErr(rootCmd.ExecuteContext(ctx))
 }
 
 func init() {
 	cobra.OnInitialize()
+	rootCmd.PersistentFlags().StringVar(&profileName, profileFlagName, "", profileUsage)
+	rootCmd.PersistentFlags().StringVar(&configPath, configFlagName, "", configUsage)
+	rootCmd.PersistentFlags().StringVarP(&output, outputFlagName, outputShorthand, pkg.OutputText, outputUsage)
+	rootCmd.PersistentFlags().CountVar(&verbosity, verboseFlagName, verboseUsage)
+	rootCmd.PersistentFlags().BoolVarP(&quiet, quietFlagName, quietShorthand, false, quietUsage)
+	rootCmd.PersistentFlags().DurationVar(&timeout, timeoutFlagName, 0, timeoutUsage)
+	rootCmd.PersistentFlags().StringVar(&proxy, proxyFlagName, "", proxyUsage)
+	rootCmd.PersistentFlags().StringVar(&caCert, caCertFlagName, "", caCertUsage)
+	rootCmd.PersistentFlags().StringVar(&clientCert, clientCertFlagName, "", clientCertUsage)
+	rootCmd.PersistentFlags().StringVar(&clientKey, clientKeyFlagName, "", clientKeyUsage)
+	rootCmd.PersistentFlags().StringVar(&minTLSVersion, minTLSVersionFlagName, "", minTLSVersionUsage)
+	rootCmd.PersistentFlags().BoolVar(&insecure, insecureFlagName, false, insecureUsage)
+	rootCmd.PersistentFlags().BoolVar(&trace, traceFlagName, false, traceUsage)
+	rootCmd.PersistentFlags().StringVar(&traceFile, traceFileFlagName, "", traceFileUsage)
+	rootCmd.PersistentFlags().StringVar(&traceFormat, traceFormatFlagName, traceFormatText, traceFormatUsage)
+	rootCmd.PersistentFlags().StringVar(&recordPath, recordFlagName, "", recordUsage)
+	rootCmd.PersistentFlags().StringVar(&replayPath, replayFlagName, "", replayUsage)
+	rootCmd.PersistentFlags().StringVar(&authType, authTypeFlagName, pkg.AuthTypeBasic, authTypeUsage)
 	rootCmd.PersistentFlags().StringVarP(&user, userFlagName, userShorthand, "", userUsage)
 	rootCmd.PersistentFlags().StringVarP(&host, hostFlagName, hostShorthand, "", hostUsage)
 	rootCmd.PersistentFlags().StringVarP(&project, projectFlagName, projectShorthand, "", projectUsage)
 	rootCmd.PersistentFlags().StringVarP(&token, tokenFlagName, tokenShorthand, "", tokenUsage)
+	rootCmd.PersistentFlags().StringVar(&tokenFile, tokenFileFlagName, "", tokenFileUsage)
+	rootCmd.PersistentFlags().StringVar(&credentialHelper, credentialHelperFlagName, "", credentialHelperUsage)
 	rootCmd.PersistentFlags().StringVarP(&version, versionFlagName, versionShorthand, "", versionUsage)
+	rootCmd.PersistentFlags().StringVar(&versionTemplate, versionTemplateFlagName, "", versionTemplateUsage)
+	rootCmd.PersistentFlags().StringVar(&stripPrefix, stripPrefixFlagName, "", stripPrefixUsage)
+	rootCmd.PersistentFlags().BoolVar(&validateSemVer, semVerFlagName, false, semVerUsage)
+	rootCmd.PersistentFlags().StringVar(&clientId, clientIdFlagName, "", clientIdUsage)
+	rootCmd.PersistentFlags().StringVar(&clientSecret, clientSecretFlagName, "", clientSecretUsage)
+	rootCmd.PersistentFlags().StringVar(&oauth2TokenFile, oauth2TokenFileFlagName, "", oauth2TokenFileUsage)
+	rootCmd.PersistentFlags().StringVar(&consumerKey, consumerKeyFlagName, "", consumerKeyUsage)
+	rootCmd.PersistentFlags().StringVar(&privateKeyFile, privateKeyFlagName, "", privateKeyUsage)
+	rootCmd.PersistentFlags().StringVar(&oauth1CredentialsFile, oauth1CredentialsFileFlagName, "", oauth1CredentialsFileUsage)
+}
+
+// resolveVersion strips the prefix from the version and, with a version template or --semver, validates it as a
+// semantic version and renders the version name. With --mapped the template is rendered for every mapping instead.
+func resolveVersion() error {
+	tag := version
+	version = strings.TrimPrefix(version, stripPrefix)
+
+	if version == "" || mapped || (versionTemplate == "" && !validateSemVer) {
+		return nil
+	}
+
+	// The version as provided is the tag, so .Tag keeps the prefix
+	name, err := pkg.VersionName(versionTemplate, tag, stripPrefix, project)
+
+	if err != nil {
+		return err
+	}
+
+	version = name
+	return nil
+}
+
+// resolveCredentials fills in the host, user and token which were not provided through flags from the other
+// credential sources
+func resolveCredentials() error {
+	provided := pkg.Credentials{Host: host, User: user, Token: token}
+	configured := pkg.Credentials{Host: selectedProfile.Host, User: selectedProfile.User}
+	credentials, err := pkg.DefaultCredentialChain(provided, configured, tokenFile, credentialHelper).Resolve()
+
+	if err != nil {
+		return err
+	}
+
+	host, user, token = credentials.Host, credentials.User, credentials.Token
+	return nil
+}
+
+// requireFlags returns a function which can be used as PreRunE of a command to check that the provided flags have a
+// value
+func requireFlags(names ...string) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		var missing []string
+
+		for _, name := range names {
+			if flag := cmd.Flags().Lookup(name); flag == nil || flag.Value.String() == "" {
+				missing = append(missing, fmt.Sprintf("%q", name))
+			}
+		}
+
+		if len(missing) != 0 {
+			return fmt.Errorf("required flag(s) %s not set", strings.Join(missing, ", "))
+		}
 
-	cobra.CheckErr(rootCmd.MarkPersistentFlagRequired(userFlagName))
-	cobra.CheckErr(rootCmd.MarkPersistentFlagRequired(hostFlagName))
-	cobra.CheckErr(rootCmd.MarkPersistentFlagRequired(projectFlagName))
-	cobra.CheckErr(rootCmd.MarkPersistentFlagRequired(tokenFlagName))
-	cobra.CheckErr(rootCmd.MarkPersistentFlagRequired(versionFlagName))
+		return nil
+	}
 }
@@ -0,0 +1,81 @@
+/*
+Copyright © 2022 Marcel Blijleven
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/marcelblijleven/jira-helper/pkg"
+
+	"github.com/spf13/cobra"
+)
+
+// unassignReleaseCmd represents the unassignRelease command
+var unassignReleaseCmd = &cobra.Command{
+	Use:   "unassignRelease",
+	Short: "Removes a version from all provided issues, e.g. to roll back a release",
+	Long: `Removes a version from all provided issues. The issue numbers are retrieved from
+the provided release body, the provided issues and, with --search, from all issues
+in the project that have the version as fix version.
+
+Afterwards the version can be marked as unreleased or deleted.`,
+	Aliases: []string{"unassignVersion", "rollback"},
+	PreRunE: requireFlags(hostFlagName, projectFlagName, versionFlagName),
+	Run: func(cmd *cobra.Command, args []string) {
+		if body == "" && (issues == nil || len(issues) == 0) && !searchIssues {
+			cobra.CheckErr(errors.New("no issues provided. Provide issue through the issues and/or releaseBody flags or use the search flag"))
+		}
+
+		if unreleaseVersion && deleteVersion {
+			cobra.CheckErr(fmt.Errorf("the %s and %s flags cannot be combined", unreleaseFlagName, deleteFlagName))
+		}
+
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		client, err := newJiraClient()
+		cobra.CheckErr(err)
+		steps, err := pkg.UnassignVersionSteps(ctx, client, body, version, project, issues, filter, searchIssues)
+		cobra.CheckErr(err)
+
+		if deleteVersion {
+			steps = append(steps, &pkg.DeleteVersionStep{Name: version, Project: project})
+		} else if unreleaseVersion {
+			steps = append(steps, &pkg.UnreleaseVersionStep{Name: version, Project: project})
+		}
+
+		operation, closeJournal, err := newOperation(client, false)
+		cobra.CheckErr(err)
+		defer closeJournal()
+		steps, err = validateIssues(ctx, client, operation, steps, []string{project})
+		cobra.CheckErr(err)
+		cobra.CheckErr(checkPermissions(ctx, client, pkg.RequiredPermissions(steps)))
+		cobra.CheckErr(runOperation(ctx, operation, steps))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(unassignReleaseCmd)
+	unassignReleaseCmd.Flags().StringVarP(&body, bodyFlagName, bodyShorthand, "", bodyUsage)
+	unassignReleaseCmd.Flags().StringSliceVarP(&issues, issuesFlagName, issuesShorthand, []string{}, issuesUsage)
+	unassignReleaseCmd.Flags().StringSliceVarP(&filter, filterFlagName, filterShorthand, []string{}, filterUsage)
+	unassignReleaseCmd.Flags().BoolVar(&searchIssues, searchFlagName, false, searchUsage)
+	unassignReleaseCmd.Flags().BoolVar(&unreleaseVersion, unreleaseFlagName, false, unreleaseUsage)
+	unassignReleaseCmd.Flags().BoolVar(&deleteVersion, deleteFlagName, false, deleteUsage)
+	unassignReleaseCmd.Flags().BoolVar(&strict, strictFlagName, false, strictUsage)
+	unassignReleaseCmd.Flags().StringSliceVar(&issueTypes, issueTypeFlagName, []string{}, issueTypeUsage)
+	unassignReleaseCmd.Flags().BoolVar(&skipPermissionCheck, skipPermissionCheckFlagName, false, skipPermissionCheckUsage)
+}
@@ -1,28 +1,148 @@
 package cmd
 
+import (
+	"github.com/marcelblijleven/jira-helper/pkg"
+	"regexp"
+	"time"
+)
+
 var (
-	user    string
-	host    string
-	token   string
-	project string
-	version string
-	body    string
-	issues  []string
-	filter  []string
+	authType string
+	user     string
+	host     string
+	token    string
+	project  string
+	version  string
+	body     string
+	issues   []string
+	filter   []string
+
+	setFields []string
+
+	fixVersions           []string
+	affectsVersions       []string
+	removeFixVersions     []string
+	removeAffectsVersions []string
+	replaceFixVersions    bool
+
+	searchIssues     bool
+	unreleaseVersion bool
+	deleteVersion    bool
+	archiveVersions  bool
+
+	transactional bool
+
+	journalPath string
+	resumePath  string
+	runId       string
+
+	clientId        string
+	clientSecret    string
+	oauth2TokenFile string
+	oauth2Scopes    []string
+	redirectPort    int
+
+	consumerKey           string
+	privateKeyFile        string
+	oauth1CredentialsFile string
+
+	tokenFile        string
+	credentialHelper string
+
+	profileName     string
+	configPath      string
+	selectedProfile = &pkg.Profile{}
+	loadedConfig    = &pkg.Config{}
+	issuePattern    *regexp.Regexp
+
+	versionTemplate string
+	stripPrefix     string
+	validateSemVer  bool
+
+	output    string
+	verbosity int
+	quiet     bool
+	timeout   time.Duration
+
+	proxy         string
+	caCert        string
+	clientCert    string
+	clientKey     string
+	minTLSVersion string
+	insecure      bool
+
+	trace       bool
+	traceFile   string
+	traceFormat string
+
+	recordPath string
+	replayPath string
+
+	mockPort int
+	seedPath string
+
+	mapped       bool
+	tag          string
+	changedPaths []string
+
+	strict     bool
+	issueTypes []string
+
+	skipPermissionCheck bool
+	operations          []string
 )
 
 const (
+	authTypeFlagName = "auth-type"
+	authTypeUsage    = "Authentication method: basic (user and API token), bearer (personal access token), anonymous, oauth1, oauth2 or oauth2-client-credentials"
+
+	clientIdFlagName = "client-id"
+	clientIdUsage    = "Client id of the OAuth 2.0 app"
+
+	clientSecretFlagName = "client-secret"
+	clientSecretUsage    = "Client secret of the OAuth 2.0 app"
+
+	oauth2TokenFileFlagName = "oauth2-token-file"
+	oauth2TokenFileUsage    = "File in which the OAuth 2.0 token is stored. Defaults to oauth2-token.json in the jira-helper config directory"
+
+	scopesFlagName = "scopes"
+	scopesUsage    = "OAuth 2.0 scopes to request"
+
+	redirectPortFlagName = "redirect-port"
+	redirectPortUsage    = "Port of the local redirect url which receives the authorization code"
+
+	consumerKeyFlagName = "consumer-key"
+	consumerKeyUsage    = "Consumer key of the OAuth 1.0a application link"
+
+	privateKeyFlagName = "private-key"
+	privateKeyUsage    = "File with the PEM encoded RSA private key of the OAuth 1.0a application link"
+
+	oauth1CredentialsFileFlagName = "oauth1-credentials-file"
+	oauth1CredentialsFileUsage    = "File in which the OAuth 1.0a credentials are stored. Defaults to oauth1-credentials.json in the jira-helper config directory"
+
 	userFlagName  = "user"
 	userShorthand = "u"
-	userUsage     = "User (email) for authenticating against the Jira API"
+	userUsage     = "User (email) for authenticating against the Jira API. Required for basic authentication"
 
 	hostFlagName  = "host"
 	hostShorthand = "s"
 	hostUsage     = "Host of the Jira API. If the host URL contains a scheme (e.g. https), you must include it"
 
+	tokenFileFlagName = "token-file"
+	tokenFileUsage    = "File which contains the token, used when the token is not provided through --token or JIRA_TOKEN"
+
+	credentialHelperFlagName = "credential-helper"
+	credentialHelperUsage    = "Executable which provides the user and token through the git credential helper protocol"
+
+	profileFlagName = "profile"
+	profileUsage    = "Profile from the config file to use. Defaults to the current profile"
+
+	configFlagName = "config"
+	configUsage    = "Config file to use instead of ~/.config/jira-helper/config.yaml and .jira-helper.yaml"
+
 	tokenFlagName  = "token"
 	tokenShorthand = "t"
-	tokenUsage     = "Token used to authenticate against the Jira API"
+	tokenUsage     = "Token used to authenticate against the Jira API, an API token or a personal access token"
 
 	projectFlagName  = "project"
 	projectShorthand = "p"
@@ -43,4 +163,132 @@ const (
 	filterFlagName  = "filter"
 	filterShorthand = "f"
 	filterUsage     = "The filter flag allows you to ignore issues when assigning a release"
+
+	setFieldFlagName = "set-field"
+	setFieldUsage    = "Set a (custom) field on the issues, e.g. \"Deployed to=production\". Can be provided multiple times"
+
+	fixVersionFlagName = "fix-version"
+	fixVersionUsage    = "Additional fix versions to add to the issues besides the version, can be a single version or comma separated"
+
+	affectsVersionFlagName = "affects-version"
+	affectsVersionUsage    = "Affects versions to add to the issues, can be a single version or comma separated"
+
+	removeFixVersionFlagName = "remove-fix-version"
+	removeFixVersionUsage    = "Fix versions to remove from the issues, can be a single version or comma separated"
+
+	removeAffectsVersionFlagName = "remove-affects-version"
+	removeAffectsVersionUsage    = "Affects versions to remove from the issues, can be a single version or comma separated"
+
+	replaceFlagName = "replace"
+	replaceUsage    = "Replace all fix versions of the issues with the version and additional fix versions instead of adding them"
+
+	searchFlagName = "search"
+	searchUsage    = "Also find the issues in the project which have the version as fix version"
+
+	unreleaseFlagName = "unrelease"
+	unreleaseUsage    = "Mark the version as unreleased after removing it from the issues"
+
+	deleteFlagName = "delete"
+	deleteUsage    = "Delete the version after removing it from the issues"
+
+	archiveFlagName = "archive"
+	archiveUsage    = "Archive the pre-release versions after moving their issues, instead of merging them into the final version"
+
+	transactionalFlagName = "transactional"
+	transactionalUsage    = "Undo all changes made by the command when one of them fails"
+
+	journalFlagName = "journal"
+	journalUsage    = "Record the completed steps in the provided journal file, so the run can be resumed with --resume"
+
+	resumeFlagName = "resume"
+	resumeUsage    = "Resume the run recorded in the provided journal file, skipping the steps which were already completed"
+
+	versionTemplateFlagName = "version-template"
+	versionTemplateUsage    = "Go template for the version name, e.g. \"Backend {{.Major}}.{{.Minor}}.{{.Patch}}\". The version is parsed as a semantic version"
+
+	stripPrefixFlagName = "strip-prefix"
+	stripPrefixUsage    = "Prefix to strip from the version, e.g. release-"
+
+	semVerFlagName = "semver"
+	semVerUsage    = "Validate the version as a semantic version and strip its leading v"
+
+	outputFlagName  = "output"
+	outputShorthand = "o"
+	outputUsage     = "Output format: text, json, yaml, table or template=<go template>, e.g. template={{range .Versions}}{{.Id}}{{end}}"
+
+	verboseFlagName = "verbose"
+	verboseUsage    = "Log progress to stderr, repeat to also log every request"
+
+	quietFlagName  = "quiet"
+	quietShorthand = "q"
+	quietUsage     = "Only log errors to stderr"
+
+	timeoutFlagName = "timeout"
+	timeoutUsage    = "Maximum duration of the command, e.g. 30s or 5m. Steps which were not completed in time are reported as not run"
+
+	proxyFlagName = "proxy"
+	proxyUsage    = "Url of the proxy to reach Jira through, e.g. http://proxy.example.com:8080. Defaults to the HTTPS_PROXY environment variable"
+
+	caCertFlagName = "ca-cert"
+	caCertUsage    = "PEM file with the CA certificates to trust in addition to the system certificates"
+
+	clientCertFlagName = "client-cert"
+	clientCertUsage    = "PEM file with the client certificate for mutual TLS, requires --client-key"
+
+	clientKeyFlagName = "client-key"
+	clientKeyUsage    = "PEM file with the key of the client certificate"
+
+	minTLSVersionFlagName = "min-tls-version"
+	minTLSVersionUsage    = "Minimum TLS version: 1.0, 1.1, 1.2 or 1.3"
+
+	insecureFlagName = "insecure"
+	insecureUsage    = "Skip the verification of the TLS certificate of Jira. Only use this for testing"
+
+	traceFlagName = "trace"
+	traceUsage    = "Log every request and response to stderr, with the authorization headers and secrets redacted"
+
+	traceFileFlagName = "trace-file"
+	traceFileUsage    = "Write the trace to the provided file instead of stderr, implies --trace"
+
+	traceFormatFlagName = "trace-format"
+	traceFormatUsage    = "Format of the trace: text or har. The har format requires --trace-file and can be opened in the network tab of the browser developer tools"
+
+	recordFlagName = "record"
+	recordUsage    = "Record the requests and responses in the provided cassette file, with the authorization headers and secrets scrubbed"
+
+	replayFlagName = "replay"
+	replayUsage    = "Replay the responses recorded in the provided cassette file instead of sending the requests to Jira"
+
+	portFlagName = "port"
+	portUsage    = "Port to serve the fake Jira API on"
+
+	seedFlagName = "seed"
+	seedUsage    = "Yaml file with the projects, versions, issues and fields to start the fake Jira API with"
+
+	traceFormatText = "text"
+	traceFormatHAR  = "har"
+
+	mappedFlagName = "mapped"
+	mappedUsage    = "Use the mappings of the repository config to create a version in the project of every mapping"
+
+	tagFlagName = "tag"
+	tagUsage    = "Tag of the release, selects the mappings with a matching tag prefix and provides the version when it is not set"
+
+	pathsFlagName = "paths"
+	pathsUsage    = "Changed paths, e.g. from git diff --name-only, selects the mappings with a matching path"
+
+	runIdFlagName = "run-id"
+	runIdUsage    = "Id of the run to record or resume. Defaults to a generated id or, when resuming, the last run in the journal"
+
+	strictFlagName = "strict"
+	strictUsage    = "Abort before any change when one of the issues does not exist, is not visible or does not match the project or issue types"
+
+	issueTypeFlagName = "issue-type"
+	issueTypeUsage    = "Only accept issues of the provided types, e.g. Story,Bug"
+
+	skipPermissionCheckFlagName = "skip-permission-check"
+	skipPermissionCheckUsage    = "Do not check that the user has the permissions the command needs before any change is made"
+
+	operationFlagName = "operation"
+	operationUsage    = "Commands to check the permissions of, e.g. createAndAssign,unassignRelease. Defaults to all commands which change Jira"
 )
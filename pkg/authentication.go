@@ -1,15 +1,101 @@
 package pkg
 
-import "net/http"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
 
-// authenticationService is used to authenticate requests
-type authenticationService struct {
-	client *JiraClient
-	email  string
-	token  string
+const (
+	AuthTypeBasic     = "basic"
+	AuthTypeBearer    = "bearer"
+	AuthTypeAnonymous = "anonymous"
+)
+
+// Authenticator is used to authenticate requests
+type Authenticator interface {
+	// Authenticate adds the credentials to the provided request
+	Authenticate(req *http.Request) error
+}
+
+// NewAuthenticator creates the authenticator for the provided auth type. The user is only used for basic
+// authentication.
+func NewAuthenticator(authType, user, token string) (Authenticator, error) {
+	var authenticator Authenticator
+	var err error
+
+	if authType = strings.ToLower(authType); authType == "" {
+		authType = AuthTypeBasic
+	}
+
+	switch authType {
+	case AuthTypeBasic:
+		authenticator, err = NewBasicAuthenticator(user, token)
+	case AuthTypeBearer, "pat":
+		authenticator, err = NewBearerAuthenticator(token)
+	case AuthTypeAnonymous, "none":
+		return AnonymousAuthenticator{}, nil
+	default:
+		return nil, fmt.Errorf("unknown auth type %q, supported auth types are %s, %s and %s", authType, AuthTypeBasic, AuthTypeBearer, AuthTypeAnonymous)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid credentials for %s authentication: %w", authType, err)
+	}
+
+	return authenticator, nil
+}
+
+// BasicAuthenticator authenticates requests with an email and API token, as used by Jira Cloud
+type BasicAuthenticator struct {
+	email string
+	token string
 }
 
-// setBasisAuth sets the username and password on the provided request
-func (a *authenticationService) setBasicAuth(req *http.Request) {
+// NewBasicAuthenticator creates a BasicAuthenticator with the provided email and token
+func NewBasicAuthenticator(email, token string) (*BasicAuthenticator, error) {
+	if email == "" {
+		return nil, errors.New("email cannot be empty")
+	}
+
+	if token == "" {
+		return nil, errors.New("token cannot be empty")
+	}
+
+	return &BasicAuthenticator{email: email, token: token}, nil
+}
+
+// Authenticate sets the email and token as username and password on the provided request
+func (a *BasicAuthenticator) Authenticate(req *http.Request) error {
 	req.SetBasicAuth(a.email, a.token)
+	return nil
+}
+
+// BearerAuthenticator authenticates requests with a personal access token, as used by Jira Server and Data Center
+type BearerAuthenticator struct {
+	token string
+}
+
+// NewBearerAuthenticator creates a BearerAuthenticator with the provided personal access token
+func NewBearerAuthenticator(token string) (*BearerAuthenticator, error) {
+	if token == "" {
+		return nil, errors.New("token cannot be empty")
+	}
+
+	return &BearerAuthenticator{token: token}, nil
+}
+
+// Authenticate sets the token in the authorization header of the provided request
+func (a *BearerAuthenticator) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	return nil
+}
+
+// AnonymousAuthenticator does not authenticate requests, for Jira instances which allow anonymous access
+type AnonymousAuthenticator struct{}
+
+// Authenticate leaves the provided request untouched
+func (AnonymousAuthenticator) Authenticate(*http.Request) error {
+	return nil
 }
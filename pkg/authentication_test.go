@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestNewAuthenticator(t *testing.T) {
+	tests := []struct {
+		name       string
+		authType   string
+		user       string
+		token      string
+		wantHeader string
+		wantErr    string
+	}{
+		{name: "default", authType: "", user: "marcel@test.nl", token: "c0ffee", wantHeader: "Basic bWFyY2VsQHRlc3Qubmw6YzBmZmVl"},
+		{name: "basic", authType: "basic", user: "marcel@test.nl", token: "c0ffee", wantHeader: "Basic bWFyY2VsQHRlc3Qubmw6YzBmZmVl"},
+		{name: "basic without user", authType: "basic", token: "c0ffee", wantErr: "invalid credentials for basic authentication: email cannot be empty"},
+		{name: "bearer", authType: "bearer", token: "c0ffee", wantHeader: "Bearer c0ffee"},
+		{name: "pat alias", authType: "PAT", user: "ignored", token: "c0ffee", wantHeader: "Bearer c0ffee"},
+		{name: "bearer without token", authType: "bearer", wantErr: "invalid credentials for bearer authentication: token cannot be empty"},
+		{name: "anonymous", authType: "anonymous", wantHeader: ""},
+		{name: "unknown", authType: "kerberos", wantErr: "unknown auth type \"kerberos\", supported auth types are basic, bearer and anonymous"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator, err := NewAuthenticator(tt.authType, tt.user, tt.token)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			req, _ := http.NewRequest(http.MethodGet, "https://test.nu", nil)
+			assert.NoError(t, authenticator.Authenticate(req))
+			assert.Equal(t, tt.wantHeader, req.Header.Get("Authorization"))
+		})
+	}
+}
+
+func TestNewJiraClientWithAuthenticator_bearer(t *testing.T) {
+	mockClient := NewMockHttpClient(t, 201)
+	authenticator, err := NewBearerAuthenticator("c0ffee")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jiraClient, err := NewJiraClientWithAuthenticator("https://test.nu", authenticator, mockClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, jiraClient.AssignVersion("MB-1337", "My first release"))
+	assert.Equal(t, "Bearer c0ffee", mockClient.CalledHeaders.Get("Authorization"))
+}
+
+func TestNewJiraClientWithAuthenticator_missingAuthenticator(t *testing.T) {
+	c, err := NewJiraClientWithAuthenticator("https://test.nu", nil, nil)
+	assert.Nil(t, c)
+	assert.EqualError(t, err, "could not create jira client: authenticator cannot be nil")
+}
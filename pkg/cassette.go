@@ -0,0 +1,196 @@
+package pkg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Cassette holds the recorded requests and responses of a session with Jira
+type Cassette struct {
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+// Interaction is a single recorded request and its response. Error is set when no response was received.
+type Interaction struct {
+	Request  RecordedRequest  `yaml:"request"`
+	Response RecordedResponse `yaml:"response,omitempty"`
+	Error    string           `yaml:"error,omitempty"`
+}
+
+// RecordedRequest is a request in a cassette
+type RecordedRequest struct {
+	Method  string      `yaml:"method"`
+	URL     string      `yaml:"url"`
+	Headers http.Header `yaml:"headers,omitempty"`
+	Body    string      `yaml:"body,omitempty"`
+}
+
+// RecordedResponse is a response in a cassette
+type RecordedResponse struct {
+	Status  int         `yaml:"status"`
+	Headers http.Header `yaml:"headers,omitempty"`
+	Body    string      `yaml:"body,omitempty"`
+}
+
+// LoadCassette reads the cassette from the provided file
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read cassette: %w", err)
+	}
+
+	var cassette Cassette
+
+	if err = yaml.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("could not parse cassette %s: %w", path, err)
+	}
+
+	return &cassette, nil
+}
+
+// Save writes the cassette to the provided file
+func (c *Cassette) Save(path string) error {
+	data, err := yaml.Marshal(c)
+
+	if err != nil {
+		return fmt.Errorf("could not marshall cassette: %w", err)
+	}
+
+	if err = ioutil.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("could not write cassette: %w", err)
+	}
+
+	return nil
+}
+
+// Recorder is an HttpClient which sends the requests with another HttpClient and records them with their responses in
+// a cassette. The sensitive headers, the OAuth 2.0 token fields and secrets are scrubbed from the cassette, which is
+// written after every request, so it is complete when the command is interrupted.
+type Recorder struct {
+	httpClient HttpClient
+	path       string
+	secrets    []string
+	cassette   Cassette
+	mu         sync.Mutex
+}
+
+// NewRecorder creates a recorder which sends the requests with the provided http client and writes the cassette to
+// the provided path
+func NewRecorder(httpClient HttpClient, path string, secrets ...string) *Recorder {
+	return &Recorder{httpClient: httpClient, path: path, secrets: secrets}
+}
+
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	interaction := Interaction{Request: RecordedRequest{
+		Method:  req.Method,
+		URL:     redactText(req.URL.String(), r.secrets),
+		Headers: redactHeaders(req.Header, r.secrets),
+		Body:    redactText(string(requestBody(req)), r.secrets),
+	}}
+
+	res, err := r.httpClient.Do(req)
+
+	if err != nil {
+		interaction.Error = redactText(err.Error(), r.secrets)
+	} else {
+		data, readErr := ioutil.ReadAll(res.Body)
+		_ = res.Body.Close()
+
+		if readErr != nil {
+			return nil, fmt.Errorf("could not record response: %w", readErr)
+		}
+
+		res.Body = ioutil.NopCloser(bytes.NewReader(data))
+		interaction.Response = RecordedResponse{
+			Status:  res.StatusCode,
+			Headers: redactHeaders(res.Header, r.secrets),
+			Body:    redactText(string(data), r.secrets),
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+
+	if saveErr := r.cassette.Save(r.path); saveErr != nil {
+		return nil, saveErr
+	}
+
+	return res, err
+}
+
+// Replayer is an HttpClient which answers requests with the responses recorded in a cassette, without network access.
+// A request is answered with the first interaction with the same method and url which was not replayed yet, so a
+// request which was repeated during the recording gets the recorded responses in order.
+type Replayer struct {
+	interactions []Interaction
+	replayed     []bool
+	mu           sync.Mutex
+}
+
+// NewReplayer creates a replayer for the interactions of the cassette
+func NewReplayer(cassette *Cassette) *Replayer {
+	return &Replayer{interactions: cassette.Interactions, replayed: make([]bool, len(cassette.Interactions))}
+}
+
+func (r *Replayer) Do(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.replayed[i] || interaction.Request.Method != req.Method || interaction.Request.URL != req.URL.String() {
+			continue
+		}
+
+		r.replayed[i] = true
+
+		if interaction.Error != "" {
+			return nil, errors.New(interaction.Error)
+		}
+
+		status := interaction.Response.Status
+		headers := interaction.Response.Headers
+
+		if headers == nil {
+			headers = http.Header{}
+		}
+
+		return &http.Response{
+			Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+			StatusCode:    status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        headers.Clone(),
+			Body:          ioutil.NopCloser(bytes.NewReader([]byte(interaction.Response.Body))),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL)
+}
+
+// Remaining returns the interactions which were not replayed yet
+func (r *Replayer) Remaining() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var remaining []Interaction
+
+	for i, interaction := range r.interactions {
+		if !r.replayed[i] {
+			remaining = append(remaining, interaction)
+		}
+	}
+
+	return remaining
+}
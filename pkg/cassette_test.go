@@ -0,0 +1,96 @@
+package pkg
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecorder_Do(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	mockClient := mockHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/rest/api/latest/issue/MB-2" {
+			return nil, errors.New("connection reset by peer")
+		}
+
+		return newMockResponse(http.StatusCreated, `{"id":"10000","name":"1.0.0","description":"s3cret"}`), nil
+	})
+	recorder := NewRecorder(mockClient, path, "s3cret")
+	jiraClient, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", recorder)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := jiraClient.CreateFixVersion("1.0.0", "MB")
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", version.Description)
+	assert.Error(t, jiraClient.AssignVersion("MB-2", "1.0.0"))
+
+	cassette, err := LoadCassette(path)
+	assert.NoError(t, err)
+	assert.Len(t, cassette.Interactions, 2)
+
+	created := cassette.Interactions[0]
+	assert.Equal(t, RecordedRequest{
+		Method:  http.MethodPost,
+		URL:     "https://test.nu/rest/api/latest/version",
+		Headers: http.Header{"Authorization": {redacted}, "Content-Type": {"application/json"}},
+		Body:    `{"name":"1.0.0","released":true,"releaseDate":"` + getDateString() + `","project":"MB"}`,
+	}, created.Request)
+	assert.Equal(t, RecordedResponse{
+		Status:  http.StatusCreated,
+		Headers: http.Header{"Content-Type": {"application/json"}},
+		Body:    `{"id":"10000","name":"1.0.0","description":"[REDACTED]"}`,
+	}, created.Response)
+	assert.Equal(t, "connection reset by peer", cassette.Interactions[1].Error)
+
+	replayClient, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", NewReplayer(cassette))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version, err = replayClient.CreateFixVersion("1.0.0", "MB")
+	assert.NoError(t, err)
+	assert.Equal(t, "10000", version.Id)
+	err = replayClient.AssignVersion("MB-2", "1.0.0")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "connection reset by peer")
+}
+
+func TestRecorder_Do_oauth2(t *testing.T) {
+	var tokenRequests []url.Values
+	server := newFakeOAuth2Server(t, &tokenRequests)
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	recorder := NewRecorder(server.Client(), path)
+	config := OAuth2Config{ClientId: "client", ClientSecret: "s3cret", TokenURL: server.URL + "/oauth/token", APIURL: server.URL}
+
+	_, err := ExchangeAuthorizationCode(config, "auth-code", "code-verifier", recorder)
+	assert.NoError(t, err)
+
+	expired := OAuth2Token{AccessToken: "expired", RefreshToken: "refresh-0", Expiry: time.Now().Add(-time.Minute)}
+	authenticator, err := NewOAuth2Authenticator(config, expired, recorder)
+	assert.NoError(t, err)
+	baseURL, err := authenticator.BaseURL("https://your-domain.atlassian.net/")
+	assert.NoError(t, err)
+	client, err := NewJiraClientWithAuthenticator(baseURL, authenticator, recorder)
+	assert.NoError(t, err)
+	assert.NoError(t, client.AssignVersion("MB-1", "1.0.0"))
+	assert.Len(t, tokenRequests, 2)
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	for _, secret := range []string{"s3cret", "auth-code", "code-verifier", "refresh-0", "access-1", "refresh-1", "access-2", "refresh-2"} {
+		assert.NotContains(t, string(data), secret)
+	}
+
+	assert.Contains(t, string(data), "refresh_token="+redacted)
+	assert.Contains(t, string(data), `"access_token":"`+redacted+`"`)
+}
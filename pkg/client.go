@@ -2,12 +2,17 @@ package pkg
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strings"
+	"time"
 )
 
 const (
@@ -18,7 +23,13 @@ const (
 type JiraClient struct {
 	host           *url.URL
 	httpClient     HttpClient
-	authentication *authenticationService
+	authentication Authenticator
+	fields         []Field
+	logger         Logger
+	observer       Observer
+	tracer         Tracer
+	secrets        []string
+	issuePattern   *regexp.Regexp
 }
 
 // HttpClient is the http client interface used by the Jira client
@@ -26,18 +37,30 @@ type HttpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
-// NewJiraClient creates a new JiraClient with the provided values
+// NewJiraClient creates a new JiraClient with the provided values, which authenticates using basic authentication
 func NewJiraClient(host, email, token string, httpClient HttpClient) (*JiraClient, error) {
 	if host == "" {
 		return nil, errors.New("could not create jira client: hostname cannot be empty")
 	}
 
-	if email == "" {
-		return nil, errors.New("could not create jira client: email cannot be empty")
+	authenticator, err := NewBasicAuthenticator(email, token)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not create jira client: %w", err)
+	}
+
+	return NewJiraClientWithAuthenticator(host, authenticator, httpClient)
+}
+
+// NewJiraClientWithAuthenticator creates a new JiraClient which authenticates its requests with the provided
+// authenticator
+func NewJiraClientWithAuthenticator(host string, authenticator Authenticator, httpClient HttpClient) (*JiraClient, error) {
+	if host == "" {
+		return nil, errors.New("could not create jira client: hostname cannot be empty")
 	}
 
-	if token == "" {
-		return nil, errors.New("could not create jira client: token cannot be empty")
+	if authenticator == nil {
+		return nil, errors.New("could not create jira client: authenticator cannot be nil")
 	}
 
 	u, err := url.Parse(host)
@@ -46,49 +69,67 @@ func NewJiraClient(host, email, token string, httpClient HttpClient) (*JiraClien
 		return nil, fmt.Errorf("could not create jira client, invalid host provided: %w", err)
 	}
 
-	client := &JiraClient{host: u, httpClient: httpClient}
-	client.authentication = &authenticationService{
-		client: client,
-		email:  email,
-		token:  token,
-	}
-	return client, nil
+	return &JiraClient{host: u, httpClient: httpClient, authentication: authenticator}, nil
 }
 
-// createRequest creates a request with the provided method and body
-func (c *JiraClient) createRequest(method, endpoint string, body interface{}) (*http.Request, error) {
-	e, err := url.Parse(endpoint)
+// createRequest creates a request with the provided method and body, which is cancelled together with the context
+func (c *JiraClient) createRequest(ctx context.Context, method, endpoint string, body interface{}) (*http.Request, error) {
+	// Keep the path of the host, e.g. the context path of a Jira Server instance or the cloud id in an OAuth 2.0 url
+	e, err := url.Parse(strings.TrimSuffix(c.host.Path, "/") + endpoint)
 
 	if err != nil {
 		return nil, fmt.Errorf("could not parse endpoint: %w", err)
 	}
 
-	data, err := json.Marshal(body)
+	var reader io.Reader = http.NoBody
 
-	if err != nil {
-		return nil, fmt.Errorf("could not marshall provided body to json: %w", err)
+	if body != nil {
+		data, marshallErr := json.Marshal(body)
+
+		if marshallErr != nil {
+			return nil, fmt.Errorf("could not marshall provided body to json: %w", marshallErr)
+		}
+
+		reader = bytes.NewReader(data)
 	}
 
 	u := c.host.ResolveReference(e).String()
 
-	req, err := http.NewRequest(method, u, bytes.NewReader(data))
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
 
 	if err != nil {
 		return nil, fmt.Errorf("could not create request: %w", err)
 	}
 
-	c.authentication.setBasicAuth(req)
+	if err = c.authentication.Authenticate(req); err != nil {
+		return nil, fmt.Errorf("could not authenticate request: %w", err)
+	}
+
 	req.Header.Add("Content-Type", "application/json")
 	return req, nil
 }
 
 func (c *JiraClient) doRequest(req *http.Request, target interface{}) error {
+	start := time.Now()
+	c.emit(Event{Type: EventRequestStart, Time: start, Method: req.Method, URL: req.URL.String()})
+	c.log().Debug("sending request", "method", req.Method, "url", req.URL.String())
 	res, err := c.httpClient.Do(req)
 
+	if c.tracer != nil {
+		c.trace(req, res, err, start)
+	}
+
+	duration := time.Since(start)
+
 	if err != nil {
+		c.emit(Event{Type: EventRequestEnd, Method: req.Method, URL: req.URL.String(), Duration: duration, Err: err})
+		c.log().Debug("request failed", "method", req.Method, "url", req.URL.String(), "duration", duration, "error", err)
 		return fmt.Errorf("could not do request: %w", err)
 	}
 
+	c.emit(Event{Type: EventRequestEnd, Method: req.Method, URL: req.URL.String(), Status: res.StatusCode, Duration: duration})
+	c.log().Debug("received response", "method", req.Method, "url", req.URL.String(), "status", res.StatusCode, "duration", duration)
+
 	if res.StatusCode/100 != 2 {
 		return handleJiraError(res)
 	}
@@ -111,44 +152,128 @@ func (c *JiraClient) doRequest(req *http.Request, target interface{}) error {
 
 // AssignVersion calls the issue endpoint to add a fixVersion to the issue
 func (c *JiraClient) AssignVersion(issue, version string) error {
-	endpoint := fmt.Sprintf("%s/issue/%s", apiEndpoint, issue)
-	body, err := newAssignRequestBody(version)
+	return c.AssignVersionContext(context.Background(), issue, version)
+}
+
+// AssignVersionContext is AssignVersion with a context which cancels the request
+func (c *JiraClient) AssignVersionContext(ctx context.Context, issue, version string) error {
+	return c.assignVersion(ctx, issue, version, nil)
+}
+
+// AssignVersionWithFields calls the issue endpoint to add a fixVersion to the issue and sets the provided fields. The
+// fields can be referenced by name or by id.
+func (c *JiraClient) AssignVersionWithFields(issue, version string, fields map[string]string) error {
+	return c.AssignVersionWithFieldsContext(context.Background(), issue, version, fields)
+}
+
+// AssignVersionWithFieldsContext is AssignVersionWithFields with a context which cancels the requests
+func (c *JiraClient) AssignVersionWithFieldsContext(ctx context.Context, issue, version string, fields map[string]string) error {
+	values, err := c.ResolveFieldValuesContext(ctx, fields)
+
+	if err != nil {
+		return fmt.Errorf("could not resolve fields: %w", err)
+	}
+
+	return c.assignVersion(ctx, issue, version, values)
+}
+
+// assignVersion adds a fixVersion to the issue and sets the already resolved field values
+func (c *JiraClient) assignVersion(ctx context.Context, issue, version string, fields map[string]interface{}) error {
+	body, err := newAssignRequestBody(version, fields)
 
 	if err != nil {
 		return fmt.Errorf("could not create assign version request body: %w", err)
 	}
 
-	req, err := c.createRequest(http.MethodPut, endpoint, body)
+	if err = c.updateIssue(ctx, issue, body); err != nil {
+		return err
+	}
+
+	c.emit(Event{Type: EventIssueAssigned, Issue: issue, Version: version})
+	c.log().Info("assigned version", "issue", issue, "version", version)
+	return nil
+}
+
+// UpdateIssue calls the issue endpoint to add, remove or replace the fix versions and affects versions of the issue
+// and to set the provided fields
+func (c *JiraClient) UpdateIssue(issue string, update IssueUpdate) error {
+	return c.UpdateIssueContext(context.Background(), issue, update)
+}
+
+// UpdateIssueContext is UpdateIssue with a context which cancels the requests
+func (c *JiraClient) UpdateIssueContext(ctx context.Context, issue string, update IssueUpdate) error {
+	values, err := c.ResolveFieldValuesContext(ctx, update.Fields)
 
-	if err = c.doRequest(req, nil); err != nil {
+	if err != nil {
+		return fmt.Errorf("could not resolve fields: %w", err)
+	}
+
+	body, err := newUpdateRequestBody(update.FixVersions, update.AffectsVersions, values)
+
+	if err != nil {
+		return fmt.Errorf("could not create update issue request body: %w", err)
+	}
+
+	if err = c.updateIssue(ctx, issue, body); err != nil {
 		return err
 	}
 
-	fmt.Printf("assigned version %q to %q\n", version, issue)
+	c.issueUpdated(issue)
 	return nil
 }
 
-// CreateFixVersion calls the version endpoint to add a fixVersion to the provided project
-func (c *JiraClient) CreateFixVersion(name, project string) error {
+// issueUpdated reports that the versions or fields of the issue were updated
+func (c *JiraClient) issueUpdated(issue string) {
+	c.emit(Event{Type: EventIssueUpdated, Issue: issue})
+	c.log().Info("updated issue", "issue", issue)
+}
+
+// updateIssue sends the update request body to the issue endpoint
+func (c *JiraClient) updateIssue(ctx context.Context, issue string, body *updateRequestBody) error {
+	endpoint := fmt.Sprintf("%s/issue/%s", apiEndpoint, issue)
+	req, err := c.createRequest(ctx, http.MethodPut, endpoint, body)
+
+	if err != nil {
+		return err
+	}
+
+	return c.doRequest(req, nil)
+}
+
+// CreateFixVersion calls the version endpoint to add a fixVersion to the provided project and returns the created
+// version. The version is released today, unless its name contains a pre-release, e.g. 1.4.0-rc.1.
+func (c *JiraClient) CreateFixVersion(name, project string) (*Version, error) {
+	return c.CreateFixVersionContext(context.Background(), name, project)
+}
+
+// CreateFixVersionContext is CreateFixVersion with a context which cancels the request
+func (c *JiraClient) CreateFixVersionContext(ctx context.Context, name, project string) (*Version, error) {
+	return c.createFixVersion(ctx, name, project, true)
+}
+
+// createFixVersion calls the version endpoint to add a released or unreleased fixVersion to the provided project and
+// returns the created version. A pre-release version is always created unreleased.
+func (c *JiraClient) createFixVersion(ctx context.Context, name, project string, released bool) (*Version, error) {
 	endpoint := apiEndpoint + "/version"
-	body, err := newReleaseRequestBody(name, project)
+	body, err := newReleaseRequestBody(name, project, released && !IsPrereleaseName(name))
 
 	if err != nil {
-		return fmt.Errorf("could not create new release request body: %w", err)
+		return nil, fmt.Errorf("could not create new release request body: %w", err)
 	}
 
-	req, err := c.createRequest(http.MethodPost, endpoint, body)
+	req, err := c.createRequest(ctx, http.MethodPost, endpoint, body)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var response createResponse
+	var response Version
 
 	if err = c.doRequest(req, &response); err != nil {
-		return fmt.Errorf("could not create fix version: %w", err)
+		return nil, fmt.Errorf("could not create fix version: %w", err)
 	}
 
-	fmt.Printf("successfully created release %q with id %q\n", response.Name, response.Id)
-	return nil
+	c.emit(Event{Type: EventVersionCreated, Project: project, Version: response.Name})
+	c.log().Info("created version", "project", project, "version", response.Name, "id", response.Id)
+	return &response, nil
 }
@@ -44,6 +44,23 @@ func (m *MockHttpClient) Do(req *http.Request) (*http.Response, error) {
 	return &http.Response{Status: "Created", StatusCode: http.StatusCreated, Body: ioutil.NopCloser(body)}, nil
 }
 
+// mockHttpClientFunc allows a test to provide its own handler for requests
+type mockHttpClientFunc func(req *http.Request) (*http.Response, error)
+
+func (f mockHttpClientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newMockResponse creates a response with the provided status code and body
+func newMockResponse(statusCode int, body string) *http.Response {
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode: statusCode,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+	}
+}
+
 func TestNewJiraClient(t *testing.T) {
 	m := NewMockHttpClient(t, 200)
 	parsedHost, _ := url.Parse("https://test.nu")
@@ -53,15 +70,12 @@ func TestNewJiraClient(t *testing.T) {
 	assert.NoError(t, err)
 
 	expected := &JiraClient{
-		host:           parsedHost,
-		httpClient:     m,
-		authentication: nil,
-	}
-
-	expected.authentication = &authenticationService{
-		client: expected,
-		email:  "marcel@test.nl",
-		token:  "c0ffee",
+		host:       parsedHost,
+		httpClient: m,
+		authentication: &BasicAuthenticator{
+			email: "marcel@test.nl",
+			token: "c0ffee",
+		},
 	}
 
 	assert.Equal(t, expected, c)
@@ -93,7 +107,7 @@ func TestJiraClient_CreateFixVersion(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = jiraClient.CreateFixVersion("test version", "MB")
+	_, err = jiraClient.CreateFixVersion("test version", "MB")
 	assert.NoError(t, err)
 	assert.Equal(t, 1, mockClient.CalledTimes)
 	assert.Equal(t, fmt.Sprintf("{\"name\":\"test version\",\"released\":true,\"releaseDate\":\"%v\",\"project\":\"MB\"}", getDateString()), mockClient.CalledWith[0])
@@ -102,6 +116,19 @@ func TestJiraClient_CreateFixVersion(t *testing.T) {
 	assert.Equal(t, "application/json", mockClient.CalledHeaders.Get("Content-Type"))
 }
 
+func TestJiraClient_CreateFixVersion_prerelease(t *testing.T) {
+	mockClient := NewMockHttpClient(t, 201)
+	jiraClient, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", mockClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = jiraClient.CreateFixVersion("Backend 1.4.0-rc.1", "MB")
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"name\":\"Backend 1.4.0-rc.1\",\"released\":false,\"project\":\"MB\"}", mockClient.CalledWith[0])
+}
+
 func TestJiraClient_CreateFixVersion_non20X(t *testing.T) {
 	mockClient := NewMockHttpClient(t, 400)
 	jiraClient, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", mockClient)
@@ -110,9 +137,9 @@ func TestJiraClient_CreateFixVersion_non20X(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = jiraClient.CreateFixVersion("test version", "MB")
+	_, err = jiraClient.CreateFixVersion("test version", "MB")
 	assert.Equal(t, 1, mockClient.CalledTimes)
-	assert.EqualError(t, err, "could not create fix version: request unsuccessful (Bad request): A version with this name already exists in this project.")
+	assert.EqualError(t, err, "could not create fix version: request unsuccessful (Bad request): name: A version with this name already exists in this project.")
 }
 
 func TestJiraClient_AssignVersion(t *testing.T) {
@@ -141,5 +168,31 @@ func TestJiraClient_AssignVersion_non20X(t *testing.T) {
 	}
 
 	err = jiraClient.AssignVersion("MB-1337", "My first release")
-	assert.EqualError(t, err, "request unsuccessful (Bad request): A version with this name already exists in this project.")
+	assert.EqualError(t, err, "request unsuccessful (Bad request): name: A version with this name already exists in this project.")
+}
+
+func TestJiraClient_replayCassette(t *testing.T) {
+	cassette, err := LoadCassette("testdata/cassettes/release_version.yaml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replayer := NewReplayer(cassette)
+	jiraClient, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", replayer)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := jiraClient.FindVersion("MB", "1.1.0")
+	assert.NoError(t, err)
+	assert.NoError(t, jiraClient.ReleaseVersion(version.Id))
+
+	err = jiraClient.AssignVersion("MB-1", "1.1.0")
+	assert.EqualError(t, err, "request unsuccessful (400 Bad Request): fixVersions: Fix Version name 1.1.0 is not valid")
+	assert.Empty(t, replayer.Remaining())
+
+	err = jiraClient.AssignVersion("MB-1", "1.1.0")
+	assert.EqualError(t, err, "could not do request: no recorded response for PUT https://test.nu/rest/api/latest/issue/MB-1")
 }
@@ -0,0 +1,332 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// RepoConfigFileName is the name of the config file which can be placed in a repository
+	RepoConfigFileName = ".jira-helper.yaml"
+	// DefaultProfileName is the name of the profile used when no profile is selected
+	DefaultProfileName = "default"
+)
+
+// Config holds the named profiles of the jira-helper config file and, in a repository config, the mappings of a
+// monorepo
+type Config struct {
+	CurrentProfile string              `yaml:"currentProfile,omitempty"`
+	Profiles       map[string]*Profile `yaml:"profiles,omitempty"`
+	Mappings       []Mapping           `yaml:"mappings,omitempty"`
+}
+
+// Profile holds the settings for a single Jira instance. Secrets are not stored in profiles, use a token file or a
+// credential helper instead.
+type Profile struct {
+	Host             string   `yaml:"host,omitempty"`
+	User             string   `yaml:"user,omitempty"`
+	AuthType         string   `yaml:"authType,omitempty"`
+	Project          string   `yaml:"project,omitempty"`
+	TokenFile        string   `yaml:"tokenFile,omitempty"`
+	CredentialHelper string   `yaml:"credentialHelper,omitempty"`
+	IssuePattern     string   `yaml:"issuePattern,omitempty"`
+	Filter           []string `yaml:"filter,omitempty"`
+	Proxy            string   `yaml:"proxy,omitempty"`
+	CACert           string   `yaml:"caCert,omitempty"`
+	ClientCert       string   `yaml:"clientCert,omitempty"`
+	ClientKey        string   `yaml:"clientKey,omitempty"`
+	MinTLSVersion    string   `yaml:"minTLSVersion,omitempty"`
+	Insecure         bool     `yaml:"insecure,omitempty"`
+}
+
+// profileKeys lists the keys which can be used with Profile.Get and Profile.Set
+var profileKeys = []string{
+	"host", "user", "authType", "project", "tokenFile", "credentialHelper", "issuePattern", "filter",
+	"proxy", "caCert", "clientCert", "clientKey", "minTLSVersion", "insecure",
+}
+
+// userOnlyProfileKeys are the keys which are only taken from the user config and flags. A repository config could use
+// them to run a command, to read the credentials from another file or to send the requests, with the token of the
+// user, to another server.
+var userOnlyProfileKeys = []string{
+	"host", "authType", "tokenFile", "credentialHelper", "proxy", "caCert", "clientCert", "clientKey", "minTLSVersion",
+	"insecure",
+}
+
+// UserConfigPath returns the path of the user config file, e.g. ~/.config/jira-helper/config.yaml on Linux
+func UserConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+
+	if err != nil {
+		return "", fmt.Errorf("could not determine config directory: %w", err)
+	}
+
+	return filepath.Join(dir, "jira-helper", "config.yaml"), nil
+}
+
+// FindRepoConfig looks for the repository config file in the provided directory and its parents. An empty string is
+// returned when no config file is found.
+func FindRepoConfig(dir string) string {
+	for {
+		path := filepath.Join(dir, RepoConfigFileName)
+
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+
+		parent := filepath.Dir(dir)
+
+		if parent == dir {
+			return ""
+		}
+
+		dir = parent
+	}
+}
+
+// LoadConfig reads the config file at the provided path. An empty config is returned when the file does not exist.
+func LoadConfig(path string) (*Config, error) {
+	config := &Config{Profiles: map[string]*Profile{}}
+	data, err := ioutil.ReadFile(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read config: %w", err)
+	}
+
+	if err = yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("could not parse config %s: %w", path, err)
+	}
+
+	if config.Profiles == nil {
+		config.Profiles = map[string]*Profile{}
+	}
+
+	return config, nil
+}
+
+// Save writes the config to the provided path
+func (c *Config) Save(path string) error {
+	data, err := yaml.Marshal(c)
+
+	if err != nil {
+		return fmt.Errorf("could not write config: %w", err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("could not write config: %w", err)
+	}
+
+	if err = ioutil.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("could not write config: %w", err)
+	}
+
+	return nil
+}
+
+// Merge returns a new config in which the values of the profiles and the mappings of other override those of c, e.g.
+// to let a repository config override the user config. The current profile and the user-only keys, like host and
+// credentialHelper, are not taken from other, see UserOnlyValues.
+func (c *Config) Merge(other *Config) *Config {
+	merged := &Config{CurrentProfile: c.CurrentProfile, Profiles: map[string]*Profile{}, Mappings: c.Mappings}
+
+	for name, profile := range c.Profiles {
+		p := *profile
+		merged.Profiles[name] = &p
+	}
+
+	if other == nil {
+		return merged
+	}
+
+	if len(other.Mappings) != 0 {
+		merged.Mappings = other.Mappings
+	}
+
+	for name, profile := range other.Profiles {
+		if existing, ok := merged.Profiles[name]; ok {
+			existing.merge(profile)
+			continue
+		}
+
+		p := &Profile{}
+		p.merge(profile)
+		merged.Profiles[name] = p
+	}
+
+	return merged
+}
+
+// UserOnlyValues returns the current profile and the user-only keys which are set in the profiles, e.g.
+// "default.credentialHelper", so it can be reported that Merge ignores them in a repository config
+func (c *Config) UserOnlyValues() []string {
+	var values []string
+
+	if c.CurrentProfile != "" {
+		values = append(values, "currentProfile")
+	}
+
+	for _, name := range c.ProfileNames() {
+		for _, key := range userOnlyProfileKeys {
+			if value, _ := c.Profiles[name].Get(key); value != "" {
+				values = append(values, name+"."+key)
+			}
+		}
+	}
+
+	return values
+}
+
+// ProfileNames returns the names of the profiles in alphabetical order
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// SelectedProfileName returns the provided name or, when it is empty, the current profile or the default profile
+func (c *Config) SelectedProfileName(name string) string {
+	if name != "" {
+		return name
+	}
+
+	if c.CurrentProfile != "" {
+		return c.CurrentProfile
+	}
+
+	return DefaultProfileName
+}
+
+// Profile returns the profile with the provided name or, when the name is empty, the current profile. An empty
+// profile is returned when no name is provided and the current profile does not exist.
+func (c *Config) Profile(name string) (*Profile, error) {
+	selected := c.SelectedProfileName(name)
+
+	if profile, ok := c.Profiles[selected]; ok {
+		return profile, nil
+	}
+
+	if name != "" || c.CurrentProfile != "" {
+		return nil, fmt.Errorf("profile %q does not exist", selected)
+	}
+
+	return &Profile{}, nil
+}
+
+// Get returns the value of the provided key
+func (p *Profile) Get(key string) (string, error) {
+	switch key {
+	case "host":
+		return p.Host, nil
+	case "user":
+		return p.User, nil
+	case "authType":
+		return p.AuthType, nil
+	case "project":
+		return p.Project, nil
+	case "tokenFile":
+		return p.TokenFile, nil
+	case "credentialHelper":
+		return p.CredentialHelper, nil
+	case "issuePattern":
+		return p.IssuePattern, nil
+	case "filter":
+		return strings.Join(p.Filter, ","), nil
+	case "proxy":
+		return p.Proxy, nil
+	case "caCert":
+		return p.CACert, nil
+	case "clientCert":
+		return p.ClientCert, nil
+	case "clientKey":
+		return p.ClientKey, nil
+	case "minTLSVersion":
+		return p.MinTLSVersion, nil
+	case "insecure":
+		if !p.Insecure {
+			return "", nil
+		}
+
+		return strconv.FormatBool(p.Insecure), nil
+	default:
+		return "", unknownProfileKeyError(key)
+	}
+}
+
+// Set sets the value of the provided key. Lists, like filter, are comma separated.
+func (p *Profile) Set(key, value string) error {
+	switch key {
+	case "host":
+		p.Host = value
+	case "user":
+		p.User = value
+	case "authType":
+		p.AuthType = value
+	case "project":
+		p.Project = value
+	case "tokenFile":
+		p.TokenFile = value
+	case "credentialHelper":
+		p.CredentialHelper = value
+	case "issuePattern":
+		p.IssuePattern = value
+	case "filter":
+		p.Filter = splitValues(value)
+	case "proxy":
+		p.Proxy = value
+	case "caCert":
+		p.CACert = value
+	case "clientCert":
+		p.ClientCert = value
+	case "clientKey":
+		p.ClientKey = value
+	case "minTLSVersion":
+		p.MinTLSVersion = value
+	case "insecure":
+		insecure, err := strconv.ParseBool(value)
+
+		if err != nil && value != "" {
+			return fmt.Errorf("invalid value %q for insecure, expected true or false", value)
+		}
+
+		p.Insecure = insecure
+	default:
+		return unknownProfileKeyError(key)
+	}
+
+	return nil
+}
+
+// merge overrides the values of the profile with the non-empty values of other, except for the user-only keys
+func (p *Profile) merge(other *Profile) {
+	for _, key := range profileKeys {
+		if containsFold(userOnlyProfileKeys, key) {
+			continue
+		}
+
+		if value, _ := other.Get(key); value != "" {
+			_ = p.Set(key, value)
+		}
+	}
+}
+
+// unknownProfileKeyError returns the error for a key which does not exist in a profile
+func unknownProfileKeyError(key string) error {
+	return fmt.Errorf("unknown key %q, valid keys are %s", key, strings.Join(profileKeys, ", "))
+}
@@ -0,0 +1,194 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	contents := `currentProfile: server
+profiles:
+  server:
+    host: https://jira.test.nl
+    authType: bearer
+    filter: [MB, JH]
+`
+	assert.NoError(t, ioutil.WriteFile(path, []byte(contents), 0o600))
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "server", config.CurrentProfile)
+	assert.Equal(t, &Profile{Host: "https://jira.test.nl", AuthType: "bearer", Filter: []string{"MB", "JH"}}, config.Profiles["server"])
+}
+
+func TestLoadConfig_missingFile(t *testing.T) {
+	config, err := LoadConfig(filepath.Join(t.TempDir(), "config.yaml"))
+	assert.NoError(t, err)
+	assert.Empty(t, config.Profiles)
+}
+
+func TestLoadConfig_invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("profiles: ["), 0o600))
+
+	_, err := LoadConfig(path)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "could not parse config")
+}
+
+func TestConfig_Save(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config.yaml")
+	config := &Config{CurrentProfile: "cloud", Profiles: map[string]*Profile{"cloud": {Host: "https://test.atlassian.net", Project: "MB"}}}
+	assert.NoError(t, config.Save(path))
+
+	loaded, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, config, loaded)
+}
+
+func TestConfig_Merge(t *testing.T) {
+	user := &Config{CurrentProfile: "cloud", Profiles: map[string]*Profile{
+		"cloud":  {Host: "https://test.atlassian.net", User: "user@test.nl", Project: "MB"},
+		"server": {Host: "https://jira.test.nl"},
+	}}
+	repo := &Config{Profiles: map[string]*Profile{
+		"cloud": {Project: "JH", Filter: []string{"JH"}},
+		"other": {Project: "OPS"},
+	}, Mappings: []Mapping{{Name: "payments", Project: "PAY"}}}
+
+	merged := user.Merge(repo)
+	assert.Equal(t, "cloud", merged.CurrentProfile)
+	assert.Equal(t, &Profile{Host: "https://test.atlassian.net", User: "user@test.nl", Project: "JH", Filter: []string{"JH"}}, merged.Profiles["cloud"])
+	assert.Equal(t, []string{"cloud", "other", "server"}, merged.ProfileNames())
+	assert.Equal(t, repo.Mappings, merged.Mappings)
+	assert.Equal(t, "MB", user.Profiles["cloud"].Project, "merge must not modify the original config")
+}
+
+func TestConfig_Merge_userOnlyKeys(t *testing.T) {
+	user := &Config{Profiles: map[string]*Profile{
+		"default": {Host: "https://test.atlassian.net", CredentialHelper: "git credential-manager"},
+	}}
+	repo := &Config{Profiles: map[string]*Profile{
+		"default": {Project: "JH", CredentialHelper: "./steal.sh", TokenFile: "/tmp/token"},
+		"other":   {Host: "https://other.atlassian.net", CredentialHelper: "./steal.sh"},
+	}}
+
+	merged := user.Merge(repo)
+	assert.Equal(t, &Profile{Host: "https://test.atlassian.net", Project: "JH", CredentialHelper: "git credential-manager"}, merged.Profiles["default"])
+	assert.Equal(t, &Profile{}, merged.Profiles["other"])
+	assert.Equal(t, []string{"default.tokenFile", "default.credentialHelper", "other.host", "other.credentialHelper"}, repo.UserOnlyValues())
+	assert.Empty(t, merged.Profiles["other"].CredentialHelper)
+}
+
+func TestConfig_Merge_transportKeys(t *testing.T) {
+	user := &Config{Profiles: map[string]*Profile{
+		"default": {Host: "https://test.atlassian.net", CACert: "/etc/ssl/ca.pem"},
+	}}
+	repo := &Config{Profiles: map[string]*Profile{
+		"default": {
+			Project:       "JH",
+			Proxy:         "http://evil.test:8080",
+			CACert:        "ca.pem",
+			ClientCert:    "client.pem",
+			ClientKey:     "client-key.pem",
+			MinTLSVersion: "1.0",
+			Insecure:      true,
+		},
+	}}
+
+	merged := user.Merge(repo)
+	assert.Equal(t, &Profile{Host: "https://test.atlassian.net", Project: "JH", CACert: "/etc/ssl/ca.pem"}, merged.Profiles["default"])
+	assert.Equal(t, []string{
+		"default.proxy", "default.caCert", "default.clientCert", "default.clientKey", "default.minTLSVersion", "default.insecure",
+	}, repo.UserOnlyValues())
+}
+
+func TestConfig_Merge_hostKeys(t *testing.T) {
+	user := &Config{CurrentProfile: "cloud", Profiles: map[string]*Profile{
+		"cloud":  {Host: "https://test.atlassian.net", AuthType: "basic", TokenFile: "/run/secrets/jira-token"},
+		"server": {Host: "https://jira.test.nl", AuthType: "bearer"},
+	}}
+	repo := &Config{CurrentProfile: "evil", Profiles: map[string]*Profile{
+		"cloud": {Host: "https://evil.test", AuthType: "bearer", Project: "JH"},
+		"evil":  {Host: "https://evil.test"},
+	}}
+
+	merged := user.Merge(repo)
+	assert.Equal(t, "cloud", merged.CurrentProfile, "the repository config must not switch the profile")
+	assert.Equal(t, &Profile{Host: "https://test.atlassian.net", AuthType: "basic", TokenFile: "/run/secrets/jira-token", Project: "JH"}, merged.Profiles["cloud"])
+	assert.Equal(t, &Profile{}, merged.Profiles["evil"])
+	assert.Equal(t, []string{"currentProfile", "cloud.host", "cloud.authType", "evil.host"}, repo.UserOnlyValues())
+}
+
+func TestConfig_Profile(t *testing.T) {
+	config := &Config{Profiles: map[string]*Profile{"default": {Project: "MB"}, "server": {Project: "JH"}}}
+
+	profile, err := config.Profile("")
+	assert.NoError(t, err)
+	assert.Equal(t, "MB", profile.Project)
+
+	profile, err = config.Profile("server")
+	assert.NoError(t, err)
+	assert.Equal(t, "JH", profile.Project)
+
+	_, err = config.Profile("missing")
+	assert.EqualError(t, err, `profile "missing" does not exist`)
+
+	config.CurrentProfile = "missing"
+	_, err = config.Profile("")
+	assert.EqualError(t, err, `profile "missing" does not exist`)
+
+	profile, err = (&Config{}).Profile("")
+	assert.NoError(t, err)
+	assert.Equal(t, &Profile{}, profile)
+}
+
+func TestProfile_GetSet(t *testing.T) {
+	profile := &Profile{}
+
+	for _, key := range profileKeys {
+		if key != "insecure" {
+			assert.NoError(t, profile.Set(key, "a, b"))
+		}
+	}
+
+	value, err := profile.Get("filter")
+	assert.NoError(t, err)
+	assert.Equal(t, "a,b", value)
+	assert.Equal(t, []string{"a", "b"}, profile.Filter)
+
+	value, err = profile.Get("host")
+	assert.NoError(t, err)
+	assert.Equal(t, "a, b", value)
+
+	assert.NoError(t, profile.Set("insecure", "true"))
+	assert.True(t, profile.Insecure)
+	value, err = profile.Get("insecure")
+	assert.NoError(t, err)
+	assert.Equal(t, "true", value)
+	err = profile.Set("insecure", "yes please")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid value "yes please" for insecure`)
+
+	err = profile.Set("token", "secret")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown key "token"`)
+	_, err = profile.Get("token")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown key "token"`)
+}
+
+func TestFindRepoConfig(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	assert.NoError(t, os.MkdirAll(nested, 0o700))
+	assert.Equal(t, "", FindRepoConfig(nested))
+
+	path := filepath.Join(root, RepoConfigFileName)
+	assert.NoError(t, ioutil.WriteFile(path, []byte("profiles: {}"), 0o600))
+	assert.Equal(t, path, FindRepoConfig(nested))
+}
@@ -0,0 +1,259 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// semVerInTextPattern finds a semantic version in a version name, e.g. 1.4.0-rc.1 in "Backend 1.4.0-rc.1"
+var semVerInTextPattern = regexp.MustCompile(`\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?`)
+
+// PrereleaseVersions returns the versions which are pre-releases of the final version. A version is a pre-release of
+// the final version when its name equals the name of the final version with the semantic version replaced by a
+// pre-release of it, e.g. "Backend 1.4.0-rc.1" for "Backend 1.4.0".
+func PrereleaseVersions(versions []Version, final string) ([]Version, error) {
+	finalVersion, prefix, suffix, err := splitVersionName(final)
+
+	if err != nil || finalVersion.IsPrerelease() {
+		return nil, fmt.Errorf("version %q does not contain a final semantic version", final)
+	}
+
+	var prereleases []Version
+
+	for _, version := range versions {
+		semVer, p, s, err := splitVersionName(version.Name)
+
+		if err != nil || p != prefix || s != suffix || !semVer.IsPrerelease() {
+			continue
+		}
+
+		if semVer.Major == finalVersion.Major && semVer.Minor == finalVersion.Minor && semVer.Patch == finalVersion.Patch {
+			prereleases = append(prereleases, version)
+		}
+	}
+
+	return prereleases, nil
+}
+
+// splitVersionName splits the version name in the text before the semantic version, the semantic version and the text
+// after it
+func splitVersionName(name string) (SemVer, string, string, error) {
+	loc := semVerInTextPattern.FindStringIndex(name)
+
+	if loc == nil {
+		return SemVer{}, "", "", fmt.Errorf("version %q does not contain a semantic version", name)
+	}
+
+	semVer, err := ParseSemVer(name[loc[0]:loc[1]])
+
+	if err != nil {
+		return SemVer{}, "", "", err
+	}
+
+	// A leading v belongs to the semantic version, e.g. "Backend v1.4.0"
+	return semVer, strings.TrimSuffix(name[:loc[0]], "v"), name[loc[1]:], nil
+}
+
+// ConsolidateSteps returns the steps to move the issues of the pre-release versions of the final version onto the
+// final version and to release it. The final version is created unreleased when it does not exist, and released after
+// the issues have been moved. The pre-release versions are merged into the final version or, when archive is true,
+// archived after their issues have been moved.
+func ConsolidateSteps(ctx context.Context, client *JiraClient, project, final string, archive bool) ([]Step, error) {
+	versions, err := client.GetProjectVersionsContext(ctx, project)
+
+	if err != nil {
+		return nil, err
+	}
+
+	prereleases, err := PrereleaseVersions(versions, final)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var steps []Step
+	var finalVersion *Version
+
+	for i := range versions {
+		if versions[i].Name == final {
+			finalVersion = &versions[i]
+		}
+	}
+
+	if finalVersion == nil {
+		steps = append(steps, &CreateVersionStep{Name: final, Project: project, Unreleased: true})
+	}
+
+	if archive {
+		moves, err := movePrereleaseIssuesSteps(ctx, client, project, final, prereleases)
+
+		if err != nil {
+			return nil, err
+		}
+
+		steps = append(steps, moves...)
+
+		for _, prerelease := range prereleases {
+			steps = append(steps, &ArchiveVersionStep{Version: prerelease, Project: project})
+		}
+	} else {
+		for _, prerelease := range prereleases {
+			steps = append(steps, &MergeVersionStep{Version: prerelease, Project: project, Target: final})
+		}
+	}
+
+	if finalVersion == nil || !finalVersion.Released {
+		steps = append(steps, &ReleaseVersionStep{Name: final, Project: project})
+	}
+
+	return steps, nil
+}
+
+// movePrereleaseIssuesSteps returns the steps to replace the pre-release versions of the issues with the final version
+func movePrereleaseIssuesSteps(ctx context.Context, client *JiraClient, project, final string, prereleases []Version) ([]Step, error) {
+	var issues []string
+	removals := map[string][]string{}
+
+	for _, prerelease := range prereleases {
+		jql := fmt.Sprintf("project = %s AND fixVersion = %s", quoteJQL(project), prerelease.Id)
+		found, err := client.SearchIssuesContext(ctx, jql)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, issue := range found {
+			if _, ok := removals[issue]; !ok {
+				issues = append(issues, issue)
+			}
+
+			removals[issue] = append(removals[issue], prerelease.Name)
+		}
+	}
+
+	var steps []Step
+
+	for _, issue := range issues {
+		steps = append(steps, &UpdateIssueStep{
+			Issue:       issue,
+			FixVersions: VersionChange{Add: []string{final}, Remove: removals[issue]},
+		})
+	}
+
+	return steps, nil
+}
+
+// MergeVersionStep merges a version into the target version, which moves its issues to the target version and deletes
+// it. The target version is looked up by name when the step is performed, so it can be created earlier in the same
+// operation. Merging a version cannot be undone.
+type MergeVersionStep struct {
+	Version Version
+	Project string
+	Target  string
+}
+
+func (s *MergeVersionStep) Id() string {
+	return fmt.Sprintf("merge-version:%s:%s:%s", s.Project, s.Version.Name, s.Target)
+}
+
+func (s *MergeVersionStep) Description() string {
+	return fmt.Sprintf("merge version %q into %q", s.Version.Name, s.Target)
+}
+
+func (s *MergeVersionStep) Do(ctx context.Context, client *JiraClient) error {
+	target, err := client.FindVersionContext(ctx, s.Project, s.Target)
+
+	if err != nil {
+		return err
+	}
+
+	return client.MergeVersionContext(ctx, s.Version.Id, target.Id)
+}
+
+func (s *MergeVersionStep) Undo(ctx context.Context, client *JiraClient) error {
+	return fmt.Errorf("version %q was merged into %q and cannot be restored", s.Version.Name, s.Target)
+}
+
+// ArchiveVersionStep archives a version. Undoing the step restores the version from the archive.
+type ArchiveVersionStep struct {
+	Version Version
+	Project string
+}
+
+func (s *ArchiveVersionStep) Id() string {
+	return fmt.Sprintf("archive-version:%s", s.Version.Id)
+}
+
+func (s *ArchiveVersionStep) Description() string {
+	return fmt.Sprintf("archive version %q", s.Version.Name)
+}
+
+func (s *ArchiveVersionStep) Do(ctx context.Context, client *JiraClient) error {
+	return client.ArchiveVersionContext(ctx, s.Version.Id)
+}
+
+func (s *ArchiveVersionStep) Undo(ctx context.Context, client *JiraClient) error {
+	return client.UnarchiveVersionContext(ctx, s.Version.Id)
+}
+
+func (s *ArchiveVersionStep) version() *Version {
+	archived := s.Version
+	archived.Archived = true
+	return &archived
+}
+
+// ReleaseVersionStep releases a version with today as release date. The version is looked up by name when the step
+// is performed. Undoing the step marks the version as unreleased again.
+type ReleaseVersionStep struct {
+	Name    string
+	Project string
+	Version *Version
+}
+
+func (s *ReleaseVersionStep) Id() string {
+	return fmt.Sprintf("release-version:%s:%s", s.Project, s.Name)
+}
+
+func (s *ReleaseVersionStep) Description() string {
+	return fmt.Sprintf("release version %q in project %s", s.Name, s.Project)
+}
+
+func (s *ReleaseVersionStep) Do(ctx context.Context, client *JiraClient) error {
+	version, err := client.FindVersionContext(ctx, s.Project, s.Name)
+
+	if err != nil {
+		return err
+	}
+
+	if err = client.ReleaseVersionContext(ctx, version.Id); err != nil {
+		return err
+	}
+
+	version.Released = true
+	version.ReleaseDate = getDateString()
+	s.Version = version
+	return nil
+}
+
+func (s *ReleaseVersionStep) Undo(ctx context.Context, client *JiraClient) error {
+	if s.Version == nil {
+		return fmt.Errorf("the id of version %q is unknown", s.Name)
+	}
+
+	return client.UnreleaseVersionContext(ctx, s.Version.Id)
+}
+
+func (s *ReleaseVersionStep) version() *Version {
+	return s.Version
+}
+
+func (s *ReleaseVersionStep) MarshalState() ([]byte, error) {
+	return json.Marshal(s.Version)
+}
+
+func (s *ReleaseVersionStep) UnmarshalState(data []byte) error {
+	return json.Unmarshal(data, &s.Version)
+}
@@ -0,0 +1,210 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/marcelblijleven/jira-helper/pkg/jiratest"
+	"github.com/marcelblijleven/jira-helper/pkg/jiratest/testserver"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+const prereleaseVersionsResponse = `[
+	{"id":"10000","name":"Backend 1.3.0","released":true},
+	{"id":"10001","name":"Backend 1.4.0-rc.1","released":false},
+	{"id":"10002","name":"Backend 1.4.0-rc.2","released":false},
+	{"id":"10003","name":"Frontend 1.4.0-rc.1","released":false},
+	{"id":"10004","name":"Backend 1.4.1-rc.1","released":false},
+	{"id":"10005","name":"Backend 1.4.0","released":false}
+]`
+
+func TestPrereleaseVersions(t *testing.T) {
+	var versions []Version
+	assert.NoError(t, json.Unmarshal([]byte(prereleaseVersionsResponse), &versions))
+
+	prereleases, err := PrereleaseVersions(versions, "Backend 1.4.0")
+	assert.NoError(t, err)
+	assert.Equal(t, []Version{versions[1], versions[2]}, prereleases)
+
+	prereleases, err = PrereleaseVersions([]Version{{Name: "v2.0.0-beta"}, {Name: "2.0.0-rc.1+build.1"}, {Name: "2.0.0"}}, "v2.0.0")
+	assert.NoError(t, err)
+	assert.Len(t, prereleases, 2)
+
+	_, err = PrereleaseVersions(versions, "Backend 1.4.0-rc.3")
+	assert.EqualError(t, err, `version "Backend 1.4.0-rc.3" does not contain a final semantic version`)
+
+	_, err = PrereleaseVersions(versions, "Sprint 5")
+	assert.EqualError(t, err, `version "Sprint 5" does not contain a final semantic version`)
+}
+
+func TestConsolidateSteps(t *testing.T) {
+	mockClient := mockHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/rest/api/latest/search" {
+			data, _ := ioutil.ReadAll(req.Body)
+
+			if string(data) == `{"jql":"project = \"MB\" AND fixVersion = 10001","startAt":0,"maxResults":100,"fields":["key"]}` {
+				return newMockResponse(http.StatusOK, `{"total":2,"issues":[{"key":"MB-1"},{"key":"MB-2"}]}`), nil
+			}
+
+			return newMockResponse(http.StatusOK, `{"total":1,"issues":[{"key":"MB-2"}]}`), nil
+		}
+
+		return newMockResponse(http.StatusOK, prereleaseVersionsResponse), nil
+	})
+	jiraClient, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", mockClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var versions []Version
+	assert.NoError(t, json.Unmarshal([]byte(prereleaseVersionsResponse), &versions))
+
+	steps, err := ConsolidateSteps(context.Background(), jiraClient, "MB", "Backend 1.4.0", false)
+	assert.NoError(t, err)
+	assert.Equal(t, []Step{
+		&MergeVersionStep{Version: versions[1], Project: "MB", Target: "Backend 1.4.0"},
+		&MergeVersionStep{Version: versions[2], Project: "MB", Target: "Backend 1.4.0"},
+		&ReleaseVersionStep{Name: "Backend 1.4.0", Project: "MB"},
+	}, steps)
+
+	steps, err = ConsolidateSteps(context.Background(), jiraClient, "MB", "Backend 1.4.0", true)
+	assert.NoError(t, err)
+	assert.Equal(t, []Step{
+		&UpdateIssueStep{Issue: "MB-1", FixVersions: VersionChange{Add: []string{"Backend 1.4.0"}, Remove: []string{"Backend 1.4.0-rc.1"}}},
+		&UpdateIssueStep{Issue: "MB-2", FixVersions: VersionChange{Add: []string{"Backend 1.4.0"}, Remove: []string{"Backend 1.4.0-rc.1", "Backend 1.4.0-rc.2"}}},
+		&ArchiveVersionStep{Version: versions[1], Project: "MB"},
+		&ArchiveVersionStep{Version: versions[2], Project: "MB"},
+		&ReleaseVersionStep{Name: "Backend 1.4.0", Project: "MB"},
+	}, steps)
+
+	steps, err = ConsolidateSteps(context.Background(), jiraClient, "MB", "Frontend 1.4.0", false)
+	assert.NoError(t, err)
+	assert.Equal(t, []Step{
+		&CreateVersionStep{Name: "Frontend 1.4.0", Project: "MB", Unreleased: true},
+		&MergeVersionStep{Version: versions[3], Project: "MB", Target: "Frontend 1.4.0"},
+		&ReleaseVersionStep{Name: "Frontend 1.4.0", Project: "MB"},
+	}, steps)
+}
+
+func TestConsolidateSteps_createFinal(t *testing.T) {
+	server := testserver.New(t)
+	server.AddVersion("MB", jiratest.Version{Name: "1.4.0-rc.1"})
+	server.AddIssue(jiratest.Issue{Key: "MB-1", FixVersions: []string{"1.4.0-rc.1"}})
+	client, err := NewJiraClient(server.URL, "marcel@test.nl", "c0ffee", http.DefaultClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps, err := ConsolidateSteps(context.Background(), client, "MB", "1.4.0", false)
+	assert.NoError(t, err)
+	assert.NoError(t, NewOperation(client, false).Run(steps))
+
+	// The final version is only released after the issues have been moved onto it
+	var changes []jiratest.Request
+
+	for _, request := range server.Requests() {
+		if request.Method != http.MethodGet {
+			changes = append(changes, request)
+		}
+	}
+
+	assert.Len(t, changes, 3)
+	assert.Equal(t, "POST /version", changes[0].Method+" "+changes[0].Path)
+	assert.Contains(t, changes[0].Body, `"released":false`)
+	assert.NotContains(t, changes[0].Body, "releaseDate")
+	assert.Contains(t, changes[1].Path, "/mergeto/")
+	assert.Contains(t, changes[2].Body, `"released":true`)
+	server.AssertVersionReleased("MB", "1.4.0", true)
+	server.AssertFixVersions("MB-1", "1.4.0")
+}
+
+func TestConsolidateSteps_rollbackMerge(t *testing.T) {
+	server := testserver.New(t)
+	server.AddVersion("MB", jiratest.Version{Id: "20001", Name: "1.4.0-rc.1"})
+	server.AddVersion("MB", jiratest.Version{Id: "20002", Name: "1.4.0-rc.2"})
+	server.AddIssue(jiratest.Issue{Key: "MB-1", FixVersions: []string{"1.4.0-rc.1"}})
+	server.AddIssue(jiratest.Issue{Key: "MB-2", FixVersions: []string{"1.4.0-rc.2"}})
+	client, err := NewJiraClient(server.URL, "marcel@test.nl", "c0ffee", http.DefaultClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps, err := ConsolidateSteps(context.Background(), client, "MB", "1.4.0", false)
+	assert.NoError(t, err)
+
+	// The final version is created before the merges with the next id, so the second merge fails after the first one
+	server.Fail(http.MethodPut, "/version/20002/mergeto/10004", http.StatusInternalServerError, 1)
+	operation := NewOperation(client, true)
+	err = operation.Run(steps)
+
+	var rollbackErr *RollbackError
+	assert.True(t, errors.As(err, &rollbackErr))
+	assert.Equal(t, []Step{steps[0]}, rollbackErr.Kept)
+	assert.Equal(t, []string{StepCompleted, StepNotRolledBack, StepFailed, StepNotRun}, stepStatuses(operation.Result()))
+
+	// The merged issues keep the final version instead of losing their version
+	server.AssertVersionReleased("MB", "1.4.0", false)
+	server.AssertNoVersion("MB", "1.4.0-rc.1")
+	server.AssertFixVersions("MB-1", "1.4.0")
+	server.AssertVersionExists("MB", "1.4.0-rc.2")
+	server.AssertFixVersions("MB-2", "1.4.0-rc.2")
+}
+
+func TestMergeVersionStep(t *testing.T) {
+	var calledPaths []string
+	mockClient := mockHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+		calledPaths = append(calledPaths, req.Method+" "+req.URL.Path)
+
+		if req.Method == http.MethodGet {
+			return newMockResponse(http.StatusOK, prereleaseVersionsResponse), nil
+		}
+
+		return newMockResponse(http.StatusNoContent, ""), nil
+	})
+	jiraClient, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", mockClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step := &MergeVersionStep{Version: Version{Id: "10001", Name: "Backend 1.4.0-rc.1"}, Project: "MB", Target: "Backend 1.4.0"}
+	assert.NoError(t, step.Do(context.Background(), jiraClient))
+	assert.Equal(t, []string{
+		"GET /rest/api/latest/project/MB/versions",
+		"PUT /rest/api/latest/version/10001/mergeto/10005",
+	}, calledPaths)
+	assert.EqualError(t, step.Undo(context.Background(), jiraClient), `version "Backend 1.4.0-rc.1" was merged into "Backend 1.4.0" and cannot be restored`)
+}
+
+func TestReleaseVersionStep(t *testing.T) {
+	var bodies []string
+	mockClient := mockHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method == http.MethodGet {
+			return newMockResponse(http.StatusOK, prereleaseVersionsResponse), nil
+		}
+
+		data, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, req.URL.Path+" "+string(data))
+		return newMockResponse(http.StatusOK, "{}"), nil
+	})
+	jiraClient, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", mockClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step := &ReleaseVersionStep{Name: "Backend 1.4.0", Project: "MB"}
+	assert.EqualError(t, step.Undo(context.Background(), jiraClient), `the id of version "Backend 1.4.0" is unknown`)
+	assert.NoError(t, step.Do(context.Background(), jiraClient))
+	assert.NoError(t, step.Undo(context.Background(), jiraClient))
+	assert.Equal(t, []string{
+		`/rest/api/latest/version/10005 {"released":true,"releaseDate":"` + getDateString() + `"}`,
+		`/rest/api/latest/version/10005 {"released":false}`,
+	}, bodies)
+}
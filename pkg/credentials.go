@@ -0,0 +1,304 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Credentials holds the Jira host and the user and token used to authenticate against it
+type Credentials struct {
+	Host  string
+	User  string
+	Token string
+}
+
+// complete reports whether all values of the credentials are set
+func (c Credentials) complete() bool {
+	return c.Host != "" && c.User != "" && c.Token != ""
+}
+
+// fill sets the empty values of the credentials to the provided values
+func (c *Credentials) fill(host, user, token string) {
+	if c.Host == "" {
+		c.Host = host
+	}
+
+	if c.User == "" {
+		c.User = user
+	}
+
+	if c.Token == "" {
+		c.Token = token
+	}
+}
+
+// hostname returns the hostname of the host, which may or may not contain a scheme
+func (c Credentials) hostname() string {
+	if u, err := url.Parse(c.Host); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+
+	return strings.Split(c.Host, "/")[0]
+}
+
+// CredentialSource provides credentials from a single source, e.g. the environment or a file
+type CredentialSource interface {
+	// Fill sets the values of the credentials which are still empty and which the source provides
+	Fill(credentials *Credentials) error
+}
+
+// CredentialChain resolves credentials by asking each of its sources in order to fill in the missing values. The
+// netrc file and credential helper are only consulted when the token is still missing.
+type CredentialChain []CredentialSource
+
+// DefaultCredentialChain returns the chain used by the CLI: the provided values (e.g. from flags), the environment,
+// the configured values (e.g. from a config file), the token file, the netrc file and finally the credential helper.
+// The token file and the credential helper are skipped when they are empty.
+func DefaultCredentialChain(provided, configured Credentials, tokenFile, credentialHelper string) CredentialChain {
+	chain := CredentialChain{StaticCredentials(provided), EnvCredentials{}, StaticCredentials(configured)}
+
+	if tokenFile != "" {
+		chain = append(chain, TokenFileCredentials{Path: tokenFile})
+	}
+
+	chain = append(chain, NetrcCredentials{})
+
+	if credentialHelper != "" {
+		chain = append(chain, CredentialHelper{Command: credentialHelper})
+	}
+
+	return chain
+}
+
+// Resolve asks the sources in order to fill in the credentials, until all values are set
+func (c CredentialChain) Resolve() (Credentials, error) {
+	var credentials Credentials
+
+	for _, source := range c {
+		if credentials.complete() {
+			break
+		}
+
+		if err := source.Fill(&credentials); err != nil {
+			return credentials, err
+		}
+	}
+
+	return credentials, nil
+}
+
+// StaticCredentials provides the credentials it holds, e.g. the values of command line flags
+type StaticCredentials Credentials
+
+func (s StaticCredentials) Fill(credentials *Credentials) error {
+	credentials.fill(s.Host, s.User, s.Token)
+	return nil
+}
+
+// EnvCredentials provides credentials from the JIRA_HOST, JIRA_USER and JIRA_TOKEN environment variables
+type EnvCredentials struct{}
+
+func (EnvCredentials) Fill(credentials *Credentials) error {
+	credentials.fill(os.Getenv("JIRA_HOST"), os.Getenv("JIRA_USER"), os.Getenv("JIRA_TOKEN"))
+	return nil
+}
+
+// TokenFileCredentials provides the token from a file, e.g. a mounted secret
+type TokenFileCredentials struct {
+	Path string
+}
+
+func (t TokenFileCredentials) Fill(credentials *Credentials) error {
+	if credentials.Token != "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(t.Path)
+
+	if err != nil {
+		return fmt.Errorf("could not read token file: %w", err)
+	}
+
+	credentials.Token = strings.TrimSpace(string(data))
+	return nil
+}
+
+// NetrcCredentials provides the user and token of the host from a netrc file. The file defaults to the file in the
+// NETRC environment variable or ~/.netrc.
+type NetrcCredentials struct {
+	Path string
+}
+
+func (n NetrcCredentials) Fill(credentials *Credentials) error {
+	if credentials.Host == "" || credentials.Token != "" {
+		return nil
+	}
+
+	path := n.Path
+
+	if path == "" {
+		path = os.Getenv("NETRC")
+	}
+
+	if path == "" {
+		home, err := os.UserHomeDir()
+
+		if err != nil {
+			return nil
+		}
+
+		path = filepath.Join(home, ".netrc")
+	}
+
+	data, err := ioutil.ReadFile(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not read netrc file: %w", err)
+	}
+
+	user, password, ok := parseNetrc(string(data), credentials.hostname(), credentials.User)
+
+	if ok {
+		credentials.fill("", user, password)
+	}
+
+	return nil
+}
+
+// parseNetrc looks up the login and password of the machine in the contents of a netrc file. When user is not empty,
+// only entries with that login match. The default entry is used when no machine matches.
+func parseNetrc(contents, machine, user string) (string, string, bool) {
+	type entry struct{ machine, login, password string }
+	var entries []entry
+	var current *entry
+
+	fields := strings.Fields(contents)
+
+parse:
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			if i+1 < len(fields) {
+				entries = append(entries, entry{machine: fields[i+1]})
+				current = &entries[len(entries)-1]
+				i++
+			}
+		case "default":
+			entries = append(entries, entry{})
+			current = &entries[len(entries)-1]
+		case "login", "password", "account":
+			if current != nil && i+1 < len(fields) {
+				if fields[i] == "login" {
+					current.login = fields[i+1]
+				} else if fields[i] == "password" {
+					current.password = fields[i+1]
+				}
+			}
+
+			i++
+		case "macdef":
+			// Macros run until the next empty line, which is lost by splitting on whitespace, so stop parsing
+			break parse
+		}
+	}
+
+	var fallback *entry
+
+	for i := range entries {
+		e := entries[i]
+
+		if user != "" && e.login != user {
+			continue
+		}
+
+		if strings.EqualFold(e.machine, machine) {
+			return e.login, e.password, true
+		}
+
+		if e.machine == "" && fallback == nil {
+			fallback = &entries[i]
+		}
+	}
+
+	if fallback != nil {
+		return fallback.login, fallback.password, true
+	}
+
+	return "", "", false
+}
+
+// CredentialHelper provides credentials by running an external executable, which speaks the git credential helper
+// protocol: the executable is called with the "get" argument and receives the protocol, host and optionally the
+// username on stdin. It writes the username and password to stdout as key=value lines.
+type CredentialHelper struct {
+	Command string
+}
+
+func (h CredentialHelper) Fill(credentials *Credentials) error {
+	if credentials.Host == "" || credentials.Token != "" {
+		return nil
+	}
+
+	args := strings.Fields(h.Command)
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	protocol := "https"
+
+	if u, err := url.Parse(credentials.Host); err == nil && u.Scheme != "" {
+		protocol = u.Scheme
+	}
+
+	var input bytes.Buffer
+	_, _ = fmt.Fprintf(&input, "protocol=%s\nhost=%s\n", protocol, credentials.hostname())
+
+	if credentials.User != "" {
+		_, _ = fmt.Fprintf(&input, "username=%s\n", credentials.User)
+	}
+
+	input.WriteString("\n")
+
+	cmd := exec.Command(args[0], append(args[1:], "get")...)
+	cmd.Stdin = &input
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+
+	if err != nil {
+		return fmt.Errorf("credential helper %s failed: %w", args[0], err)
+	}
+
+	var user, password string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "=", 2)
+
+		if len(parts) != 2 {
+			continue
+		}
+
+		switch parts[0] {
+		case "username":
+			user = parts[1]
+		case "password":
+			password = parts[1]
+		}
+	}
+
+	credentials.fill("", user, password)
+	return nil
+}
@@ -0,0 +1,92 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCredentialChain_Resolve(t *testing.T) {
+	t.Setenv("JIRA_HOST", "https://env.atlassian.net")
+	t.Setenv("JIRA_USER", "env@test.nl")
+	t.Setenv("JIRA_TOKEN", "")
+	t.Setenv("NETRC", filepath.Join(t.TempDir(), "does-not-exist"))
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, ioutil.WriteFile(tokenFile, []byte("c0ffee\n"), 0o600))
+
+	configured := Credentials{Host: "https://config.atlassian.net", User: "config@test.nl"}
+	chain := DefaultCredentialChain(Credentials{User: "flag@test.nl"}, configured, tokenFile, "")
+	credentials, err := chain.Resolve()
+	assert.NoError(t, err)
+	assert.Equal(t, Credentials{Host: "https://env.atlassian.net", User: "flag@test.nl", Token: "c0ffee"}, credentials)
+
+	t.Setenv("JIRA_HOST", "")
+	credentials, err = chain.Resolve()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://config.atlassian.net", credentials.Host)
+}
+
+func TestCredentialChain_Resolve_missingTokenFile(t *testing.T) {
+	t.Setenv("JIRA_TOKEN", "")
+	chain := DefaultCredentialChain(Credentials{}, Credentials{}, filepath.Join(t.TempDir(), "token"), "")
+	_, err := chain.Resolve()
+	assert.Error(t, err)
+}
+
+func TestNetrcCredentials_Fill(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".netrc")
+	netrc := `machine github.com login octocat password ghp_token
+machine your-domain.atlassian.net
+	login marcel@test.nl
+	password c0ffee
+default login anonymous password guest
+macdef init
+	cd /pub
+`
+	assert.NoError(t, ioutil.WriteFile(path, []byte(netrc), 0o600))
+
+	credentials := Credentials{Host: "https://your-domain.atlassian.net"}
+	assert.NoError(t, NetrcCredentials{Path: path}.Fill(&credentials))
+	assert.Equal(t, Credentials{Host: "https://your-domain.atlassian.net", User: "marcel@test.nl", Token: "c0ffee"}, credentials)
+
+	credentials = Credentials{Host: "jira.test.nu"}
+	assert.NoError(t, NetrcCredentials{Path: path}.Fill(&credentials))
+	assert.Equal(t, Credentials{Host: "jira.test.nu", User: "anonymous", Token: "guest"}, credentials)
+
+	credentials = Credentials{Host: "https://your-domain.atlassian.net", User: "someone@test.nl"}
+	assert.NoError(t, NetrcCredentials{Path: path}.Fill(&credentials))
+	assert.Equal(t, "", credentials.Token, "entries for other users should not match")
+}
+
+func TestCredentialHelper_Fill(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("credential helper script requires a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	helper := filepath.Join(dir, "jira-credential-helper")
+	script := `#!/bin/sh
+[ "$1" = "get" ] || exit 1
+cat > "` + filepath.Join(dir, "input") + `"
+echo "username=helper@test.nl"
+echo "password=c0ffee"
+`
+	assert.NoError(t, ioutil.WriteFile(helper, []byte(script), 0o700))
+
+	credentials := Credentials{Host: "https://your-domain.atlassian.net/jira"}
+	assert.NoError(t, CredentialHelper{Command: helper}.Fill(&credentials))
+	assert.Equal(t, Credentials{Host: "https://your-domain.atlassian.net/jira", User: "helper@test.nl", Token: "c0ffee"}, credentials)
+
+	input, err := ioutil.ReadFile(filepath.Join(dir, "input"))
+	assert.NoError(t, err)
+	assert.Equal(t, "protocol=https\nhost=your-domain.atlassian.net\n\n", string(input))
+}
+
+func TestCredentialHelper_Fill_failure(t *testing.T) {
+	credentials := Credentials{Host: "https://your-domain.atlassian.net"}
+	err := CredentialHelper{Command: filepath.Join(t.TempDir(), "missing-helper")}.Fill(&credentials)
+	assert.Error(t, err)
+}
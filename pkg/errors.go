@@ -0,0 +1,186 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxErrorBodyLength is the maximum length of a response body which is not a Jira error response in an error message
+const maxErrorBodyLength = 200
+
+var (
+	htmlTitlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	htmlTagPattern   = regexp.MustCompile(`(?s)<[^>]*>`)
+	issuePathPattern = regexp.MustCompile(`/issue/([^/]+)`)
+)
+
+// JiraError is returned when the Jira API responds with an unsuccessful status. It holds the error messages and field
+// errors of the response and the request which failed. Use errors.As or one of the Is functions, e.g. IsNotFound, to
+// check for a JiraError.
+type JiraError struct {
+	StatusCode int    `json:"-"`
+	Status     string `json:"-"`
+	Method     string `json:"-"`
+	Path       string `json:"-"`
+	// Issue is the issue key of the request, when the request targets an issue
+	Issue string `json:"-"`
+	// RetryAfter is the duration from the Retry-After header, e.g. when the request was rate limited
+	RetryAfter time.Duration `json:"-"`
+
+	ErrorMessages []string          `json:"errorMessages"`
+	Errors        map[string]string `json:"errors"`
+	Warnings      []string          `json:"warningMessages"`
+	// Body holds the response when it is not a Jira error response, e.g. the title of an HTML error page of a proxy
+	Body string `json:"-"`
+}
+
+func (e *JiraError) Error() string {
+	details := e.Details()
+
+	if details == "" {
+		return fmt.Sprintf("request unsuccessful (%s)", e.Status)
+	}
+
+	return fmt.Sprintf("request unsuccessful (%s): %s", e.Status, details)
+}
+
+// Details returns the error messages and the field errors, ordered by field, or the body when the response was not a
+// Jira error response
+func (e *JiraError) Details() string {
+	messages := append([]string{}, e.ErrorMessages...)
+	fields := make([]string, 0, len(e.Errors))
+
+	for field := range e.Errors {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		messages = append(messages, fmt.Sprintf("%s: %s", field, e.Errors[field]))
+	}
+
+	if len(messages) == 0 && e.Body != "" {
+		messages = append(messages, e.Body)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// IsNotFound reports whether the error is a JiraError with status 404, e.g. for an issue which does not exist or is
+// not visible to the user
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsUnauthorized reports whether the error is a JiraError with status 401, e.g. for invalid credentials
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsForbidden reports whether the error is a JiraError with status 403, e.g. for a missing permission
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// IsConflict reports whether the error is a JiraError with status 409
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// IsRateLimited reports whether the error is a JiraError with status 429. The RetryAfter field of the error holds the
+// duration to wait, when Jira provided it.
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
+
+// hasStatus reports whether the error is a JiraError with the provided status code
+func hasStatus(err error, statusCode int) bool {
+	var jiraError *JiraError
+	return errors.As(err, &jiraError) && jiraError.StatusCode == statusCode
+}
+
+// handleJiraError retrieves the error from the Jira api response
+func handleJiraError(res *http.Response) error {
+	jiraError := &JiraError{StatusCode: res.StatusCode, Status: res.Status, RetryAfter: parseRetryAfter(res.Header.Get("Retry-After"))}
+
+	if res.Request != nil && res.Request.URL != nil {
+		jiraError.Method = res.Request.Method
+		jiraError.Path = res.Request.URL.Path
+
+		if match := issuePathPattern.FindStringSubmatch(jiraError.Path); match != nil {
+			jiraError.Issue = match[1]
+		}
+	}
+
+	data, readErr := ioutil.ReadAll(res.Body)
+	defer res.Body.Close()
+
+	if readErr != nil {
+		return fmt.Errorf("request unsuccessful (%s), could not read response: %w", res.Status, readErr)
+	}
+
+	if err := json.Unmarshal(data, jiraError); err != nil || !jiraError.hasMessages() {
+		jiraError.ErrorMessages, jiraError.Errors, jiraError.Warnings = nil, nil, nil
+		jiraError.Body = summarizeBody(string(data))
+	}
+
+	return jiraError
+}
+
+// hasMessages reports whether the error contains any message from a Jira error response
+func (e *JiraError) hasMessages() bool {
+	return len(e.ErrorMessages) != 0 || len(e.Errors) != 0 || len(e.Warnings) != 0
+}
+
+// summarizeBody returns the title of an HTML page or the body without markup, shortened to maxErrorBodyLength bytes
+func summarizeBody(body string) string {
+	if match := htmlTitlePattern.FindStringSubmatch(body); match != nil {
+		body = match[1]
+	} else {
+		body = htmlTagPattern.ReplaceAllString(body, " ")
+	}
+
+	body = strings.Join(strings.Fields(body), " ")
+
+	if len(body) > maxErrorBodyLength {
+		end := maxErrorBodyLength
+
+		// Cut before the rune which is split by the limit, so the body stays valid UTF-8
+		for end > 0 && !utf8.RuneStart(body[end]) {
+			end--
+		}
+
+		body = body[:end] + "..."
+	}
+
+	return body
+}
+
+// parseRetryAfter parses the Retry-After header, which holds either a number of seconds or a date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
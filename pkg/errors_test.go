@@ -0,0 +1,100 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// newErrorResponse creates an unsuccessful response to a request for the provided path
+func newErrorResponse(statusCode int, method, path, body string) *http.Response {
+	res := newMockResponse(statusCode, body)
+	res.Request, _ = http.NewRequest(method, "https://test.nu"+path, nil)
+	return res
+}
+
+func Test_handleJiraError_fieldErrors(t *testing.T) {
+	res := newErrorResponse(http.StatusBadRequest, http.MethodPut, "/rest/api/latest/issue/MB-1",
+		`{"errorMessages":["Issue is closed."],"errors":{"fixVersions":"Version 2.0.0 is not valid.","customfield_10010":"Select a valid option."},"warningMessages":["Field is deprecated."]}`)
+	err := handleJiraError(res)
+
+	var jiraError *JiraError
+	assert.True(t, errors.As(err, &jiraError))
+	assert.Equal(t, &JiraError{
+		StatusCode:    http.StatusBadRequest,
+		Status:        "400 Bad Request",
+		Method:        http.MethodPut,
+		Path:          "/rest/api/latest/issue/MB-1",
+		Issue:         "MB-1",
+		ErrorMessages: []string{"Issue is closed."},
+		Errors:        map[string]string{"fixVersions": "Version 2.0.0 is not valid.", "customfield_10010": "Select a valid option."},
+		Warnings:      []string{"Field is deprecated."},
+	}, jiraError)
+	assert.EqualError(t, err, "request unsuccessful (400 Bad Request): Issue is closed.; customfield_10010: Select a valid option.; fixVersions: Version 2.0.0 is not valid.")
+}
+
+func Test_handleJiraError_html(t *testing.T) {
+	res := newErrorResponse(http.StatusBadGateway, http.MethodGet, "/rest/api/latest/field",
+		"<!DOCTYPE html>\n<html><head><title>\n  502 Bad Gateway\n</title></head><body><h1>Bad Gateway</h1></body></html>")
+	assert.EqualError(t, handleJiraError(res), "request unsuccessful (502 Bad Gateway): 502 Bad Gateway")
+
+	res = newErrorResponse(http.StatusServiceUnavailable, http.MethodGet, "/", "<p>Jira is <b>down</b> for maintenance</p>")
+	assert.EqualError(t, handleJiraError(res), "request unsuccessful (503 Service Unavailable): Jira is down for maintenance")
+
+	res = newErrorResponse(http.StatusInternalServerError, http.MethodGet, "/", strings.Repeat("a", 300))
+	assert.EqualError(t, handleJiraError(res), "request unsuccessful (500 Internal Server Error): "+strings.Repeat("a", 200)+"...")
+
+	// The limit falls in the middle of a two byte rune, which is left out
+	res = newErrorResponse(http.StatusInternalServerError, http.MethodGet, "/", "a"+strings.Repeat("ü", 150))
+	err := handleJiraError(res)
+	assert.EqualError(t, err, "request unsuccessful (500 Internal Server Error): a"+strings.Repeat("ü", 99)+"...")
+	assert.True(t, utf8.ValidString(err.Error()))
+
+	res = newErrorResponse(http.StatusNotFound, http.MethodGet, "/", "")
+	assert.EqualError(t, handleJiraError(res), "request unsuccessful (404 Not Found)")
+
+	res = newErrorResponse(http.StatusNotFound, http.MethodGet, "/", "{}")
+	assert.EqualError(t, handleJiraError(res), "request unsuccessful (404 Not Found): {}")
+}
+
+func TestIsNotFound(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		check      func(error) bool
+	}{
+		{http.StatusNotFound, IsNotFound},
+		{http.StatusUnauthorized, IsUnauthorized},
+		{http.StatusForbidden, IsForbidden},
+		{http.StatusConflict, IsConflict},
+		{http.StatusTooManyRequests, IsRateLimited},
+	}
+
+	for _, test := range tests {
+		t.Run(http.StatusText(test.statusCode), func(t *testing.T) {
+			err := fmt.Errorf("wrapped: %w", handleJiraError(newErrorResponse(test.statusCode, http.MethodGet, "/", "")))
+			assert.True(t, test.check(err))
+			assert.False(t, test.check(handleJiraError(newErrorResponse(http.StatusBadRequest, http.MethodGet, "/", ""))))
+			assert.False(t, test.check(errors.New("not a jira error")))
+			assert.False(t, test.check(nil))
+		})
+	}
+}
+
+func Test_parseRetryAfter(t *testing.T) {
+	res := newErrorResponse(http.StatusTooManyRequests, http.MethodGet, "/", "")
+	res.Header.Set("Retry-After", "30")
+
+	var jiraError *JiraError
+	assert.True(t, errors.As(handleJiraError(res), &jiraError))
+	assert.Equal(t, 30*time.Second, jiraError.RetryAfter)
+
+	retryAt := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	assert.InDelta(t, float64(time.Minute), float64(parseRetryAfter(retryAt)), float64(2*time.Second))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(""))
+}
@@ -0,0 +1,218 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Field represents a (custom) field as returned by the Jira field endpoint
+type Field struct {
+	Id     string      `json:"id"`
+	Name   string      `json:"name"`
+	Custom bool        `json:"custom"`
+	Schema FieldSchema `json:"schema"`
+}
+
+// FieldSchema describes the type of the values a field accepts
+type FieldSchema struct {
+	Type     string `json:"type"`
+	Items    string `json:"items,omitempty"`
+	System   string `json:"system,omitempty"`
+	Custom   string `json:"custom,omitempty"`
+	CustomId int    `json:"customId,omitempty"`
+}
+
+// GetFields retrieves the field metadata of the Jira instance. The result is cached on the client, so subsequent calls
+// do not hit the Jira API again.
+func (c *JiraClient) GetFields() ([]Field, error) {
+	return c.GetFieldsContext(context.Background())
+}
+
+// GetFieldsContext is GetFields with a context which cancels the request
+func (c *JiraClient) GetFieldsContext(ctx context.Context) ([]Field, error) {
+	if c.fields != nil {
+		return c.fields, nil
+	}
+
+	req, err := c.createRequest(ctx, http.MethodGet, apiEndpoint+"/field", nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []Field
+
+	if err = c.doRequest(req, &fields); err != nil {
+		return nil, fmt.Errorf("could not retrieve fields: %w", err)
+	}
+
+	c.fields = fields
+	return fields, nil
+}
+
+// ResolveField looks up a field by its id (e.g. customfield_10010) or by its human-readable name. Names are matched
+// case-insensitively.
+func (c *JiraClient) ResolveField(nameOrId string) (*Field, error) {
+	return c.ResolveFieldContext(context.Background(), nameOrId)
+}
+
+// ResolveFieldContext is ResolveField with a context which cancels the request
+func (c *JiraClient) ResolveFieldContext(ctx context.Context, nameOrId string) (*Field, error) {
+	fields, err := c.GetFieldsContext(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Field
+
+	for _, field := range fields {
+		if field.Id == nameOrId {
+			return &field, nil
+		}
+
+		if strings.EqualFold(field.Name, nameOrId) {
+			matches = append(matches, field)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("field %q does not exist", nameOrId)
+	case 1:
+		return &matches[0], nil
+	default:
+		ids := make([]string, len(matches))
+
+		for i, match := range matches {
+			ids[i] = match.Id
+		}
+
+		return nil, fmt.Errorf("field name %q is ambiguous, use one of the field ids instead: %s", nameOrId, strings.Join(ids, ", "))
+	}
+}
+
+// ResolveFieldValues resolves the provided field names to field ids and converts the raw values to the format the
+// Jira API expects for the type of each field
+func (c *JiraClient) ResolveFieldValues(values map[string]string) (map[string]interface{}, error) {
+	return c.ResolveFieldValuesContext(context.Background(), values)
+}
+
+// ResolveFieldValuesContext is ResolveFieldValues with a context which cancels the request
+func (c *JiraClient) ResolveFieldValuesContext(ctx context.Context, values map[string]string) (map[string]interface{}, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	resolved := make(map[string]interface{}, len(values))
+
+	for name, raw := range values {
+		field, err := c.ResolveFieldContext(ctx, name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := formatFieldValue(*field, raw)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for field %q: %w", name, err)
+		}
+
+		resolved[field.Id] = value
+	}
+
+	return resolved, nil
+}
+
+// ParseFieldAssignments parses assignments in the form of "Field name=value" into a map of field name to value
+func ParseFieldAssignments(assignments []string) (map[string]string, error) {
+	values := make(map[string]string, len(assignments))
+
+	for _, assignment := range assignments {
+		parts := strings.SplitN(assignment, "=", 2)
+
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid field assignment %q, expected format is \"field=value\"", assignment)
+		}
+
+		values[strings.TrimSpace(parts[0])] = parts[1]
+	}
+
+	return values, nil
+}
+
+// formatFieldValue converts the raw value into the representation the Jira API expects for the schema of the field
+func formatFieldValue(field Field, raw string) (interface{}, error) {
+	if field.Schema.Type == "array" {
+		var values []interface{}
+
+		for _, item := range splitValues(raw) {
+			value, err := formatSingleFieldValue(field.Schema.Items, item)
+
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+		}
+
+		if values == nil {
+			return []interface{}{}, nil
+		}
+
+		return values, nil
+	}
+
+	return formatSingleFieldValue(field.Schema.Type, raw)
+}
+
+// formatSingleFieldValue converts a single raw value for the provided schema type
+func formatSingleFieldValue(schemaType, raw string) (interface{}, error) {
+	switch schemaType {
+	case "string", "any":
+		return raw, nil
+	case "option":
+		return map[string]string{"value": raw}, nil
+	case "user":
+		return map[string]string{"accountId": raw}, nil
+	case "version", "component", "priority":
+		return map[string]string{"name": raw}, nil
+	case "date":
+		if _, err := time.Parse("2006-01-02", raw); err != nil {
+			return nil, fmt.Errorf("%q is not a valid date, expected format is YYYY-MM-DD", raw)
+		}
+
+		return raw, nil
+	case "number":
+		number, err := strconv.ParseFloat(raw, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid number", raw)
+		}
+
+		return number, nil
+	case "":
+		return nil, errors.New("field has no schema type")
+	default:
+		return nil, fmt.Errorf("fields of type %q are not supported", schemaType)
+	}
+}
+
+// splitValues splits a comma separated value and trims the individual values
+func splitValues(raw string) []string {
+	var values []string
+
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,153 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+const fieldsResponse = `[
+	{"id":"summary","name":"Summary","custom":false,"schema":{"type":"string","system":"summary"}},
+	{"id":"customfield_10010","name":"Deployed to","custom":true,"schema":{"type":"option","custom":"com.atlassian.jira.plugin.system.customfieldtypes:select","customId":10010}},
+	{"id":"customfield_10011","name":"Release train","custom":true,"schema":{"type":"array","items":"option","customId":10011}},
+	{"id":"customfield_10012","name":"Release manager","custom":true,"schema":{"type":"user","customId":10012}},
+	{"id":"customfield_10013","name":"Deployed on","custom":true,"schema":{"type":"date","customId":10013}},
+	{"id":"customfield_10014","name":"Story points","custom":true,"schema":{"type":"number","customId":10014}},
+	{"id":"customfield_10015","name":"Team","custom":true,"schema":{"type":"string","customId":10015}},
+	{"id":"customfield_10016","name":"Team","custom":true,"schema":{"type":"string","customId":10016}}
+]`
+
+// newFieldsMockClient returns a mock http client that serves the field endpoint and records the other requests
+func newFieldsMockClient(t *testing.T, fieldCalls *int, calledWith *[]string) mockHttpClientFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/rest/api/latest/field" {
+			*fieldCalls++
+			return newMockResponse(http.StatusOK, fieldsResponse), nil
+		}
+
+		data, err := ioutil.ReadAll(req.Body)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		*calledWith = append(*calledWith, string(data))
+		return newMockResponse(http.StatusNoContent, ""), nil
+	}
+}
+
+func TestJiraClient_GetFields_cached(t *testing.T) {
+	var fieldCalls int
+	var calledWith []string
+	client, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", newFieldsMockClient(t, &fieldCalls, &calledWith))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields, err := client.GetFields()
+	assert.NoError(t, err)
+	assert.Len(t, fields, 8)
+
+	_, err = client.GetFields()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fieldCalls)
+}
+
+func TestJiraClient_ResolveField(t *testing.T) {
+	var fieldCalls int
+	var calledWith []string
+	client, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", newFieldsMockClient(t, &fieldCalls, &calledWith))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	field, err := client.ResolveField("deployed to")
+	assert.NoError(t, err)
+	assert.Equal(t, "customfield_10010", field.Id)
+
+	field, err = client.ResolveField("customfield_10016")
+	assert.NoError(t, err)
+	assert.Equal(t, "Team", field.Name)
+
+	_, err = client.ResolveField("Team")
+	assert.EqualError(t, err, "field name \"Team\" is ambiguous, use one of the field ids instead: customfield_10015, customfield_10016")
+
+	_, err = client.ResolveField("Does not exist")
+	assert.EqualError(t, err, "field \"Does not exist\" does not exist")
+}
+
+func TestJiraClient_ResolveFieldValues(t *testing.T) {
+	var fieldCalls int
+	var calledWith []string
+	client, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", newFieldsMockClient(t, &fieldCalls, &calledWith))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := client.ResolveFieldValues(map[string]string{
+		"Deployed to":     "production",
+		"Release train":   "blue, green",
+		"Release manager": "5b10ac8d82e05b22cc7d4ef5",
+		"Deployed on":     "2022-01-31",
+		"Story points":    "3",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"customfield_10010": map[string]string{"value": "production"},
+		"customfield_10011": []interface{}{map[string]string{"value": "blue"}, map[string]string{"value": "green"}},
+		"customfield_10012": map[string]string{"accountId": "5b10ac8d82e05b22cc7d4ef5"},
+		"customfield_10013": "2022-01-31",
+		"customfield_10014": float64(3),
+	}, values)
+
+	_, err = client.ResolveFieldValues(map[string]string{"Deployed on": "31-01-2022"})
+	assert.EqualError(t, err, "invalid value for field \"Deployed on\": \"31-01-2022\" is not a valid date, expected format is YYYY-MM-DD")
+
+	_, err = client.ResolveFieldValues(map[string]string{"Story points": "three"})
+	assert.EqualError(t, err, "invalid value for field \"Story points\": \"three\" is not a valid number")
+}
+
+func TestAssignVersionsWithFields(t *testing.T) {
+	var fieldCalls int
+	var calledWith []string
+	client, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", newFieldsMockClient(t, &fieldCalls, &calledWith))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = AssignVersionsWithFields("", "My first version", client, []string{"MB-1", "MB-2"}, nil, map[string]string{"Deployed to": "production"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fieldCalls)
+	assert.Equal(t, []string{
+		"{\"update\":{\"fixVersions\":[{\"add\":{\"name\":\"My first version\"}}]},\"fields\":{\"customfield_10010\":{\"value\":\"production\"}}}",
+		"{\"update\":{\"fixVersions\":[{\"add\":{\"name\":\"My first version\"}}]},\"fields\":{\"customfield_10010\":{\"value\":\"production\"}}}",
+	}, calledWith)
+}
+
+func TestAssignVersionsWithFields_invalidField(t *testing.T) {
+	var fieldCalls int
+	var calledWith []string
+	client, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", newFieldsMockClient(t, &fieldCalls, &calledWith))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = AssignVersionsWithFields("", "My first version", client, []string{"MB-1"}, nil, map[string]string{"Unknown": "value"})
+	assert.EqualError(t, err, "could not resolve fields: field \"Unknown\" does not exist")
+	assert.Empty(t, calledWith)
+}
+
+func TestParseFieldAssignments(t *testing.T) {
+	values, err := ParseFieldAssignments([]string{"Deployed to=production", "Formula=a=b"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Deployed to": "production", "Formula": "a=b"}, values)
+
+	_, err = ParseFieldAssignments([]string{"production"})
+	assert.EqualError(t, err, "invalid field assignment \"production\", expected format is \"field=value\"")
+}
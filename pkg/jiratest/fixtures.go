@@ -0,0 +1,134 @@
+package jiratest
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixtures describe the initial state of the fake, e.g. in a yaml file:
+//
+//	projects:
+//	  - key: MB
+//	    versions:
+//	      - name: 1.0.0
+//	        released: true
+//	    issues:
+//	      - key: MB-1
+//	        fixVersions: [1.0.0]
+//	fields:
+//	  - id: customfield_10001
+//	    name: Team
+//	    custom: true
+//	    schema:
+//	      type: string
+type Fixtures struct {
+	Projects    []ProjectFixture `yaml:"projects"`
+	Fields      []Field          `yaml:"fields,omitempty"`
+	Transitions []Transition     `yaml:"transitions,omitempty"`
+}
+
+// ProjectFixture is a project with its versions and issues. DeniedPermissions are the permissions the user does not
+// have in the project, see Jira.DenyPermission.
+type ProjectFixture struct {
+	Key               string    `yaml:"key"`
+	Name              string    `yaml:"name,omitempty"`
+	Versions          []Version `yaml:"versions,omitempty"`
+	Issues            []Issue   `yaml:"issues,omitempty"`
+	DeniedPermissions []string  `yaml:"deniedPermissions,omitempty"`
+}
+
+// LoadFixtures reads the fixtures from the provided yaml file
+func LoadFixtures(path string) (*Fixtures, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read fixtures: %w", err)
+	}
+
+	var fixtures Fixtures
+
+	if err = yaml.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("could not parse fixtures %s: %w", path, err)
+	}
+
+	return &fixtures, nil
+}
+
+// Seed adds the projects, versions, issues and fields of the fixtures. The transitions replace the default workflow
+// when provided. The fixtures are validated first, so nothing is added when they are invalid.
+func (j *Jira) Seed(fixtures Fixtures) error {
+	if err := fixtures.validate(); err != nil {
+		return err
+	}
+
+	for _, p := range fixtures.Projects {
+		project := j.AddProject(p.Key)
+
+		if p.Name != "" {
+			j.mu.Lock()
+			j.project(project.Key).Name = p.Name
+			j.mu.Unlock()
+		}
+
+		for _, version := range p.Versions {
+			j.AddVersion(p.Key, version)
+		}
+
+		for _, issue := range p.Issues {
+			j.AddIssue(issue)
+		}
+
+		if len(p.DeniedPermissions) != 0 {
+			j.DenyPermission(p.Key, p.DeniedPermissions...)
+		}
+	}
+
+	for _, field := range fixtures.Fields {
+		j.AddField(field)
+	}
+
+	if len(fixtures.Transitions) != 0 {
+		j.mu.Lock()
+		j.transitions = append([]Transition(nil), fixtures.Transitions...)
+		j.mu.Unlock()
+	}
+
+	return nil
+}
+
+// validate checks that every project has a key, that the issues belong to their project and that their versions
+// exist
+func (f Fixtures) validate() error {
+	for _, project := range f.Projects {
+		if project.Key == "" {
+			return errors.New("invalid fixtures: project without key")
+		}
+
+		versions := map[string]bool{}
+
+		for _, version := range project.Versions {
+			if version.Name == "" {
+				return fmt.Errorf("invalid fixtures: version without name in project %s", project.Key)
+			}
+
+			versions[version.Name] = true
+		}
+
+		for _, issue := range project.Issues {
+			if issueProject(issue.Key) != project.Key || issue.Key == project.Key {
+				return fmt.Errorf("invalid fixtures: issue %q does not belong to project %s", issue.Key, project.Key)
+			}
+
+			for _, name := range append(append([]string{}, issue.FixVersions...), issue.AffectsVersions...) {
+				if !versions[name] {
+					return fmt.Errorf("invalid fixtures: issue %s references unknown version %q", issue.Key, name)
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,849 @@
+package jiratest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiPrefix matches the path prefix of the supported api versions
+var apiPrefix = regexp.MustCompile(`^/rest/api/(2|3|latest)(/|$)`)
+
+// errorResponse is the error format of the Jira API
+type errorResponse struct {
+	ErrorMessages []string          `json:"errorMessages"`
+	Errors        map[string]string `json:"errors"`
+}
+
+// versionReference references a version by name or id in a request body
+type versionReference struct {
+	Id   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// versionOperation is an add, remove or set operation on a version field of an issue
+type versionOperation struct {
+	Add    *versionReference  `json:"add"`
+	Remove *versionReference  `json:"remove"`
+	Set    []versionReference `json:"set"`
+}
+
+// InspectPath is the path of the endpoint which shows the changes made through the api and the received requests.
+// It does not require authentication.
+const InspectPath = "/jiratest/changes"
+
+// ServeHTTP handles a request to the Jira REST API
+func (j *Jira) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == InspectPath && r.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, struct {
+			Changes
+			Requests []Request `json:"requests"`
+		}{j.Changes(), append([]Request{}, j.Requests()...)})
+		return
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	loc := apiPrefix.FindStringIndex(r.URL.Path)
+
+	if loc == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s is not a Jira REST API path", r.URL.Path), nil)
+		return
+	}
+
+	path := "/" + strings.Trim(r.URL.Path[loc[1]:], "/")
+	j.requests = append(j.requests, Request{Method: r.Method, Path: path, Body: string(body)})
+
+	if j.auth != "" && r.Header.Get("Authorization") != j.auth {
+		writeError(w, http.StatusUnauthorized, "You are not authenticated. Authentication required to perform this operation.", nil)
+		return
+	}
+
+	if status, ok := j.failure(r.Method, path); ok {
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "1")
+		}
+
+		writeError(w, status, http.StatusText(status), nil)
+		return
+	}
+
+	j.route(w, r, path, body)
+}
+
+// failure returns the status of the first failure which matches the request and counts it down
+func (j *Jira) failure(method, path string) (int, bool) {
+	for _, f := range j.failures {
+		if f.times == 0 || (f.method != "" && f.method != method) || (f.path != "" && f.path != path) {
+			continue
+		}
+
+		if f.times > 0 {
+			f.times--
+		}
+
+		return f.status, true
+	}
+
+	return 0, false
+}
+
+// route dispatches the request to the handler of the endpoint
+func (j *Jira) route(w http.ResponseWriter, r *http.Request, path string, body []byte) {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	endpoint := r.Method + " /" + parts[0]
+
+	switch {
+	case endpoint == "GET /field" && len(parts) == 1:
+		writeJSON(w, http.StatusOK, j.fields)
+	case endpoint == "GET /project" && len(parts) == 2:
+		j.getProject(w, parts[1])
+	case endpoint == "GET /project" && len(parts) == 3 && parts[2] == "versions":
+		j.getProjectVersions(w, r, parts[1])
+	case endpoint == "POST /version" && len(parts) == 1:
+		j.createVersion(w, r, body)
+	case endpoint == "GET /version" && len(parts) == 2:
+		j.getVersion(w, r, parts[1])
+	case endpoint == "PUT /version" && len(parts) == 2:
+		j.updateVersion(w, r, parts[1], body)
+	case endpoint == "DELETE /version" && len(parts) == 2:
+		j.deleteVersion(w, parts[1])
+	case endpoint == "PUT /version" && len(parts) == 4 && parts[2] == "mergeto":
+		j.mergeVersion(w, parts[1], parts[3])
+	case endpoint == "GET /issue" && len(parts) == 2:
+		j.getIssue(w, r, parts[1])
+	case endpoint == "PUT /issue" && len(parts) == 2:
+		j.updateIssue(w, parts[1], body)
+	case endpoint == "GET /issue" && len(parts) == 3 && parts[2] == "transitions":
+		j.getTransitions(w, parts[1])
+	case endpoint == "POST /issue" && len(parts) == 3 && parts[2] == "transitions":
+		j.transitionIssue(w, parts[1], body)
+	case endpoint == "GET /issue" && len(parts) == 3 && parts[2] == "comment":
+		j.getComments(w, parts[1])
+	case endpoint == "POST /issue" && len(parts) == 3 && parts[2] == "comment":
+		j.addComment(w, parts[1], body)
+	case (endpoint == "GET /search" || endpoint == "POST /search") && len(parts) == 1:
+		j.search(w, r, body)
+	case endpoint == "GET /mypermissions" && len(parts) == 1:
+		j.getMyPermissions(w, r)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s %s is not supported by jiratest", r.Method, path), nil)
+	}
+}
+
+func (j *Jira) getProject(w http.ResponseWriter, key string) {
+	project := j.project(key)
+
+	if project == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("No project could be found with key '%s'.", key), nil)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, project)
+}
+
+func (j *Jira) getProjectVersions(w http.ResponseWriter, r *http.Request, key string) {
+	project := j.project(key)
+
+	if project == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("No project could be found with key '%s'.", key), nil)
+		return
+	}
+
+	versions := []map[string]interface{}{}
+
+	for _, version := range j.projectVersions(key) {
+		versions = append(versions, j.versionJSON(r, version))
+	}
+
+	writeJSON(w, http.StatusOK, versions)
+}
+
+func (j *Jira) createVersion(w http.ResponseWriter, r *http.Request, body []byte) {
+	var request struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Released    bool   `json:"released"`
+		Archived    bool   `json:"archived"`
+		ReleaseDate string `json:"releaseDate"`
+		Project     string `json:"project"`
+		ProjectId   int    `json:"projectId"`
+	}
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body: "+err.Error(), nil)
+		return
+	}
+
+	project := j.project(request.Project)
+
+	if request.Project == "" && request.ProjectId != 0 {
+		project = j.projectById(strconv.Itoa(request.ProjectId))
+	}
+
+	switch {
+	case project == nil:
+		writeError(w, http.StatusBadRequest, "", map[string]string{"project": "Project must be specified to create a version."})
+	case strings.TrimSpace(request.Name) == "":
+		writeError(w, http.StatusBadRequest, "", map[string]string{"name": "You must specify a valid version name"})
+	case j.versionByName(project.Key, request.Name) != nil:
+		writeError(w, http.StatusBadRequest, "", map[string]string{"name": "A version with this name already exists in this project."})
+	default:
+		version := &Version{
+			Id:          j.id(),
+			Name:        request.Name,
+			Description: request.Description,
+			Released:    request.Released,
+			Archived:    request.Archived,
+			ReleaseDate: request.ReleaseDate,
+			Project:     project.Key,
+		}
+		j.versions = append(j.versions, version)
+		j.changes.createdVersions = append(j.changes.createdVersions, version.Id)
+		writeJSON(w, http.StatusCreated, j.versionJSON(r, version))
+	}
+}
+
+func (j *Jira) getVersion(w http.ResponseWriter, r *http.Request, id string) {
+	version := j.versionById(id)
+
+	if version == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Could not find version for id '%s'", id), nil)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, j.versionJSON(r, version))
+}
+
+func (j *Jira) updateVersion(w http.ResponseWriter, r *http.Request, id string, body []byte) {
+	version := j.versionById(id)
+
+	if version == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Could not find version for id '%s'", id), nil)
+		return
+	}
+
+	var request struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		Released    *bool   `json:"released"`
+		Archived    *bool   `json:"archived"`
+		ReleaseDate *string `json:"releaseDate"`
+	}
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body: "+err.Error(), nil)
+		return
+	}
+
+	if request.Name != nil {
+		if existing := j.versionByName(version.Project, *request.Name); existing != nil && existing != version {
+			writeError(w, http.StatusBadRequest, "", map[string]string{"name": "A version with this name already exists in this project."})
+			return
+		}
+
+		j.renameVersion(version, *request.Name)
+	}
+
+	if request.Description != nil {
+		version.Description = *request.Description
+	}
+
+	if request.Released != nil {
+		version.Released = *request.Released
+	}
+
+	if request.Archived != nil {
+		version.Archived = *request.Archived
+	}
+
+	if request.ReleaseDate != nil {
+		version.ReleaseDate = *request.ReleaseDate
+	}
+
+	j.versionUpdated(version)
+
+	writeJSON(w, http.StatusOK, j.versionJSON(r, version))
+}
+
+func (j *Jira) deleteVersion(w http.ResponseWriter, id string) {
+	version := j.versionById(id)
+
+	if version == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Could not find version for id '%s'", id), nil)
+		return
+	}
+
+	j.removeVersion(version, "")
+	j.changes.deletedVersions = append(j.changes.deletedVersions, *version)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (j *Jira) mergeVersion(w http.ResponseWriter, id, targetId string) {
+	version, target := j.versionById(id), j.versionById(targetId)
+
+	switch {
+	case version == nil:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Could not find version for id '%s'", id), nil)
+	case target == nil:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Could not find version for id '%s'", targetId), nil)
+	case version == target || version.Project != target.Project:
+		writeError(w, http.StatusBadRequest, "A version can only be merged into another version of the same project.", nil)
+	default:
+		j.removeVersion(version, target.Name)
+		j.changes.deletedVersions = append(j.changes.deletedVersions, *version)
+		j.versionUpdated(target)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// versionUpdated records that the version was changed through the api
+func (j *Jira) versionUpdated(version *Version) {
+	if !containsName(j.changes.updatedVersions, version.Id) {
+		j.changes.updatedVersions = append(j.changes.updatedVersions, version.Id)
+	}
+}
+
+// issueUpdated records that the issue was changed through the api
+func (j *Jira) issueUpdated(issue *Issue) {
+	if !containsName(j.changes.updatedIssues, issue.Key) {
+		j.changes.updatedIssues = append(j.changes.updatedIssues, issue.Key)
+	}
+}
+
+// removeVersion deletes the version and removes it from the issues of its project. When replacement is not empty, the
+// issues get the replacement version instead.
+func (j *Jira) removeVersion(version *Version, replacement string) {
+	for i, v := range j.versions {
+		if v == version {
+			j.versions = append(j.versions[:i], j.versions[i+1:]...)
+			break
+		}
+	}
+
+	for _, issue := range j.issues {
+		if issueProject(issue.Key) != version.Project {
+			continue
+		}
+
+		issue.FixVersions = replaceName(issue.FixVersions, version.Name, replacement)
+		issue.AffectsVersions = replaceName(issue.AffectsVersions, version.Name, replacement)
+	}
+}
+
+// renameVersion renames the version and updates the issues which reference it
+func (j *Jira) renameVersion(version *Version, name string) {
+	for _, issue := range j.issues {
+		if issueProject(issue.Key) == version.Project {
+			issue.FixVersions = replaceName(issue.FixVersions, version.Name, name)
+			issue.AffectsVersions = replaceName(issue.AffectsVersions, version.Name, name)
+		}
+	}
+
+	version.Name = name
+}
+
+func (j *Jira) getIssue(w http.ResponseWriter, r *http.Request, key string) {
+	issue := j.issue(key)
+
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.", nil)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, j.issueJSON(r, issue))
+}
+
+func (j *Jira) updateIssue(w http.ResponseWriter, key string, body []byte) {
+	issue := j.issue(key)
+
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.", nil)
+		return
+	}
+
+	var request struct {
+		Update map[string][]versionOperation `json:"update"`
+		Fields map[string]interface{}        `json:"fields"`
+	}
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body: "+err.Error(), nil)
+		return
+	}
+
+	// Validate the whole request before changing the issue, like Jira does
+	fixVersions, affectsVersions := issue.FixVersions, issue.AffectsVersions
+	errs := map[string]string{}
+	project := issueProject(issue.Key)
+
+	for field, operations := range request.Update {
+		switch field {
+		case "fixVersions":
+			fixVersions = j.applyVersionOperations(project, "Fix Version", field, fixVersions, operations, errs)
+		case "versions":
+			affectsVersions = j.applyVersionOperations(project, "Affects Version", field, affectsVersions, operations, errs)
+		default:
+			errs[field] = fmt.Sprintf("Field '%s' cannot be updated with operations.", field)
+		}
+	}
+
+	for field := range request.Fields {
+		if !j.hasField(field) {
+			errs[field] = fmt.Sprintf("Field '%s' cannot be set. It is not on the appropriate screen, or unknown.", field)
+		} else if field == "fixVersions" || field == "versions" || field == "summary" {
+			errs[field] = fmt.Sprintf("Field '%s' is not supported by jiratest, use update instead.", field)
+		}
+	}
+
+	if len(errs) != 0 {
+		writeError(w, http.StatusBadRequest, "", errs)
+		return
+	}
+
+	issue.FixVersions, issue.AffectsVersions = fixVersions, affectsVersions
+
+	for field, value := range request.Fields {
+		if issue.Fields == nil {
+			issue.Fields = map[string]interface{}{}
+		}
+
+		issue.Fields[field] = value
+	}
+
+	j.issueUpdated(issue)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyVersionOperations applies the operations to the version names. Unknown versions are reported in errs.
+func (j *Jira) applyVersionOperations(project, label, field string, names []string, operations []versionOperation, errs map[string]string) []string {
+	names = append([]string(nil), names...)
+
+	resolve := func(reference versionReference) (string, bool) {
+		var version *Version
+
+		if reference.Id != "" {
+			if version = j.versionById(reference.Id); version != nil && version.Project != project {
+				version = nil
+			}
+		} else {
+			version = j.versionByName(project, reference.Name)
+		}
+
+		if version == nil {
+			errs[field] = fmt.Sprintf("%s name '%s' is not valid", label, reference.Name+reference.Id)
+			return "", false
+		}
+
+		return version.Name, true
+	}
+
+	for _, operation := range operations {
+		switch {
+		case operation.Add != nil:
+			if name, ok := resolve(*operation.Add); ok && !containsName(names, name) {
+				names = append(names, name)
+			}
+		case operation.Remove != nil:
+			if name, ok := resolve(*operation.Remove); ok {
+				names = replaceName(names, name, "")
+			}
+		default:
+			names = []string{}
+
+			for _, reference := range operation.Set {
+				if name, ok := resolve(reference); ok && !containsName(names, name) {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+
+	return names
+}
+
+// hasField reports whether the field with the provided id exists
+func (j *Jira) hasField(id string) bool {
+	for _, field := range j.fields {
+		if field.Id == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (j *Jira) getTransitions(w http.ResponseWriter, key string) {
+	if j.issue(key) == nil {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.", nil)
+		return
+	}
+
+	transitions := []map[string]interface{}{}
+
+	for _, transition := range j.transitions {
+		transitions = append(transitions, map[string]interface{}{
+			"id":   transition.Id,
+			"name": transition.Name,
+			"to":   map[string]string{"name": transition.To},
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"transitions": transitions})
+}
+
+func (j *Jira) transitionIssue(w http.ResponseWriter, key string, body []byte) {
+	issue := j.issue(key)
+
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.", nil)
+		return
+	}
+
+	var request struct {
+		Transition struct {
+			Id string `json:"id"`
+		} `json:"transition"`
+	}
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body: "+err.Error(), nil)
+		return
+	}
+
+	for _, transition := range j.transitions {
+		if transition.Id == request.Transition.Id {
+			issue.Status = transition.To
+			j.issueUpdated(issue)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	writeError(w, http.StatusBadRequest, fmt.Sprintf("Transition id '%s' is not valid for this issue.", request.Transition.Id), nil)
+}
+
+func (j *Jira) getComments(w http.ResponseWriter, key string) {
+	issue := j.issue(key)
+
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.", nil)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, commentsJSON(issue))
+}
+
+func (j *Jira) addComment(w http.ResponseWriter, key string, body []byte) {
+	issue := j.issue(key)
+
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.", nil)
+		return
+	}
+
+	var request struct {
+		Body string `json:"body"`
+	}
+
+	if err := json.Unmarshal(body, &request); err != nil || strings.TrimSpace(request.Body) == "" {
+		writeError(w, http.StatusBadRequest, "", map[string]string{"comment": "Comment body can not be empty!"})
+		return
+	}
+
+	issue.Comments = append(issue.Comments, request.Body)
+	j.issueUpdated(issue)
+	writeJSON(w, http.StatusCreated, map[string]string{"id": strconv.Itoa(len(issue.Comments)), "body": request.Body})
+}
+
+func (j *Jira) search(w http.ResponseWriter, r *http.Request, body []byte) {
+	request := struct {
+		Jql           string `json:"jql"`
+		StartAt       int    `json:"startAt"`
+		MaxResults    int    `json:"maxResults"`
+		ValidateQuery string `json:"validateQuery"`
+	}{MaxResults: 50}
+
+	if r.Method == http.MethodPost {
+		if err := json.Unmarshal(body, &request); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body: "+err.Error(), nil)
+			return
+		}
+	} else {
+		query := r.URL.Query()
+		request.Jql = query.Get("jql")
+		request.StartAt, _ = strconv.Atoi(query.Get("startAt"))
+		request.ValidateQuery = query.Get("validateQuery")
+
+		if maxResults, err := strconv.Atoi(query.Get("maxResults")); err == nil {
+			request.MaxResults = maxResults
+		}
+	}
+
+	query, err := parseJQL(request.Jql)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Error in the JQL Query: "+err.Error(), nil)
+		return
+	}
+
+	warnings := query.warnings(j)
+
+	// Like Jira, unknown issue keys are an error unless the query is validated with warn or none
+	if len(warnings) != 0 && request.ValidateQuery != "warn" && request.ValidateQuery != "none" {
+		writeError(w, http.StatusBadRequest, strings.Join(warnings, " "), nil)
+		return
+	}
+
+	var matches []*Issue
+
+	for _, issue := range j.issues {
+		if query.matches(j, issue) {
+			matches = append(matches, issue)
+		}
+	}
+
+	issues := []map[string]interface{}{}
+
+	for i := request.StartAt; i < len(matches) && i < request.StartAt+request.MaxResults; i++ {
+		issues = append(issues, j.issueJSON(r, matches[i]))
+	}
+
+	response := map[string]interface{}{
+		"startAt":    request.StartAt,
+		"maxResults": request.MaxResults,
+		"total":      len(matches),
+		"issues":     issues,
+	}
+
+	if len(warnings) != 0 && request.ValidateQuery == "warn" {
+		response["warningMessages"] = warnings
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// projectById returns the project with the provided id or nil
+// permissions are the ids and names of the permissions the mypermissions endpoint knows, by their key
+var permissions = map[string]struct{ id, name string }{
+	"ADMINISTER":          {"0", "Administer Jira"},
+	"BROWSE_PROJECTS":     {"10", "Browse Projects"},
+	"CREATE_ISSUES":       {"11", "Create Issues"},
+	"EDIT_ISSUES":         {"12", "Edit Issues"},
+	"RESOLVE_ISSUES":      {"14", "Resolve Issues"},
+	"ADD_COMMENTS":        {"15", "Add Comments"},
+	"ADMINISTER_PROJECTS": {"23", "Administer Projects"},
+	"TRANSITION_ISSUES":   {"46", "Transition Issues"},
+}
+
+func (j *Jira) getMyPermissions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var project *Project
+
+	if key := query.Get("projectKey"); key != "" {
+		if project = j.project(key); project == nil {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("No project could be found with key '%s'.", key), nil)
+			return
+		}
+	} else if id := query.Get("projectId"); id != "" {
+		if project = j.projectById(id); project == nil {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("No project could be found with id '%s'.", id), nil)
+			return
+		}
+	}
+
+	if query.Get("permissions") == "" {
+		writeError(w, http.StatusBadRequest, "The 'permissions' query parameter is required.", nil)
+		return
+	}
+
+	result := map[string]interface{}{}
+
+	for _, key := range strings.Split(query.Get("permissions"), ",") {
+		key = strings.TrimSpace(key)
+		permission, ok := permissions[key]
+
+		if !ok {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid permission key: %s", key), nil)
+			return
+		}
+
+		permissionType := "PROJECT"
+
+		if key == "ADMINISTER" {
+			permissionType = "GLOBAL"
+		}
+
+		have := true
+
+		if project != nil {
+			have = !containsName(j.denied[project.Key], key)
+		}
+
+		result[key] = map[string]interface{}{
+			"id":             permission.id,
+			"key":            key,
+			"name":           permission.name,
+			"type":           permissionType,
+			"description":    fmt.Sprintf("Ability to %s.", strings.ToLower(permission.name)),
+			"havePermission": have,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"permissions": result})
+}
+
+func (j *Jira) projectById(id string) *Project {
+	for _, project := range j.projects {
+		if project.Id == id {
+			return project
+		}
+	}
+
+	return nil
+}
+
+// versionJSON returns the representation of the version in the Jira API
+func (j *Jira) versionJSON(r *http.Request, version *Version) map[string]interface{} {
+	projectId, _ := strconv.Atoi(j.project(version.Project).Id)
+
+	return map[string]interface{}{
+		"self":        apiURL(r, "/version/"+version.Id),
+		"id":          version.Id,
+		"name":        version.Name,
+		"description": version.Description,
+		"archived":    version.Archived,
+		"released":    version.Released,
+		"releaseDate": version.ReleaseDate,
+		"projectId":   projectId,
+	}
+}
+
+// issueJSON returns the representation of the issue in the Jira API
+func (j *Jira) issueJSON(r *http.Request, issue *Issue) map[string]interface{} {
+	project := j.project(issueProject(issue.Key))
+	fields := map[string]interface{}{
+		"summary":     issue.Summary,
+		"issuetype":   map[string]string{"name": issue.Type},
+		"status":      map[string]string{"name": issue.Status},
+		"project":     map[string]string{"id": project.Id, "key": project.Key, "name": project.Name},
+		"fixVersions": j.versionReferences(project.Key, issue.FixVersions),
+		"versions":    j.versionReferences(project.Key, issue.AffectsVersions),
+		"comment":     commentsJSON(issue),
+	}
+
+	for field, value := range issue.Fields {
+		fields[field] = value
+	}
+
+	return map[string]interface{}{
+		"id":     issue.Id,
+		"key":    issue.Key,
+		"self":   apiURL(r, "/issue/"+issue.Id),
+		"fields": fields,
+	}
+}
+
+// versionReferences returns the references to the versions with the provided names, as used in the fields of issues
+func (j *Jira) versionReferences(project string, names []string) []map[string]interface{} {
+	references := []map[string]interface{}{}
+
+	for _, name := range names {
+		if version := j.versionByName(project, name); version != nil {
+			references = append(references, map[string]interface{}{
+				"id":       version.Id,
+				"name":     version.Name,
+				"released": version.Released,
+				"archived": version.Archived,
+			})
+		}
+	}
+
+	return references
+}
+
+// commentsJSON returns the representation of the comments of the issue in the Jira API
+func commentsJSON(issue *Issue) map[string]interface{} {
+	comments := []map[string]string{}
+
+	for i, comment := range issue.Comments {
+		comments = append(comments, map[string]string{"id": strconv.Itoa(i + 1), "body": comment})
+	}
+
+	return map[string]interface{}{"startAt": 0, "maxResults": len(comments), "total": len(comments), "comments": comments}
+}
+
+// apiURL returns the absolute url of the api path
+func apiURL(r *http.Request, path string) string {
+	scheme := "http"
+
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host + "/rest/api/2" + path
+}
+
+// writeJSON writes the value as a JSON response with the provided status
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.Header().Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// writeError writes an error response in the format of the Jira API
+func writeError(w http.ResponseWriter, status int, message string, errs map[string]string) {
+	response := errorResponse{ErrorMessages: []string{}, Errors: map[string]string{}}
+
+	if message != "" {
+		response.ErrorMessages = append(response.ErrorMessages, message)
+	}
+
+	for field, err := range errs {
+		response.Errors[field] = err
+	}
+
+	writeJSON(w, status, response)
+}
+
+// basicCredentials returns the encoded credentials of a basic authorization header
+func basicCredentials(user, token string) string {
+	return base64.StdEncoding.EncodeToString([]byte(user + ":" + token))
+}
+
+// containsName reports whether the names contain the name
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// replaceName replaces the name with the replacement, or removes it when the replacement is empty. The replacement is
+// not added twice.
+func replaceName(names []string, name, replacement string) []string {
+	var result []string
+
+	for _, n := range names {
+		if n != name {
+			result = append(result, n)
+		} else if replacement != "" && !containsName(names, replacement) && !containsName(result, replacement) {
+			result = append(result, replacement)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,435 @@
+// Package jiratest provides an in-memory fake of the Jira REST API for tests. It emulates projects, versions, issues
+// with their fix versions, affects versions, fields, transitions and comments, the permissions of the user and the
+// search endpoint, and can fail requests on purpose to test error handling. Jira is an http.Handler which does not
+// depend on the testing package; package testserver runs it for the duration of a test.
+package jiratest
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Project is a Jira project
+type Project struct {
+	Id   string `json:"id" yaml:"id,omitempty"`
+	Key  string `json:"key" yaml:"key"`
+	Name string `json:"name" yaml:"name,omitempty"`
+}
+
+// Version is a version of a project
+type Version struct {
+	Id          string `json:"id" yaml:"id,omitempty"`
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Archived    bool   `json:"archived" yaml:"archived,omitempty"`
+	Released    bool   `json:"released" yaml:"released,omitempty"`
+	ReleaseDate string `json:"releaseDate,omitempty" yaml:"releaseDate,omitempty"`
+	Project     string `json:"project,omitempty" yaml:"-"`
+}
+
+// Issue is a Jira issue. Its versions are referenced by name. MovedFrom holds the keys the issue had before it was
+// moved to another project, which Jira still resolves to the issue.
+type Issue struct {
+	Id              string                 `json:"id" yaml:"id,omitempty"`
+	Key             string                 `json:"key" yaml:"key"`
+	Type            string                 `json:"type" yaml:"type,omitempty"`
+	Status          string                 `json:"status" yaml:"status,omitempty"`
+	Summary         string                 `json:"summary,omitempty" yaml:"summary,omitempty"`
+	FixVersions     []string               `json:"fixVersions" yaml:"fixVersions,omitempty"`
+	AffectsVersions []string               `json:"affectsVersions" yaml:"affectsVersions,omitempty"`
+	Fields          map[string]interface{} `json:"fields,omitempty" yaml:"fields,omitempty"`
+	Comments        []string               `json:"comments,omitempty" yaml:"comments,omitempty"`
+	MovedFrom       []string               `json:"movedFrom,omitempty" yaml:"movedFrom,omitempty"`
+}
+
+// Field is a (custom) field, in the format of the Jira field endpoint
+type Field struct {
+	Id     string      `json:"id" yaml:"id"`
+	Name   string      `json:"name" yaml:"name"`
+	Custom bool        `json:"custom" yaml:"custom,omitempty"`
+	Schema FieldSchema `json:"schema" yaml:"schema"`
+}
+
+// FieldSchema describes the type of the values of a field
+type FieldSchema struct {
+	Type  string `json:"type" yaml:"type"`
+	Items string `json:"items,omitempty" yaml:"items,omitempty"`
+}
+
+// Transition moves an issue to another status
+type Transition struct {
+	Id   string `json:"id" yaml:"id"`
+	Name string `json:"name" yaml:"name"`
+	To   string `json:"to" yaml:"to"`
+}
+
+// Request is a request received by the fake
+type Request struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Body   string `json:"body,omitempty"`
+}
+
+// defaultFields are the system fields every Jira instance has
+var defaultFields = []Field{
+	{Id: "summary", Name: "Summary", Schema: FieldSchema{Type: "string"}},
+	{Id: "issuetype", Name: "Issue Type", Schema: FieldSchema{Type: "issuetype"}},
+	{Id: "status", Name: "Status", Schema: FieldSchema{Type: "status"}},
+	{Id: "fixVersions", Name: "Fix versions", Schema: FieldSchema{Type: "array", Items: "version"}},
+	{Id: "versions", Name: "Affects versions", Schema: FieldSchema{Type: "array", Items: "version"}},
+}
+
+// defaultTransitions are the transitions of the default workflow
+var defaultTransitions = []Transition{
+	{Id: "11", Name: "To Do", To: "To Do"},
+	{Id: "21", Name: "In Progress", To: "In Progress"},
+	{Id: "31", Name: "Done", To: "Done"},
+}
+
+// Jira is an in-memory fake of the Jira REST API, which serves the api under /rest/api/2, /rest/api/3 and
+// /rest/api/latest. It is safe for concurrent use.
+type Jira struct {
+	mu          sync.Mutex
+	projects    []*Project
+	versions    []*Version
+	issues      []*Issue
+	fields      []Field
+	transitions []Transition
+	requests    []Request
+	failures    []*failure
+	auth        string
+	nextId      int
+	changes     changes
+	denied      map[string][]string
+}
+
+// Changes are the versions and issues which were changed through the api, in their current state. Versions which
+// were created and then updated are only reported as created.
+type Changes struct {
+	CreatedVersions []Version `json:"createdVersions"`
+	UpdatedVersions []Version `json:"updatedVersions"`
+	DeletedVersions []Version `json:"deletedVersions"`
+	UpdatedIssues   []Issue   `json:"updatedIssues"`
+}
+
+// changes tracks the ids of the changed versions and the keys of the changed issues
+type changes struct {
+	createdVersions []string
+	updatedVersions []string
+	deletedVersions []Version
+	updatedIssues   []string
+}
+
+// failure makes requests matching the method and path fail with the status
+type failure struct {
+	method string
+	path   string
+	status int
+	times  int
+}
+
+// New creates an empty fake with the default fields and workflow
+func New() *Jira {
+	return &Jira{
+		fields:      append([]Field(nil), defaultFields...),
+		transitions: append([]Transition(nil), defaultTransitions...),
+		nextId:      10000,
+	}
+}
+
+// id returns a new unique id
+func (j *Jira) id() string {
+	j.nextId++
+	return strconv.Itoa(j.nextId)
+}
+
+// AddProject adds a project with the provided key, or returns the project when it already exists
+func (j *Jira) AddProject(key string) Project {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return *j.addProject(key)
+}
+
+func (j *Jira) addProject(key string) *Project {
+	if project := j.project(key); project != nil {
+		return project
+	}
+
+	project := &Project{Id: j.id(), Key: key, Name: key}
+	j.projects = append(j.projects, project)
+	return project
+}
+
+// AddVersion adds a version to the project, which is created when it does not exist. The id of the version is
+// generated when it is empty.
+func (j *Jira) AddVersion(project string, version Version) Version {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.addProject(project)
+	version.Project = project
+
+	if version.Id == "" {
+		version.Id = j.id()
+	}
+
+	j.versions = append(j.versions, &version)
+	return version
+}
+
+// AddIssue adds an issue. The project is taken from the key and created when it does not exist. The type defaults to
+// Task and the status to To Do.
+func (j *Jira) AddIssue(issue Issue) Issue {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.addProject(issueProject(issue.Key))
+
+	if issue.Id == "" {
+		issue.Id = j.id()
+	}
+
+	if issue.Type == "" {
+		issue.Type = "Task"
+	}
+
+	if issue.Status == "" {
+		issue.Status = defaultTransitions[0].To
+	}
+
+	j.issues = append(j.issues, &issue)
+	return issue
+}
+
+// AddField adds a (custom) field, which can then be set on issues
+func (j *Jira) AddField(field Field) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.fields = append(j.fields, field)
+}
+
+// RequireBasicAuth makes every request without the provided user and token fail with 401 Unauthorized
+func (j *Jira) RequireBasicAuth(user, token string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.auth = "Basic " + basicCredentials(user, token)
+}
+
+// RequireBearerToken makes every request without the provided bearer token fail with 401 Unauthorized
+func (j *Jira) RequireBearerToken(token string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.auth = "Bearer " + token
+}
+
+// Fail makes the next requests with the method and path fail with the status, e.g. 429 Too Many Requests or 500
+// Internal Server Error. An empty method matches every method. The path is relative to the api, e.g. /issue/MB-1,
+// and an empty path matches every path. A negative number of times makes every matching request fail.
+func (j *Jira) Fail(method, path string, status int, times int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.failures = append(j.failures, &failure{method: method, path: path, status: status, times: times})
+}
+
+// DenyPermission makes the mypermissions endpoint report that the user does not have the permissions in the project,
+// e.g. ADMINISTER_PROJECTS. The user has every other permission. The other endpoints do not check permissions.
+func (j *Jira) DenyPermission(project string, permissions ...string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.denied == nil {
+		j.denied = map[string][]string{}
+	}
+
+	j.denied[project] = append(j.denied[project], permissions...)
+}
+
+// Project returns the project with the provided key
+func (j *Jira) Project(key string) (Project, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if project := j.project(key); project != nil {
+		return *project, true
+	}
+
+	return Project{}, false
+}
+
+// Version returns the version with the provided name in the project
+func (j *Jira) Version(project, name string) (Version, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if version := j.versionByName(project, name); version != nil {
+		return *version, true
+	}
+
+	return Version{}, false
+}
+
+// Versions returns the versions of the project
+func (j *Jira) Versions(project string) []Version {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var versions []Version
+
+	for _, version := range j.projectVersions(project) {
+		versions = append(versions, *version)
+	}
+
+	return versions
+}
+
+// Issue returns the issue with the provided key
+func (j *Jira) Issue(key string) (Issue, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if issue := j.issue(key); issue != nil {
+		return copyIssue(issue), true
+	}
+
+	return Issue{}, false
+}
+
+// Issues returns all issues
+func (j *Jira) Issues() []Issue {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var issues []Issue
+
+	for _, issue := range j.issues {
+		issues = append(issues, copyIssue(issue))
+	}
+
+	return issues
+}
+
+// Changes returns the versions and issues which were changed through the api
+func (j *Jira) Changes() Changes {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	result := Changes{
+		CreatedVersions: []Version{},
+		UpdatedVersions: []Version{},
+		DeletedVersions: append([]Version{}, j.changes.deletedVersions...),
+		UpdatedIssues:   []Issue{},
+	}
+
+	for _, id := range j.changes.createdVersions {
+		if version := j.versionById(id); version != nil {
+			result.CreatedVersions = append(result.CreatedVersions, *version)
+		}
+	}
+
+	for _, id := range j.changes.updatedVersions {
+		if version := j.versionById(id); version != nil && !containsName(j.changes.createdVersions, id) {
+			result.UpdatedVersions = append(result.UpdatedVersions, *version)
+		}
+	}
+
+	for _, key := range j.changes.updatedIssues {
+		if issue := j.issue(key); issue != nil {
+			result.UpdatedIssues = append(result.UpdatedIssues, copyIssue(issue))
+		}
+	}
+
+	return result
+}
+
+// Requests returns the requests received by the fake, in order
+func (j *Jira) Requests() []Request {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]Request(nil), j.requests...)
+}
+
+// project returns the project with the provided key or nil
+func (j *Jira) project(key string) *Project {
+	for _, project := range j.projects {
+		if project.Key == key {
+			return project
+		}
+	}
+
+	return nil
+}
+
+// projectVersions returns the versions of the project
+func (j *Jira) projectVersions(project string) []*Version {
+	var versions []*Version
+
+	for _, version := range j.versions {
+		if version.Project == project {
+			versions = append(versions, version)
+		}
+	}
+
+	return versions
+}
+
+// versionByName returns the version with the provided name in the project or nil
+func (j *Jira) versionByName(project, name string) *Version {
+	for _, version := range j.projectVersions(project) {
+		if version.Name == name {
+			return version
+		}
+	}
+
+	return nil
+}
+
+// versionById returns the version with the provided id or nil
+func (j *Jira) versionById(id string) *Version {
+	for _, version := range j.versions {
+		if version.Id == id {
+			return version
+		}
+	}
+
+	return nil
+}
+
+// issue returns the issue with the provided key, previous key or id, or nil
+func (j *Jira) issue(keyOrId string) *Issue {
+	for _, issue := range j.issues {
+		if issue.Key == keyOrId || issue.Id == keyOrId || containsName(issue.MovedFrom, keyOrId) {
+			return issue
+		}
+	}
+
+	return nil
+}
+
+// issueProject returns the project key of the issue key, e.g. MB for MB-1
+func issueProject(key string) string {
+	if i := strings.LastIndex(key, "-"); i > 0 {
+		return key[:i]
+	}
+
+	return key
+}
+
+// copyIssue returns a copy of the issue which does not share its slices and fields with the issue
+func copyIssue(issue *Issue) Issue {
+	c := *issue
+	c.FixVersions = append([]string(nil), issue.FixVersions...)
+	c.AffectsVersions = append([]string(nil), issue.AffectsVersions...)
+	c.MovedFrom = append([]string(nil), issue.MovedFrom...)
+	c.Comments = append([]string(nil), issue.Comments...)
+
+	if issue.Fields != nil {
+		c.Fields = make(map[string]interface{}, len(issue.Fields))
+
+		for key, value := range issue.Fields {
+			c.Fields[key] = value
+		}
+	}
+
+	return c
+}
@@ -0,0 +1,374 @@
+package jiratest_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/marcelblijleven/jira-helper/pkg"
+	"github.com/marcelblijleven/jira-helper/pkg/jiratest"
+	"github.com/marcelblijleven/jira-helper/pkg/jiratest/testserver"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+// newClient creates a Jira client for the server which authenticates with the credentials required by the server
+func newClient(t *testing.T, server *testserver.Server) *pkg.JiraClient {
+	server.RequireBasicAuth("marcel@test.nl", "c0ffee")
+	client, err := pkg.NewJiraClient(server.URL, "marcel@test.nl", "c0ffee", http.DefaultClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+// do sends a request to the server without authentication and returns the status and decoded body
+func do(t *testing.T, server *testserver.Server, method, path, body string) (int, map[string]interface{}) {
+	req, err := http.NewRequest(method, server.URL+"/rest/api/2"+path, bytes.NewReader([]byte(body)))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer res.Body.Close()
+	data, _ := ioutil.ReadAll(res.Body)
+	var response map[string]interface{}
+	_ = json.Unmarshal(data, &response)
+	return res.StatusCode, response
+}
+
+func TestServer_versions(t *testing.T) {
+	server := testserver.New(t)
+	client := newClient(t, server)
+	server.AddVersion("MB", jiratest.Version{Name: "1.0.0"})
+	server.AddIssue(jiratest.Issue{Key: "MB-1", FixVersions: []string{"1.0.0"}})
+
+	version, err := client.CreateFixVersion("1.1.0", "MB")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1.0", version.Name)
+	server.AssertVersionExists("MB", "1.1.0")
+
+	_, err = client.CreateFixVersion("1.1.0", "MB")
+	assert.EqualError(t, err, "could not create fix version: request unsuccessful (400 Bad Request): name: A version with this name already exists in this project.")
+
+	assert.NoError(t, client.ReleaseVersion(version.Id))
+	server.AssertVersionReleased("MB", "1.1.0", true)
+
+	found, err := client.FindVersion("MB", "1.0.0")
+	assert.NoError(t, err)
+	assert.NoError(t, client.MergeVersion(found.Id, version.Id))
+	server.AssertNoVersion("MB", "1.0.0")
+	server.AssertFixVersions("MB-1", "1.1.0")
+
+	assert.NoError(t, client.DeleteVersion(version.Id))
+	server.AssertNoVersion("MB", "1.1.0")
+	server.AssertFixVersions("MB-1")
+
+	_, err = client.GetProjectVersions("XX")
+	assert.True(t, pkg.IsNotFound(err))
+}
+
+func TestServer_issues(t *testing.T) {
+	server := testserver.New(t)
+	client := newClient(t, server)
+	server.AddVersion("MB", jiratest.Version{Name: "1.0.0"})
+	server.AddVersion("MB", jiratest.Version{Name: "1.1.0"})
+	server.AddField(jiratest.Field{Id: "customfield_10001", Name: "Team", Custom: true, Schema: jiratest.FieldSchema{Type: "string"}})
+	server.AddIssue(jiratest.Issue{Key: "MB-1", FixVersions: []string{"1.0.0"}})
+
+	assert.NoError(t, client.AssignVersionWithFields("MB-1", "1.1.0", map[string]string{"Team": "Platform"}))
+	server.AssertFixVersions("MB-1", "1.0.0", "1.1.0")
+	server.AssertField("MB-1", "customfield_10001", "Platform")
+
+	assert.NoError(t, client.UpdateIssue("MB-1", pkg.IssueUpdate{
+		FixVersions:     pkg.VersionChange{Remove: []string{"1.0.0"}},
+		AffectsVersions: pkg.VersionChange{Set: []string{"1.0.0"}},
+	}))
+	server.AssertFixVersions("MB-1", "1.1.0")
+	server.AssertAffectsVersions("MB-1", "1.0.0")
+
+	err := client.AssignVersion("MB-1", "2.0.0")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "fixVersions: Fix Version name '2.0.0' is not valid")
+	server.AssertFixVersions("MB-1", "1.1.0")
+
+	assert.True(t, pkg.IsNotFound(client.AssignVersion("MB-2", "1.1.0")))
+}
+
+func TestServer_search(t *testing.T) {
+	server := testserver.New(t)
+	client := newClient(t, server)
+	version := server.AddVersion("MB", jiratest.Version{Name: "1.0.0"})
+	server.AddIssue(jiratest.Issue{Key: "MB-1", FixVersions: []string{"1.0.0"}})
+	server.AddIssue(jiratest.Issue{Key: "MB-2", Type: "Bug", Status: "Done", FixVersions: []string{"1.0.0"}})
+	server.AddIssue(jiratest.Issue{Key: "MB-3"})
+	server.AddIssue(jiratest.Issue{Key: "XX-1", FixVersions: []string{"1.0.0"}})
+
+	tests := []struct {
+		jql  string
+		want []string
+	}{
+		{jql: `project = MB AND fixVersion = "1.0.0"`, want: []string{"MB-1", "MB-2"}},
+		{jql: "project = MB AND fixVersion = " + version.Id, want: []string{"MB-1", "MB-2"}},
+		{jql: "key in (MB-1, MB-3)", want: []string{"MB-1", "MB-3"}},
+		{jql: "issuetype = bug and status != 'To Do'", want: []string{"MB-2"}},
+		{jql: "project = MB AND fixVersion is EMPTY ORDER BY key", want: []string{"MB-3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.jql, func(t *testing.T) {
+			keys, err := client.SearchIssues(tt.jql)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, keys)
+		})
+	}
+
+	_, err := client.SearchIssues("key in (MB-1, MB-9)")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "An issue with key 'MB-9' does not exist for field 'key'.")
+
+	_, err = client.SearchIssues("summary ~ release")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Error in the JQL Query: field 'summary' is not supported")
+}
+
+func TestServer_searchPagination(t *testing.T) {
+	server := testserver.New(t)
+
+	for _, key := range []string{"MB-1", "MB-2", "MB-3"} {
+		server.AddIssue(jiratest.Issue{Key: key})
+	}
+
+	status, response := do(t, server, http.MethodPost, "/search", `{"jql":"key in (MB-1, MB-2, MB-3, MB-4)","startAt":1,"maxResults":1,"validateQuery":"warn"}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, float64(3), response["total"])
+	assert.Len(t, response["issues"], 1)
+	assert.Equal(t, "MB-2", response["issues"].([]interface{})[0].(map[string]interface{})["key"])
+	assert.Equal(t, []interface{}{"An issue with key 'MB-4' does not exist for field 'key'."}, response["warningMessages"])
+}
+
+func TestServer_movedIssue(t *testing.T) {
+	server := testserver.New(t)
+	server.AddVersion("XX", jiratest.Version{Name: "1.0.0"})
+	server.AddIssue(jiratest.Issue{Key: "XX-5", MovedFrom: []string{"MB-1"}})
+
+	status, response := do(t, server, http.MethodGet, "/issue/MB-1", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "XX-5", response["key"])
+
+	status, response = do(t, server, http.MethodPost, "/search", `{"jql":"key in (MB-1)"}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "XX-5", response["issues"].([]interface{})[0].(map[string]interface{})["key"])
+
+	status, _ = do(t, server, http.MethodPut, "/issue/MB-1", `{"update":{"fixVersions":[{"add":{"name":"1.0.0"}}]}}`)
+	assert.Equal(t, http.StatusNoContent, status)
+	server.AssertFixVersions("XX-5", "1.0.0")
+}
+
+func TestServer_myPermissions(t *testing.T) {
+	server := testserver.New(t)
+	server.AddProject("MB")
+	server.DenyPermission("MB", "ADMINISTER_PROJECTS")
+
+	status, response := do(t, server, http.MethodGet, "/mypermissions?projectKey=MB&permissions=EDIT_ISSUES,ADMINISTER_PROJECTS", "")
+	assert.Equal(t, http.StatusOK, status)
+	permissions := response["permissions"].(map[string]interface{})
+	assert.Len(t, permissions, 2)
+	assert.Equal(t, true, permissions["EDIT_ISSUES"].(map[string]interface{})["havePermission"])
+	assert.Equal(t, false, permissions["ADMINISTER_PROJECTS"].(map[string]interface{})["havePermission"])
+	assert.Equal(t, "Administer Projects", permissions["ADMINISTER_PROJECTS"].(map[string]interface{})["name"])
+
+	status, response = do(t, server, http.MethodGet, "/mypermissions?projectKey=XX&permissions=EDIT_ISSUES", "")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, []interface{}{"No project could be found with key 'XX'."}, response["errorMessages"])
+
+	status, response = do(t, server, http.MethodGet, "/mypermissions?projectKey=MB&permissions=FLY", "")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, []interface{}{"Invalid permission key: FLY"}, response["errorMessages"])
+
+	status, _ = do(t, server, http.MethodGet, "/mypermissions?projectKey=MB", "")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestServer_transitionsAndComments(t *testing.T) {
+	server := testserver.New(t)
+	server.AddIssue(jiratest.Issue{Key: "MB-1"})
+
+	status, response := do(t, server, http.MethodGet, "/issue/MB-1/transitions", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, response["transitions"], 3)
+
+	status, _ = do(t, server, http.MethodPost, "/issue/MB-1/transitions", `{"transition":{"id":"31"}}`)
+	assert.Equal(t, http.StatusNoContent, status)
+	server.AssertStatus("MB-1", "Done")
+
+	status, response = do(t, server, http.MethodPost, "/issue/MB-1/transitions", `{"transition":{"id":"99"}}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, []interface{}{"Transition id '99' is not valid for this issue."}, response["errorMessages"])
+
+	status, _ = do(t, server, http.MethodPost, "/issue/MB-1/comment", `{"body":"Released in 1.0.0"}`)
+	assert.Equal(t, http.StatusCreated, status)
+	server.AssertComment("MB-1", "1.0.0")
+
+	status, response = do(t, server, http.MethodGet, "/issue/MB-1", "")
+	assert.Equal(t, http.StatusOK, status)
+	fields := response["fields"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"name": "Done"}, fields["status"])
+	assert.Equal(t, float64(1), fields["comment"].(map[string]interface{})["total"])
+}
+
+func TestServer_errors(t *testing.T) {
+	server := testserver.New(t)
+	client := newClient(t, server)
+	server.AddVersion("MB", jiratest.Version{Name: "1.0.0"})
+	server.AddIssue(jiratest.Issue{Key: "MB-1"})
+
+	status, response := do(t, server, http.MethodGet, "/issue/MB-1", "")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, []interface{}{"You are not authenticated. Authentication required to perform this operation."}, response["errorMessages"])
+
+	unauthorized, err := pkg.NewJiraClient(server.URL, "marcel@test.nl", "wrong", http.DefaultClient)
+	assert.NoError(t, err)
+	assert.True(t, pkg.IsUnauthorized(unauthorized.AssignVersion("MB-1", "1.0.0")))
+
+	server.Fail(http.MethodPut, "/issue/MB-1", http.StatusTooManyRequests, 1)
+	err = client.AssignVersion("MB-1", "1.0.0")
+	assert.True(t, pkg.IsRateLimited(err))
+
+	var jiraErr *pkg.JiraError
+	assert.ErrorAs(t, err, &jiraErr)
+	assert.Equal(t, "1s", jiraErr.RetryAfter.String())
+
+	assert.NoError(t, client.AssignVersion("MB-1", "1.0.0"))
+	server.AssertFixVersions("MB-1", "1.0.0")
+
+	server.Fail("", "", http.StatusInternalServerError, -1)
+	_, err = client.GetProjectVersions("MB")
+	assert.EqualError(t, err, "could not retrieve versions of project MB: request unsuccessful (500 Internal Server Error): Internal Server Error")
+	_, err = client.GetProjectVersions("MB")
+	assert.Error(t, err)
+
+	status, _ = do(t, server, http.MethodGet, "/unknown", "")
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestJira_Requests(t *testing.T) {
+	server := testserver.New(t)
+	server.AddIssue(jiratest.Issue{Key: "MB-1"})
+
+	do(t, server, http.MethodPost, "/issue/MB-1/comment", `{"body":"hello"}`)
+	do(t, server, http.MethodGet, "/unknown", "")
+
+	assert.Equal(t, []jiratest.Request{
+		{Method: http.MethodPost, Path: "/issue/MB-1/comment", Body: `{"body":"hello"}`},
+		{Method: http.MethodGet, Path: "/unknown"},
+	}, server.Requests())
+}
+
+func TestJira_Seed(t *testing.T) {
+	fixtures, err := jiratest.LoadFixtures("testdata/fixtures.yaml")
+	assert.NoError(t, err)
+
+	server := testserver.New(t)
+	assert.NoError(t, server.Seed(*fixtures))
+
+	project, ok := server.Project("MB")
+	assert.True(t, ok)
+	assert.Equal(t, "My Board", project.Name)
+	server.AssertVersionReleased("MB", "1.0.0", true)
+	server.AssertVersionReleased("MB", "1.1.0", false)
+	server.AssertFixVersions("MB-1", "1.0.0")
+	server.AssertAffectsVersions("MB-2", "1.0.0")
+	server.AssertStatus("MB-2", "In Progress")
+	server.AssertStatus("MB-3", "To Do")
+
+	version, _ := server.Version("MB", "1.0.0")
+	assert.Equal(t, "2022-03-01", version.ReleaseDate)
+
+	client := newClient(t, server)
+	assert.NoError(t, client.AssignVersionWithFields("MB-3", "1.1.0", map[string]string{"Team": "Platform"}))
+	server.AssertField("MB-3", "customfield_10001", "Platform")
+
+	permissions, err := client.GetMyPermissions("OPS", "ADMINISTER_PROJECTS", "EDIT_ISSUES")
+	assert.NoError(t, err)
+	assert.False(t, permissions["ADMINISTER_PROJECTS"].HavePermission)
+	assert.True(t, permissions["EDIT_ISSUES"].HavePermission)
+}
+
+func TestJira_Seed_invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixtures jiratest.Fixtures
+		wantErr  string
+	}{
+		{
+			name:     "project without key",
+			fixtures: jiratest.Fixtures{Projects: []jiratest.ProjectFixture{{Name: "My Board"}}},
+			wantErr:  "invalid fixtures: project without key",
+		},
+		{
+			name:     "issue of another project",
+			fixtures: jiratest.Fixtures{Projects: []jiratest.ProjectFixture{{Key: "MB", Issues: []jiratest.Issue{{Key: "XX-1"}}}}},
+			wantErr:  `invalid fixtures: issue "XX-1" does not belong to project MB`,
+		},
+		{
+			name:     "unknown version",
+			fixtures: jiratest.Fixtures{Projects: []jiratest.ProjectFixture{{Key: "MB", Issues: []jiratest.Issue{{Key: "MB-1", FixVersions: []string{"2.0.0"}}}}}},
+			wantErr:  `invalid fixtures: issue MB-1 references unknown version "2.0.0"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jira := jiratest.New()
+			assert.EqualError(t, jira.Seed(tt.fixtures), tt.wantErr)
+			assert.Empty(t, jira.Issues())
+		})
+	}
+}
+
+func TestJira_Changes(t *testing.T) {
+	server := testserver.New(t)
+	client := newClient(t, server)
+	old := server.AddVersion("MB", jiratest.Version{Name: "0.9.0"})
+	server.AddVersion("MB", jiratest.Version{Name: "0.9.1"})
+	server.AddIssue(jiratest.Issue{Key: "MB-1"})
+	server.AddIssue(jiratest.Issue{Key: "MB-2"})
+
+	version, err := client.CreateFixVersion("1.0.0", "MB")
+	assert.NoError(t, err)
+	assert.NoError(t, client.ReleaseVersion(version.Id))
+	assert.NoError(t, client.ArchiveVersion(old.Id))
+	assert.NoError(t, client.AssignVersion("MB-1", "1.0.0"))
+	found, err := client.FindVersion("MB", "0.9.1")
+	assert.NoError(t, err)
+	assert.NoError(t, client.DeleteVersion(found.Id))
+
+	changes := server.Changes()
+	assert.Equal(t, []jiratest.Version{{Id: version.Id, Name: "1.0.0", Released: true, ReleaseDate: version.ReleaseDate, Project: "MB"}}, changes.CreatedVersions)
+	assert.Equal(t, []jiratest.Version{{Id: old.Id, Name: "0.9.0", Archived: true, Project: "MB"}}, changes.UpdatedVersions)
+	assert.Equal(t, []jiratest.Version{{Id: found.Id, Name: "0.9.1", Project: "MB"}}, changes.DeletedVersions)
+	assert.Len(t, changes.UpdatedIssues, 1)
+	assert.Equal(t, "MB-1", changes.UpdatedIssues[0].Key)
+
+	res, err := http.Get(server.URL + jiratest.InspectPath)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+
+	var inspection struct {
+		jiratest.Changes
+		Requests []jiratest.Request `json:"requests"`
+	}
+
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&inspection))
+	assert.Equal(t, changes, inspection.Changes)
+	assert.Len(t, inspection.Requests, 6)
+}
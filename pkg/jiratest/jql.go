@@ -0,0 +1,324 @@
+package jiratest
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// jqlQuery is a parsed JQL query. The fake supports a subset of JQL: clauses on the project, key, fixVersion,
+// affectedVersion, issuetype and status fields with the =, !=, in, not in, is empty and is not empty operators,
+// combined with AND. An ORDER BY clause is ignored.
+type jqlQuery struct {
+	clauses []jqlClause
+}
+
+// jqlClause is a single condition of a query
+type jqlClause struct {
+	field    string
+	operator string
+	values   []string
+}
+
+// jqlFields maps the supported field names and aliases to their canonical name
+var jqlFields = map[string]string{
+	"project":         "project",
+	"key":             "key",
+	"issue":           "key",
+	"issuekey":        "key",
+	"fixversion":      "fixVersion",
+	"affectedversion": "affectedVersion",
+	"issuetype":       "issuetype",
+	"type":            "issuetype",
+	"status":          "status",
+}
+
+// parseJQL parses the supported subset of JQL
+func parseJQL(jql string) (*jqlQuery, error) {
+	tokens, err := tokenizeJQL(jql)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := &jqlQuery{}
+	p := &jqlParser{tokens: tokens}
+
+	for !p.done() {
+		if strings.EqualFold(p.peek(), "order") {
+			break
+		}
+
+		if len(query.clauses) != 0 {
+			if !strings.EqualFold(p.next(), "and") {
+				return nil, fmt.Errorf("only AND is supported between clauses, got '%s'", p.last())
+			}
+		}
+
+		clause, err := p.clause()
+
+		if err != nil {
+			return nil, err
+		}
+
+		query.clauses = append(query.clauses, clause)
+	}
+
+	return query, nil
+}
+
+// matches reports whether the issue matches all clauses of the query
+func (q *jqlQuery) matches(j *Jira, issue *Issue) bool {
+	for _, clause := range q.clauses {
+		if !clause.matches(j, issue) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// warnings returns the warnings Jira gives for the query, which are the issue keys which do not exist
+func (q *jqlQuery) warnings(j *Jira) []string {
+	var warnings []string
+
+	for _, clause := range q.clauses {
+		if clause.field != "key" {
+			continue
+		}
+
+		for _, key := range clause.values {
+			if j.issue(key) == nil {
+				warnings = append(warnings, fmt.Sprintf("An issue with key '%s' does not exist for field 'key'.", key))
+			}
+		}
+	}
+
+	return warnings
+}
+
+// matches reports whether the issue matches the clause
+func (c jqlClause) matches(j *Jira, issue *Issue) bool {
+	var actual []string
+
+	switch c.field {
+	case "project":
+		actual = []string{issueProject(issue.Key)}
+
+		if project := j.project(issueProject(issue.Key)); project != nil {
+			actual = append(actual, project.Id, project.Name)
+		}
+	case "key":
+		actual = append([]string{issue.Key, issue.Id}, issue.MovedFrom...)
+	case "fixVersion":
+		actual = j.versionNamesAndIds(issueProject(issue.Key), issue.FixVersions)
+	case "affectedVersion":
+		actual = j.versionNamesAndIds(issueProject(issue.Key), issue.AffectsVersions)
+	case "issuetype":
+		actual = []string{issue.Type}
+	case "status":
+		actual = []string{issue.Status}
+	}
+
+	switch c.operator {
+	case "is":
+		return len(actual) == 0
+	case "is not":
+		return len(actual) != 0
+	case "=", "in":
+		return intersects(actual, c.values)
+	default:
+		return !intersects(actual, c.values)
+	}
+}
+
+// versionNamesAndIds returns the names and ids of the versions with the provided names
+func (j *Jira) versionNamesAndIds(project string, names []string) []string {
+	var result []string
+
+	for _, name := range names {
+		result = append(result, name)
+
+		if version := j.versionByName(project, name); version != nil {
+			result = append(result, version.Id)
+		}
+	}
+
+	return result
+}
+
+// intersects reports whether a and b have a value in common, ignoring case like JQL does
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if strings.EqualFold(x, y) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// jqlParser parses a list of tokens
+type jqlParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *jqlParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *jqlParser) peek() string {
+	if p.done() {
+		return ""
+	}
+
+	return p.tokens[p.pos]
+}
+
+func (p *jqlParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *jqlParser) last() string {
+	if p.pos > len(p.tokens) {
+		return "end of query"
+	}
+
+	return p.tokens[p.pos-1]
+}
+
+// clause parses a single condition
+func (p *jqlParser) clause() (jqlClause, error) {
+	name := p.next()
+	field, ok := jqlFields[strings.ToLower(name)]
+
+	if !ok {
+		return jqlClause{}, fmt.Errorf("field '%s' is not supported", name)
+	}
+
+	clause := jqlClause{field: field, operator: strings.ToLower(p.next())}
+
+	if clause.operator == "not" || clause.operator == "is" {
+		if strings.EqualFold(p.peek(), "not") || strings.EqualFold(p.peek(), "in") {
+			clause.operator += " " + strings.ToLower(p.next())
+		}
+	}
+
+	switch clause.operator {
+	case "=", "!=":
+		value := p.next()
+
+		if p.pos > len(p.tokens) || isJQLSyntax(value) {
+			return jqlClause{}, fmt.Errorf("expected a value after '%s %s'", name, clause.operator)
+		}
+
+		clause.values = []string{value}
+	case "in", "not in":
+		values, err := p.list()
+
+		if err != nil {
+			return jqlClause{}, err
+		}
+
+		clause.values = values
+	case "is", "is not":
+		if value := p.next(); !strings.EqualFold(value, "empty") && !strings.EqualFold(value, "null") {
+			return jqlClause{}, fmt.Errorf("expected EMPTY after '%s %s'", name, clause.operator)
+		}
+	default:
+		return jqlClause{}, fmt.Errorf("operator '%s' is not supported", p.last())
+	}
+
+	return clause, nil
+}
+
+// list parses a parenthesized, comma separated list of values
+func (p *jqlParser) list() ([]string, error) {
+	if p.next() != "(" {
+		return nil, fmt.Errorf("expected '(' but got '%s'", p.last())
+	}
+
+	var values []string
+
+	for {
+		value := p.next()
+
+		if p.pos > len(p.tokens) || isJQLSyntax(value) {
+			return nil, fmt.Errorf("expected a value but got '%s'", p.last())
+		}
+
+		values = append(values, value)
+
+		switch p.next() {
+		case ",":
+			continue
+		case ")":
+			return values, nil
+		default:
+			return nil, fmt.Errorf("expected ',' or ')' but got '%s'", p.last())
+		}
+	}
+}
+
+// isJQLSyntax reports whether the token is a reserved character instead of a value
+func isJQLSyntax(token string) bool {
+	return token == "(" || token == ")" || token == ","
+}
+
+// tokenizeJQL splits the query into words, quoted strings, operators and the reserved characters
+func tokenizeJQL(jql string) ([]string, error) {
+	var tokens []string
+	runes := []rune(jql)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == ',' || r == '=':
+			tokens = append(tokens, string(r))
+			i++
+		case r == '!' && i+1 < len(runes) && runes[i+1] == '=':
+			tokens = append(tokens, "!=")
+			i += 2
+		case r == '"' || r == '\'':
+			var value strings.Builder
+			i++
+
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+
+				value.WriteRune(runes[i])
+			}
+
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string %s%s", string(r), value.String())
+			}
+
+			tokens = append(tokens, value.String())
+			i++
+		default:
+			start := i
+
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(`()=,!"'`, runes[i]) {
+				i++
+			}
+
+			if start == i {
+				return nil, fmt.Errorf("unexpected character '%s'", string(r))
+			}
+
+			tokens = append(tokens, string(runes[start:i]))
+		}
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,181 @@
+// Package testserver runs the fake Jira of package jiratest on a local http server for the duration of a test, and
+// provides assertions on its state.
+package testserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/marcelblijleven/jira-helper/pkg/jiratest"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Server runs the fake on a local http server for the duration of a test
+type Server struct {
+	*jiratest.Jira
+	// URL is the base url of the server, which can be used as host of the Jira client
+	URL string
+	t   testing.TB
+}
+
+// New starts a server with an empty fake, which is closed when the test finishes
+func New(t testing.TB) *Server {
+	t.Helper()
+	jira := jiratest.New()
+	server := httptest.NewServer(jira)
+	t.Cleanup(server.Close)
+	return &Server{Jira: jira, URL: server.URL, t: t}
+}
+
+// AssertVersionExists fails the test when the version does not exist in the project
+func (s *Server) AssertVersionExists(project, name string) {
+	s.t.Helper()
+
+	if _, ok := s.Version(project, name); !ok {
+		s.t.Errorf("expected version %q to exist in project %s, found %s", name, project, s.versionNames(project))
+	}
+}
+
+// AssertNoVersion fails the test when the version exists in the project
+func (s *Server) AssertNoVersion(project, name string) {
+	s.t.Helper()
+
+	if _, ok := s.Version(project, name); ok {
+		s.t.Errorf("expected version %q not to exist in project %s", name, project)
+	}
+}
+
+// AssertVersionReleased fails the test when the version does not exist or has another release state
+func (s *Server) AssertVersionReleased(project, name string, released bool) {
+	s.t.Helper()
+	version, ok := s.Version(project, name)
+
+	switch {
+	case !ok:
+		s.t.Errorf("expected version %q to exist in project %s, found %s", name, project, s.versionNames(project))
+	case version.Released != released:
+		s.t.Errorf("expected released of version %q in project %s to be %t", name, project, released)
+	}
+}
+
+// AssertFixVersions fails the test when the issue does not have exactly the provided fix versions, in any order
+func (s *Server) AssertFixVersions(key string, versions ...string) {
+	s.t.Helper()
+	issue, ok := s.Issue(key)
+
+	if !ok {
+		s.t.Errorf("expected issue %s to exist", key)
+		return
+	}
+
+	if !sameNames(issue.FixVersions, versions) {
+		s.t.Errorf("expected fix versions of %s to be %q, got %q", key, versions, issue.FixVersions)
+	}
+}
+
+// AssertAffectsVersions fails the test when the issue does not have exactly the provided affects versions, in any
+// order
+func (s *Server) AssertAffectsVersions(key string, versions ...string) {
+	s.t.Helper()
+	issue, ok := s.Issue(key)
+
+	if !ok {
+		s.t.Errorf("expected issue %s to exist", key)
+		return
+	}
+
+	if !sameNames(issue.AffectsVersions, versions) {
+		s.t.Errorf("expected affects versions of %s to be %q, got %q", key, versions, issue.AffectsVersions)
+	}
+}
+
+// AssertStatus fails the test when the issue does not have the provided status
+func (s *Server) AssertStatus(key, status string) {
+	s.t.Helper()
+	issue, ok := s.Issue(key)
+
+	switch {
+	case !ok:
+		s.t.Errorf("expected issue %s to exist", key)
+	case issue.Status != status:
+		s.t.Errorf("expected status of %s to be %q, got %q", key, status, issue.Status)
+	}
+}
+
+// AssertField fails the test when the field of the issue does not have the provided value, compared in its JSON
+// representation
+func (s *Server) AssertField(key, field string, value interface{}) {
+	s.t.Helper()
+	issue, ok := s.Issue(key)
+
+	if !ok {
+		s.t.Errorf("expected issue %s to exist", key)
+		return
+	}
+
+	if actual, expected := mustMarshal(issue.Fields[field]), mustMarshal(value); actual != expected {
+		s.t.Errorf("expected field %s of %s to be %s, got %s", field, key, expected, actual)
+	}
+}
+
+// AssertComment fails the test when the issue has no comment containing the text
+func (s *Server) AssertComment(key, text string) {
+	s.t.Helper()
+	issue, ok := s.Issue(key)
+
+	if !ok {
+		s.t.Errorf("expected issue %s to exist", key)
+		return
+	}
+
+	for _, comment := range issue.Comments {
+		if strings.Contains(comment, text) {
+			return
+		}
+	}
+
+	s.t.Errorf("expected a comment on %s containing %q, got %q", key, text, issue.Comments)
+}
+
+// versionNames returns the names of the versions of the project, for use in failure messages
+func (s *Server) versionNames(project string) string {
+	var names []string
+
+	for _, version := range s.Versions(project) {
+		names = append(names, version.Name)
+	}
+
+	return fmt.Sprintf("%q", names)
+}
+
+// sameNames reports whether both slices contain the same names, in any order
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	a, b = append([]string(nil), a...), append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// mustMarshal returns the JSON representation of the value, for comparisons
+func mustMarshal(value interface{}) string {
+	data, err := json.Marshal(value)
+
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(data)
+}
@@ -0,0 +1,218 @@
+package pkg
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	journalStatusCompleted = "completed"
+	journalStatusUndone    = "undone"
+)
+
+// JournalEntry represents a single line in a journal file
+type JournalEntry struct {
+	RunId       string          `json:"runId"`
+	Step        string          `json:"step"`
+	Description string          `json:"description"`
+	Status      string          `json:"status"`
+	Time        time.Time       `json:"time"`
+	State       json.RawMessage `json:"state,omitempty"`
+}
+
+// StatefulStep is implemented by steps which need to persist state in the journal, so they can be undone after
+// resuming, e.g. the id of a created version
+type StatefulStep interface {
+	Step
+	MarshalState() ([]byte, error)
+	UnmarshalState(data []byte) error
+}
+
+// Journal records the completed steps of a run in a JSON Lines file, so the run can be resumed after it was
+// interrupted
+type Journal struct {
+	runId     string
+	file      *os.File
+	completed map[string]JournalEntry
+}
+
+// NewRunId generates a new unique run id
+func NewRunId() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102T150405Z"), hex.EncodeToString(b))
+}
+
+// OpenJournal opens the journal file at the provided path for a new run. Entries are appended when the file already
+// exists. When runId is empty, a new run id is generated.
+func OpenJournal(path, runId string) (*Journal, error) {
+	if runId == "" {
+		runId = NewRunId()
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not open journal: %w", err)
+	}
+
+	return &Journal{runId: runId, file: file, completed: map[string]JournalEntry{}}, nil
+}
+
+// ResumeJournal reads the journal file at the provided path and opens it to continue the run with the provided id.
+// When runId is empty, the run of the last entry in the journal is resumed.
+func ResumeJournal(path, runId string) (*Journal, error) {
+	entries, err := ReadJournal(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if runId == "" {
+		if len(entries) == 0 {
+			return nil, fmt.Errorf("could not resume journal: %s does not contain any entries", path)
+		}
+
+		runId = entries[len(entries)-1].RunId
+	}
+
+	journal, err := OpenJournal(path, runId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.RunId != runId {
+			continue
+		}
+
+		switch entry.Status {
+		case journalStatusCompleted:
+			journal.completed[entry.Step] = entry
+		case journalStatusUndone:
+			delete(journal.completed, entry.Step)
+		}
+	}
+
+	return journal, nil
+}
+
+// ReadJournal reads all entries from the journal file at the provided path
+func ReadJournal(path string) ([]JournalEntry, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read journal: %w", err)
+	}
+
+	defer file.Close()
+
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(file)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry JournalEntry
+
+		if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("could not read journal: invalid entry on line %d: %w", line, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read journal: %w", err)
+	}
+
+	return entries, nil
+}
+
+// RunId returns the id of the run the journal records
+func (j *Journal) RunId() string {
+	return j.runId
+}
+
+// IsCompleted reports whether the step has been completed in the run. When it has, the state recorded for the step
+// is restored.
+func (j *Journal) IsCompleted(step Step) (bool, error) {
+	entry, ok := j.completed[step.Id()]
+
+	if !ok {
+		return false, nil
+	}
+
+	if stateful, ok := step.(StatefulStep); ok && len(entry.State) != 0 {
+		if err := stateful.UnmarshalState(entry.State); err != nil {
+			return true, fmt.Errorf("could not restore state of step %s: %w", step.Id(), err)
+		}
+	}
+
+	return true, nil
+}
+
+// RecordCompleted records that the step has been completed
+func (j *Journal) RecordCompleted(step Step) error {
+	return j.record(step, journalStatusCompleted)
+}
+
+// RecordUndone records that the step has been undone
+func (j *Journal) RecordUndone(step Step) error {
+	return j.record(step, journalStatusUndone)
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	return j.file.Close()
+}
+
+// record appends an entry with the provided status for the step to the journal file
+func (j *Journal) record(step Step, status string) error {
+	entry := JournalEntry{
+		RunId:       j.runId,
+		Step:        step.Id(),
+		Description: step.Description(),
+		Status:      status,
+		Time:        time.Now().UTC(),
+	}
+
+	if stateful, ok := step.(StatefulStep); ok && status == journalStatusCompleted {
+		state, err := stateful.MarshalState()
+
+		if err != nil {
+			return fmt.Errorf("could not record state of step %s: %w", step.Id(), err)
+		}
+
+		entry.State = state
+	}
+
+	data, err := json.Marshal(entry)
+
+	if err != nil {
+		return fmt.Errorf("could not record step %s: %w", step.Id(), err)
+	}
+
+	if _, err = j.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("could not write to journal: %w", err)
+	}
+
+	if status == journalStatusCompleted {
+		j.completed[entry.Step] = entry
+	} else {
+		delete(j.completed, entry.Step)
+	}
+
+	return nil
+}
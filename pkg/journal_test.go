@@ -0,0 +1,117 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournal_resume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	var calledWith []string
+	failing := true
+	client, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", newStepsMockClient(&calledWith, func(req *http.Request) bool {
+		return failing && req.URL.Path == "/rest/api/latest/issue/MB-2"
+	}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	journal, err := OpenJournal(path, "run-1")
+	assert.NoError(t, err)
+
+	operation := NewOperation(client, false)
+	operation.SetJournal(journal)
+	err = operation.Run(CreateAndAssignSteps("1.0.0", "MB", "MB-1 and MB-2", nil, nil, nil))
+	assert.Error(t, err)
+	assert.NoError(t, journal.Close())
+
+	entries, err := ReadJournal(path)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "create-version:MB:1.0.0", entries[0].Step)
+	assert.Equal(t, "assign-version:MB-1:1.0.0", entries[1].Step)
+
+	failing = false
+	calledWith = nil
+	journal, err = ResumeJournal(path, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "run-1", journal.RunId())
+
+	operation = NewOperation(client, true)
+	operation.SetJournal(journal)
+	steps := CreateAndAssignSteps("1.0.0", "MB", "MB-1 and MB-2", nil, nil, nil)
+	err = operation.Run(steps)
+	assert.NoError(t, err)
+	assert.NoError(t, journal.Close())
+	assert.Equal(t, []string{
+		"PUT /rest/api/latest/issue/MB-2 {\"update\":{\"fixVersions\":[{\"add\":{\"name\":\"1.0.0\"}}]}}",
+	}, calledWith)
+	assert.Equal(t, "10000", steps[0].(*CreateVersionStep).Version.Id, "state of the skipped step should be restored")
+	assert.Len(t, operation.Completed(), 3)
+}
+
+func TestJournal_resumeAfterRollback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	var calledWith []string
+	client, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", newStepsMockClient(&calledWith, func(req *http.Request) bool {
+		return req.URL.Path == "/rest/api/latest/issue/MB-2"
+	}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	journal, err := OpenJournal(path, "run-1")
+	assert.NoError(t, err)
+
+	operation := NewOperation(client, true)
+	operation.SetJournal(journal)
+	assert.Error(t, operation.Run(CreateAndAssignSteps("1.0.0", "MB", "MB-1 and MB-2", nil, nil, nil)))
+	assert.NoError(t, journal.Close())
+
+	journal, err = ResumeJournal(path, "run-1")
+	assert.NoError(t, err)
+	defer journal.Close()
+
+	completed, err := journal.IsCompleted(&CreateVersionStep{Name: "1.0.0", Project: "MB"})
+	assert.NoError(t, err)
+	assert.False(t, completed, "undone steps should not be skipped when resuming")
+}
+
+func TestResumeJournal_updateIssue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	journal, err := OpenJournal(path, "run-1")
+	assert.NoError(t, err)
+
+	fields := map[string]interface{}{"customfield_10001": "Platform", "customfield_10002": 3}
+	assert.NoError(t, journal.RecordCompleted(&UpdateIssueStep{Issue: "MB-1", FixVersions: VersionChange{Add: []string{"1.0.0"}}, Fields: fields}))
+	assert.NoError(t, journal.Close())
+
+	journal, err = ResumeJournal(path, "run-1")
+	assert.NoError(t, err)
+	defer journal.Close()
+
+	// The same changes are skipped, also when the fields are built in another order
+	sameFields := map[string]interface{}{"customfield_10002": 3, "customfield_10001": "Platform"}
+	completed, err := journal.IsCompleted(&UpdateIssueStep{Issue: "MB-1", FixVersions: VersionChange{Add: []string{"1.0.0"}}, Fields: sameFields})
+	assert.NoError(t, err)
+	assert.True(t, completed)
+
+	completed, err = journal.IsCompleted(&UpdateIssueStep{Issue: "MB-1", FixVersions: VersionChange{Remove: []string{"1.0.0"}}, Fields: fields})
+	assert.NoError(t, err)
+	assert.False(t, completed, "an update with other changes should not be skipped when resuming")
+}
+
+func TestResumeJournal_empty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	journal, err := OpenJournal(path, "")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, journal.RunId())
+	assert.NoError(t, journal.Close())
+
+	_, err = ResumeJournal(path, "")
+	assert.EqualError(t, err, "could not resume journal: "+path+" does not contain any entries")
+}
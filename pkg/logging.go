@@ -0,0 +1,191 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logger is a structured logger. Its methods match those of log/slog.Logger, so a *slog.Logger can be used as well.
+// The args are alternating keys and values.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// LogLevel is the minimum level of the messages written by a TextLogger. The values match those of log/slog.
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = -4
+	LevelInfo  LogLevel = 0
+	LevelWarn  LogLevel = 4
+	LevelError LogLevel = 8
+)
+
+func (l LogLevel) String() string {
+	switch {
+	case l < LevelInfo:
+		return "DEBUG"
+	case l < LevelWarn:
+		return "INFO"
+	case l < LevelError:
+		return "WARN"
+	default:
+		return "ERROR"
+	}
+}
+
+// TextLogger writes messages of the minimum level and above as key=value lines, in the format of slog.TextHandler
+type TextLogger struct {
+	w     io.Writer
+	level LogLevel
+	now   func() time.Time
+	mu    sync.Mutex
+}
+
+// NewTextLogger creates a logger which writes the messages of the provided level and above to w
+func NewTextLogger(w io.Writer, level LogLevel) *TextLogger {
+	return &TextLogger{w: w, level: level, now: time.Now}
+}
+
+func (l *TextLogger) Debug(msg string, args ...interface{}) {
+	l.log(LevelDebug, msg, args)
+}
+
+func (l *TextLogger) Info(msg string, args ...interface{}) {
+	l.log(LevelInfo, msg, args)
+}
+
+func (l *TextLogger) Warn(msg string, args ...interface{}) {
+	l.log(LevelWarn, msg, args)
+}
+
+func (l *TextLogger) Error(msg string, args ...interface{}) {
+	l.log(LevelError, msg, args)
+}
+
+// log writes the message when its level is enabled
+func (l *TextLogger) log(level LogLevel, msg string, args []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "time=%s level=%s msg=%s", l.now().Format(time.RFC3339), level, quoteLogValue(msg))
+
+	for i := 0; i < len(args); i += 2 {
+		key := fmt.Sprint(args[i])
+		var value interface{} = "!MISSING"
+
+		if i+1 < len(args) {
+			value = args[i+1]
+		}
+
+		_, _ = fmt.Fprintf(&b, " %s=%s", key, quoteLogValue(fmt.Sprint(value)))
+	}
+
+	b.WriteString("\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = io.WriteString(l.w, b.String())
+}
+
+// quoteLogValue quotes the value when it is empty or contains spaces, quotes or equal signs
+func quoteLogValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		return strconv.Quote(value)
+	}
+
+	return value
+}
+
+// nopLogger discards all messages, it is used when a JiraClient has no logger
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// EventType identifies the kind of event
+type EventType string
+
+const (
+	EventRequestStart   EventType = "request.start"
+	EventRequestEnd     EventType = "request.end"
+	EventVersionCreated EventType = "version.created"
+	EventIssueAssigned  EventType = "issue.assigned"
+	EventIssueUpdated   EventType = "issue.updated"
+	EventIssueSkipped   EventType = "issue.skipped"
+)
+
+// Event describes something the client did. Only the fields which apply to the type of event are set.
+type Event struct {
+	Type EventType
+	Time time.Time
+
+	// Method, URL, Status and Duration are set for request events. Status and Duration are only set when the
+	// request has ended.
+	Method   string
+	URL      string
+	Status   int
+	Duration time.Duration
+
+	Project string
+	Version string
+	Issue   string
+	// Reason explains why an issue was skipped
+	Reason string
+	Err    error
+}
+
+// Observer receives the events of a JiraClient
+type Observer interface {
+	OnEvent(event Event)
+}
+
+// ObserverFunc allows a function to be used as Observer
+type ObserverFunc func(event Event)
+
+func (f ObserverFunc) OnEvent(event Event) {
+	f(event)
+}
+
+// SetLogger sets the logger of the client. Without a logger, or with a nil logger, all messages are discarded.
+func (c *JiraClient) SetLogger(logger Logger) {
+	c.logger = logger
+}
+
+// SetObserver sets the observer which receives the events of the client. A nil observer removes the observer.
+func (c *JiraClient) SetObserver(observer Observer) {
+	c.observer = observer
+}
+
+// log returns the logger of the client
+func (c *JiraClient) log() Logger {
+	if c.logger == nil {
+		return nopLogger{}
+	}
+
+	return c.logger
+}
+
+// emit sends the event to the observer of the client
+func (c *JiraClient) emit(event Event) {
+	if c.observer == nil {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	c.observer.OnEvent(event)
+}
@@ -0,0 +1,78 @@
+package pkg
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTextLogger(t *testing.T) {
+	var b bytes.Buffer
+	logger := NewTextLogger(&b, LevelInfo)
+	logger.now = func() time.Time {
+		return time.Date(2022, 1, 31, 12, 0, 0, 0, time.UTC)
+	}
+
+	logger.Debug("hidden")
+	logger.Info("created version", "version", "1.0.0", "id", 10000)
+	logger.Warn("quoted", "reason", "has spaces", "empty", "", "odd")
+	logger.Error("failed", "error", "key=value")
+
+	assert.Equal(t, `time=2022-01-31T12:00:00Z level=INFO msg="created version" version=1.0.0 id=10000
+time=2022-01-31T12:00:00Z level=WARN msg=quoted reason="has spaces" empty="" odd=!MISSING
+time=2022-01-31T12:00:00Z level=ERROR msg=failed error="key=value"
+`, b.String())
+}
+
+func TestLogLevel_String(t *testing.T) {
+	assert.Equal(t, "DEBUG", LevelDebug.String())
+	assert.Equal(t, "INFO", LevelInfo.String())
+	assert.Equal(t, "WARN", LevelWarn.String())
+	assert.Equal(t, "ERROR", LevelError.String())
+}
+
+func TestJiraClient_SetObserver(t *testing.T) {
+	var calledWith []string
+	client, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", newStepsMockClient(&calledWith, func(req *http.Request) bool {
+		return req.URL.Path == "/rest/api/latest/issue/MB-2"
+	}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []Event
+	client.SetObserver(ObserverFunc(func(event Event) {
+		assert.False(t, event.Time.IsZero())
+		event.Time, event.Duration = time.Time{}, 0
+		events = append(events, event)
+	}))
+
+	var logs bytes.Buffer
+	client.SetLogger(NewTextLogger(&logs, LevelDebug))
+
+	operation := NewOperation(client, false)
+	operation.SkipIssues("no mapping", "OPS-1")
+	err = operation.Run(CreateAndAssignSteps("1.0.0", "MB", "MB-1 and MB-2", nil, nil, nil))
+	assert.Error(t, err)
+
+	assert.Equal(t, []Event{
+		{Type: EventIssueSkipped, Issue: "OPS-1", Reason: "no mapping"},
+		{Type: EventRequestStart, Method: http.MethodPost, URL: "https://test.nu/rest/api/latest/version"},
+		{Type: EventRequestEnd, Method: http.MethodPost, URL: "https://test.nu/rest/api/latest/version", Status: http.StatusCreated},
+		{Type: EventVersionCreated, Project: "MB", Version: "1.0.0"},
+		{Type: EventRequestStart, Method: http.MethodPut, URL: "https://test.nu/rest/api/latest/issue/MB-1"},
+		{Type: EventRequestEnd, Method: http.MethodPut, URL: "https://test.nu/rest/api/latest/issue/MB-1", Status: http.StatusNoContent},
+		{Type: EventIssueAssigned, Issue: "MB-1", Version: "1.0.0"},
+		{Type: EventRequestStart, Method: http.MethodPut, URL: "https://test.nu/rest/api/latest/issue/MB-2"},
+		{Type: EventRequestEnd, Method: http.MethodPut, URL: "https://test.nu/rest/api/latest/issue/MB-2", Status: http.StatusBadRequest},
+	}, events)
+	assert.Contains(t, logs.String(), `level=INFO msg="assigned version" issue=MB-1 version=1.0.0`)
+	assert.Contains(t, logs.String(), `level=DEBUG msg="received response" method=PUT url=https://test.nu/rest/api/latest/issue/MB-2 status=400`)
+
+	client.SetObserver(nil)
+	client.SetLogger(nil)
+	assert.NoError(t, client.AssignVersion("MB-1", "1.0.0"))
+}
@@ -0,0 +1,206 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Mapping maps a part of a repository, selected by path globs or a tag prefix, to a Jira project and the name of its
+// versions. This allows a monorepo with several services to release each service in its own project.
+type Mapping struct {
+	Name            string   `yaml:"name"`
+	Project         string   `yaml:"project"`
+	Paths           []string `yaml:"paths,omitempty"`
+	TagPrefix       string   `yaml:"tagPrefix,omitempty"`
+	VersionTemplate string   `yaml:"versionTemplate,omitempty"`
+}
+
+// MatchesPath reports whether the path matches one of the path globs of the mapping. A "**" element matches any
+// number of directories.
+func (m Mapping) MatchesPath(p string) bool {
+	for _, pattern := range m.Paths {
+		if matchGlob(strings.Split(pattern, "/"), strings.Split(strings.TrimPrefix(p, "./"), "/")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchesTag reports whether the tag starts with the tag prefix of the mapping and returns the tag without the prefix
+func (m Mapping) MatchesTag(tag string) (string, bool) {
+	if m.TagPrefix == "" || !strings.HasPrefix(tag, m.TagPrefix) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(tag, m.TagPrefix), true
+}
+
+// VersionName renders the version template of the mapping for the provided version, which must be a semantic
+// version. The version is used as is when the mapping has no template.
+func (m Mapping) VersionName(version string) (string, error) {
+	return m.versionName(version, "")
+}
+
+// versionName renders the version template of the mapping for the tag without the prefix, so .Tag is the tag as
+// provided. The tag without the prefix is used as is when the mapping has no template.
+func (m Mapping) versionName(tag, prefix string) (string, error) {
+	if m.VersionTemplate == "" {
+		return strings.TrimPrefix(tag, prefix), nil
+	}
+
+	data, err := NewVersionTemplateData(tag, prefix, m.Project)
+
+	if err != nil {
+		return "", fmt.Errorf("invalid version for mapping %q: %w", m.Name, err)
+	}
+
+	data.Name = m.Name
+	name, err := data.Render(m.VersionTemplate)
+
+	if err != nil {
+		return "", fmt.Errorf("mapping %q: %w", m.Name, err)
+	}
+
+	return name, nil
+}
+
+// matchGlob matches the path elements against the pattern elements
+func matchGlob(pattern, elements []string) bool {
+	if len(pattern) == 0 {
+		return len(elements) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(elements); i++ {
+			if matchGlob(pattern[1:], elements[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(elements) == 0 {
+		return false
+	}
+
+	if ok, err := path.Match(pattern[0], elements[0]); err != nil || !ok {
+		return false
+	}
+
+	return matchGlob(pattern[1:], elements[1:])
+}
+
+// PlannedRelease is the version to create in the project of a mapping and the issues to assign it to
+type PlannedRelease struct {
+	Mapping Mapping
+	Version string
+	Issues  []string
+}
+
+// ReleasePlan holds the releases of a monorepo and the issues which do not belong to the project of any release
+type ReleasePlan struct {
+	Releases []PlannedRelease
+	Unmapped []string
+}
+
+// PlanReleases selects the mappings for the tag and the changed paths and groups the issues by the project of the
+// selected mappings. When a tag is provided, the prefix is stripped from it, only the mappings with a matching tag
+// prefix are selected and the version defaults to the tag without both prefixes. When paths are provided, only the
+// mappings with a matching path are selected. The issues are extracted from the release body with the default pattern,
+// see JiraClient.ExtractIssues.
+func PlanReleases(mappings []Mapping, version, tag, prefix string, paths []string, releaseBody string, issues []string, filter []string) (*ReleasePlan, error) {
+	if len(mappings) == 0 {
+		return nil, errors.New("no mappings configured")
+	}
+
+	plan := &ReleasePlan{}
+	projects := map[string]int{}
+
+	for _, mapping := range mappings {
+		if mapping.Project == "" {
+			return nil, fmt.Errorf("mapping %q has no project", mapping.Name)
+		}
+
+		// The version is rendered from the tag as provided, with both prefixes stripped, unless a version is provided
+		mappingVersion, mappingPrefix := version, ""
+
+		if tag != "" {
+			if _, ok := mapping.MatchesTag(strings.TrimPrefix(tag, prefix)); !ok {
+				continue
+			}
+
+			if mappingVersion == "" {
+				mappingVersion, mappingPrefix = tag, prefix+mapping.TagPrefix
+			}
+		}
+
+		if len(paths) != 0 && !mapping.matchesAnyPath(paths) {
+			continue
+		}
+
+		if mappingVersion == "" {
+			return nil, fmt.Errorf("no version provided for mapping %q", mapping.Name)
+		}
+
+		if _, ok := projects[mapping.Project]; ok {
+			return nil, fmt.Errorf("mapping %q selects project %s, which is already selected by another mapping", mapping.Name, mapping.Project)
+		}
+
+		name, err := mapping.versionName(mappingVersion, mappingPrefix)
+
+		if err != nil {
+			return nil, err
+		}
+
+		projects[mapping.Project] = len(plan.Releases)
+		plan.Releases = append(plan.Releases, PlannedRelease{Mapping: mapping, Version: name})
+	}
+
+	if len(plan.Releases) == 0 {
+		return nil, errors.New("no mapping matches the provided tag and paths")
+	}
+
+	for _, issue := range collectIssues(nil, releaseBody, issues, filter) {
+		i, ok := projects[IssueProject(issue)]
+
+		if !ok {
+			plan.Unmapped = append(plan.Unmapped, issue)
+			continue
+		}
+
+		plan.Releases[i].Issues = append(plan.Releases[i].Issues, issue)
+	}
+
+	return plan, nil
+}
+
+// matchesAnyPath reports whether one of the paths matches the mapping
+func (m Mapping) matchesAnyPath(paths []string) bool {
+	for _, p := range paths {
+		if m.MatchesPath(p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IssueProject returns the project key of the issue key, e.g. MB for MB-123
+func IssueProject(issue string) string {
+	return strings.SplitN(issue, "-", 2)[0]
+}
+
+// Steps returns the steps to create the version of every release and to assign it to the issues of the release
+func (p *ReleasePlan) Steps(fields map[string]interface{}) []Step {
+	var steps []Step
+
+	for _, release := range p.Releases {
+		steps = append(steps, CreateAndAssignSteps(release.Version, release.Mapping.Project, "", release.Issues, nil, fields)...)
+	}
+
+	return steps
+}
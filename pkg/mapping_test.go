@@ -0,0 +1,114 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMapping_MatchesPath(t *testing.T) {
+	mapping := Mapping{Name: "payments", Paths: []string{"services/payments/**", "libs/*.go"}}
+
+	assert.True(t, mapping.MatchesPath("services/payments/main.go"))
+	assert.True(t, mapping.MatchesPath("./services/payments/internal/api/handler.go"))
+	assert.True(t, mapping.MatchesPath("libs/money.go"))
+	assert.False(t, mapping.MatchesPath("libs/nested/money.go"))
+	assert.False(t, mapping.MatchesPath("services/orders/main.go"))
+}
+
+func TestMapping_MatchesTag(t *testing.T) {
+	mapping := Mapping{Name: "payments", TagPrefix: "payments-"}
+
+	version, ok := mapping.MatchesTag("payments-1.4.0")
+	assert.True(t, ok)
+	assert.Equal(t, "1.4.0", version)
+
+	_, ok = mapping.MatchesTag("orders-1.4.0")
+	assert.False(t, ok)
+
+	_, ok = Mapping{Name: "all"}.MatchesTag("payments-1.4.0")
+	assert.False(t, ok)
+}
+
+func TestMapping_VersionName(t *testing.T) {
+	name, err := Mapping{Name: "payments", Project: "PAY", VersionTemplate: "{{.Name}}-{{.Version}}"}.VersionName("1.4.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "payments-1.4.0", name)
+
+	name, err = Mapping{Name: "payments"}.VersionName("1.4.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.4.0", name)
+
+	_, err = Mapping{Name: "payments", VersionTemplate: "{{.Name"}.VersionName("1.4.0")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `mapping "payments": invalid version template`)
+
+	_, err = Mapping{Name: "payments", VersionTemplate: "{{.Missing}}"}.VersionName("1.4.0")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `mapping "payments": could not render version template`)
+
+	_, err = Mapping{Name: "payments", VersionTemplate: "{{.Version}}"}.VersionName("sprint-5")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid version for mapping "payments": invalid semantic version "sprint-5"`)
+}
+
+func TestPlanReleases(t *testing.T) {
+	mappings := []Mapping{
+		{Name: "payments", Project: "PAY", Paths: []string{"services/payments/**"}, TagPrefix: "payments-", VersionTemplate: "{{.Name}}-{{.Version}}"},
+		{Name: "orders", Project: "ORD", Paths: []string{"services/orders/**"}, TagPrefix: "orders-", VersionTemplate: "Orders {{.Version}}"},
+	}
+	body := "PAY-1 fix rounding, ORD-2 add endpoint, OPS-3 bump ci"
+
+	plan, err := PlanReleases(mappings, "1.4.0", "", "", nil, body, []string{"PAY-4"}, []string{"PAY-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []PlannedRelease{
+		{Mapping: mappings[0], Version: "payments-1.4.0", Issues: []string{"PAY-4"}},
+		{Mapping: mappings[1], Version: "Orders 1.4.0", Issues: []string{"ORD-2"}},
+	}, plan.Releases)
+	assert.Equal(t, []string{"OPS-3"}, plan.Unmapped)
+
+	plan, err = PlanReleases(mappings, "", "orders-2.0.0", "", nil, body, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []PlannedRelease{{Mapping: mappings[1], Version: "Orders 2.0.0", Issues: []string{"ORD-2"}}}, plan.Releases)
+	assert.Equal(t, []string{"PAY-1", "OPS-3"}, plan.Unmapped)
+
+	// .Tag is the tag as provided, with the stripped prefix and the tag prefix of the mapping
+	tagged := []Mapping{{Name: "payments", Project: "PAY", TagPrefix: "payments-", VersionTemplate: "{{.Version}} ({{.Tag}})"}}
+	plan, err = PlanReleases(tagged, "", "release/payments-v1.4.0", "release/", nil, body, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.4.0 (release/payments-v1.4.0)", plan.Releases[0].Version)
+
+	plan, err = PlanReleases(mappings, "1.5.0", "", "", []string{"services/payments/main.go", "README.md"}, body, nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, plan.Releases, 1)
+	assert.Equal(t, "payments-1.5.0", plan.Releases[0].Version)
+
+	steps := plan.Steps(nil)
+	assert.Equal(t, []Step{
+		&CreateVersionStep{Name: "payments-1.5.0", Project: "PAY"},
+		&AssignVersionStep{Issue: "PAY-1", Version: "payments-1.5.0"},
+	}, steps)
+}
+
+func TestPlanReleases_errors(t *testing.T) {
+	mappings := []Mapping{{Name: "payments", Project: "PAY", TagPrefix: "payments-"}}
+
+	_, err := PlanReleases(nil, "1.0.0", "", "", nil, "", nil, nil)
+	assert.EqualError(t, err, "no mappings configured")
+
+	_, err = PlanReleases(mappings, "", "orders-1.0.0", "", nil, "", nil, nil)
+	assert.EqualError(t, err, "no mapping matches the provided tag and paths")
+
+	_, err = PlanReleases(mappings, "", "", "", nil, "", nil, nil)
+	assert.EqualError(t, err, `no version provided for mapping "payments"`)
+
+	_, err = PlanReleases([]Mapping{{Name: "payments"}}, "1.0.0", "", "", nil, "", nil, nil)
+	assert.EqualError(t, err, `mapping "payments" has no project`)
+
+	_, err = PlanReleases(append(mappings, Mapping{Name: "other", Project: "PAY"}), "1.0.0", "", "", nil, "", nil, nil)
+	assert.EqualError(t, err, `mapping "other" selects project PAY, which is already selected by another mapping`)
+}
+
+func TestIssueProject(t *testing.T) {
+	assert.Equal(t, "MB", IssueProject("MB-123"))
+	assert.Equal(t, "", IssueProject(""))
+}
@@ -0,0 +1,350 @@
+package pkg
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AuthTypeOAuth1 = "oauth1"
+
+	oauth1RequestTokenEndpoint = "/plugins/servlet/oauth/request-token"
+	oauth1AuthorizeEndpoint    = "/plugins/servlet/oauth/authorize"
+	oauth1AccessTokenEndpoint  = "/plugins/servlet/oauth/access-token"
+)
+
+// OAuth1Authenticator signs requests with OAuth 1.0a RSA-SHA1 signatures, as used by application links of Jira Server
+type OAuth1Authenticator struct {
+	consumerKey string
+	privateKey  *rsa.PrivateKey
+	token       string
+
+	now   func() time.Time
+	nonce func() string
+}
+
+// NewOAuth1Authenticator creates an authenticator which signs requests with the private key of the consumer and the
+// provided access token
+func NewOAuth1Authenticator(consumerKey string, privateKey *rsa.PrivateKey, accessToken string) (*OAuth1Authenticator, error) {
+	if consumerKey == "" {
+		return nil, errors.New("consumer key cannot be empty")
+	}
+
+	if privateKey == nil {
+		return nil, errors.New("private key cannot be nil")
+	}
+
+	return &OAuth1Authenticator{
+		consumerKey: consumerKey,
+		privateKey:  privateKey,
+		token:       accessToken,
+		now:         time.Now,
+		nonce:       newOAuth1Nonce,
+	}, nil
+}
+
+// Authenticate signs the provided request and sets the signature in the authorization header
+func (a *OAuth1Authenticator) Authenticate(req *http.Request) error {
+	return a.sign(req, nil)
+}
+
+// sign signs the request with the oauth parameters and the provided extra oauth parameters, e.g. the callback or
+// verifier used while obtaining a token
+func (a *OAuth1Authenticator) sign(req *http.Request, extra map[string]string) error {
+	params := map[string]string{
+		"oauth_consumer_key":     a.consumerKey,
+		"oauth_nonce":            a.nonce(),
+		"oauth_signature_method": "RSA-SHA1",
+		"oauth_timestamp":        strconv.FormatInt(a.now().Unix(), 10),
+		"oauth_version":          "1.0",
+	}
+
+	if a.token != "" {
+		params["oauth_token"] = a.token
+	}
+
+	for key, value := range extra {
+		params[key] = value
+	}
+
+	sum := sha1.Sum([]byte(oauth1SignatureBaseString(req, params)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.privateKey, crypto.SHA1, sum[:])
+
+	if err != nil {
+		return fmt.Errorf("could not sign request: %w", err)
+	}
+
+	params["oauth_signature"] = base64.StdEncoding.EncodeToString(signature)
+
+	keys := make([]string, 0, len(params))
+
+	for key := range params {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s=\"%s\"", oauth1Escape(key), oauth1Escape(params[key]))
+	}
+
+	req.Header.Set("Authorization", "OAuth "+strings.Join(parts, ", "))
+	return nil
+}
+
+// oauth1SignatureBaseString creates the signature base string of the request, see RFC 5849 section 3.4.1
+func oauth1SignatureBaseString(req *http.Request, oauthParams map[string]string) string {
+	type param struct{ key, value string }
+	var params []param
+
+	for key, values := range req.URL.Query() {
+		for _, value := range values {
+			params = append(params, param{oauth1Escape(key), oauth1Escape(value)})
+		}
+	}
+
+	for key, value := range oauthParams {
+		params = append(params, param{oauth1Escape(key), oauth1Escape(value)})
+	}
+
+	sort.Slice(params, func(i, j int) bool {
+		if params[i].key == params[j].key {
+			return params[i].value < params[j].value
+		}
+
+		return params[i].key < params[j].key
+	})
+
+	parts := make([]string, len(params))
+
+	for i, p := range params {
+		parts[i] = p.key + "=" + p.value
+	}
+
+	baseURL := url.URL{Scheme: strings.ToLower(req.URL.Scheme), Host: strings.ToLower(req.URL.Host), Path: req.URL.EscapedPath()}
+	return strings.Join([]string{
+		strings.ToUpper(req.Method),
+		oauth1Escape(baseURL.String()),
+		oauth1Escape(strings.Join(parts, "&")),
+	}, "&")
+}
+
+// oauth1Escape percent-encodes the value as described in RFC 5849 section 3.6
+func oauth1Escape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+// newOAuth1Nonce generates a random nonce
+func newOAuth1Nonce() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// LoadRSAPrivateKey reads a PEM encoded PKCS #1 or PKCS #8 RSA private key from the provided file
+func LoadRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read private key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+
+	if block == nil {
+		return nil, fmt.Errorf("could not read private key: %s does not contain a PEM encoded key", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not parse private key: %w", err)
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+
+	if !ok {
+		return nil, errors.New("could not parse private key: not an RSA key")
+	}
+
+	return key, nil
+}
+
+// OAuth1Flow obtains an access token for an application link using the request token and access token endpoints of
+// a Jira Server instance
+type OAuth1Flow struct {
+	host        *url.URL
+	consumerKey string
+	privateKey  *rsa.PrivateKey
+	httpClient  HttpClient
+}
+
+// NewOAuth1Flow creates a flow to obtain an access token from the provided Jira Server host
+func NewOAuth1Flow(host, consumerKey string, privateKey *rsa.PrivateKey, httpClient HttpClient) (*OAuth1Flow, error) {
+	u, err := url.Parse(host)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid host provided: %w", err)
+	}
+
+	if _, err = NewOAuth1Authenticator(consumerKey, privateKey, ""); err != nil {
+		return nil, err
+	}
+
+	return &OAuth1Flow{host: u, consumerKey: consumerKey, privateKey: privateKey, httpClient: httpClient}, nil
+}
+
+// RequestToken obtains a temporary request token. Use "oob" as callback when the verifier is entered by the user.
+func (f *OAuth1Flow) RequestToken(callback string) (string, error) {
+	values, err := f.tokenRequest(oauth1RequestTokenEndpoint, "", map[string]string{"oauth_callback": callback})
+
+	if err != nil {
+		return "", fmt.Errorf("could not obtain request token: %w", err)
+	}
+
+	return values.Get("oauth_token"), nil
+}
+
+// AuthorizationURL returns the url the user visits to authorize the request token
+func (f *OAuth1Flow) AuthorizationURL(requestToken string) string {
+	u := f.host.ResolveReference(&url.URL{Path: strings.TrimSuffix(f.host.Path, "/") + oauth1AuthorizeEndpoint})
+	u.RawQuery = url.Values{"oauth_token": {requestToken}}.Encode()
+	return u.String()
+}
+
+// AccessToken exchanges the authorized request token and the verifier for an access token
+func (f *OAuth1Flow) AccessToken(requestToken, verifier string) (string, error) {
+	values, err := f.tokenRequest(oauth1AccessTokenEndpoint, requestToken, map[string]string{"oauth_verifier": verifier})
+
+	if err != nil {
+		return "", fmt.Errorf("could not obtain access token: %w", err)
+	}
+
+	return values.Get("oauth_token"), nil
+}
+
+// tokenRequest sends a signed request to one of the token endpoints and parses the form encoded response
+func (f *OAuth1Flow) tokenRequest(endpoint, token string, extra map[string]string) (url.Values, error) {
+	u := f.host.ResolveReference(&url.URL{Path: strings.TrimSuffix(f.host.Path, "/") + endpoint})
+	req, err := http.NewRequest(http.MethodPost, u.String(), http.NoBody)
+
+	if err != nil {
+		return nil, err
+	}
+
+	authenticator, err := NewOAuth1Authenticator(f.consumerKey, f.privateKey, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = authenticator.sign(req, extra); err != nil {
+		return nil, err
+	}
+
+	res, err := f.httpClient.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(res.Body)
+	defer res.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("request unsuccessful (%s): %s", res.Status, strings.TrimSpace(string(data)))
+	}
+
+	values, err := url.ParseQuery(string(data))
+
+	if err != nil {
+		return nil, fmt.Errorf("could not process response: %w", err)
+	}
+
+	if values.Get("oauth_token") == "" {
+		return nil, errors.New("response does not contain a token")
+	}
+
+	return values, nil
+}
+
+// OAuth1Credentials holds everything needed to authenticate with OAuth 1.0a after the token has been obtained
+type OAuth1Credentials struct {
+	ConsumerKey    string `json:"consumerKey"`
+	PrivateKeyFile string `json:"privateKeyFile"`
+	AccessToken    string `json:"accessToken"`
+}
+
+// Authenticator loads the private key and creates an OAuth1Authenticator for the credentials
+func (c OAuth1Credentials) Authenticator() (*OAuth1Authenticator, error) {
+	key, err := LoadRSAPrivateKey(c.PrivateKeyFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewOAuth1Authenticator(c.ConsumerKey, key, c.AccessToken)
+}
+
+// LoadOAuth1Credentials reads credentials stored with SaveOAuth1Credentials
+func LoadOAuth1Credentials(path string) (*OAuth1Credentials, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read oauth1 credentials: %w", err)
+	}
+
+	var credentials OAuth1Credentials
+
+	if err = json.Unmarshal(data, &credentials); err != nil {
+		return nil, fmt.Errorf("could not process oauth1 credentials: %w", err)
+	}
+
+	return &credentials, nil
+}
+
+// SaveOAuth1Credentials stores the credentials in the provided file, which is only readable by the current user
+func SaveOAuth1Credentials(path string, credentials OAuth1Credentials) error {
+	data, err := json.MarshalIndent(credentials, "", "  ")
+
+	if err != nil {
+		return fmt.Errorf("could not store oauth1 credentials: %w", err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("could not store oauth1 credentials: %w", err)
+	}
+
+	if err = ioutil.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("could not store oauth1 credentials: %w", err)
+	}
+
+	return nil
+}
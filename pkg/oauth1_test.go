@@ -0,0 +1,147 @@
+package pkg
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestRSAKey generates a private key and stores it as PKCS #8 PEM in a temporary file
+func newTestRSAKey(t *testing.T) (*rsa.PrivateKey, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jira_privatekey.pem")
+
+	if err = ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return key, path
+}
+
+// parseOAuth1Header parses the parameters of an OAuth authorization header
+func parseOAuth1Header(t *testing.T, header string) map[string]string {
+	assert.True(t, strings.HasPrefix(header, "OAuth "))
+	params := map[string]string{}
+
+	for _, part := range strings.Split(strings.TrimPrefix(header, "OAuth "), ", ") {
+		kv := strings.SplitN(part, "=", 2)
+		value, err := url.QueryUnescape(strings.Trim(kv[1], "\""))
+		assert.NoError(t, err)
+		params[kv[0]] = value
+	}
+
+	return params
+}
+
+func Test_oauth1SignatureBaseString(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://Jira.Test.nu/rest/api/latest/search?jql=project%20%3D%20MB&b=2", nil)
+	base := oauth1SignatureBaseString(req, map[string]string{"oauth_consumer_key": "jira-helper", "oauth_nonce": "abc"})
+	assert.Equal(t, "GET&https%3A%2F%2Fjira.test.nu%2Frest%2Fapi%2Flatest%2Fsearch&b%3D2%26jql%3Dproject%2520%253D%2520MB%26oauth_consumer_key%3Djira-helper%26oauth_nonce%3Dabc", base)
+}
+
+func TestOAuth1Authenticator_Authenticate(t *testing.T) {
+	key, path := newTestRSAKey(t)
+	loaded, err := LoadRSAPrivateKey(path)
+	assert.NoError(t, err)
+	assert.True(t, key.Equal(loaded))
+
+	authenticator, err := NewOAuth1Authenticator("jira-helper", loaded, "access-token")
+	assert.NoError(t, err)
+	authenticator.now = func() time.Time { return time.Unix(1643630400, 0) }
+	authenticator.nonce = func() string { return "c0ffee" }
+
+	req, _ := http.NewRequest(http.MethodPut, "https://jira.test.nu/rest/api/latest/issue/MB-1", nil)
+	assert.NoError(t, authenticator.Authenticate(req))
+
+	params := parseOAuth1Header(t, req.Header.Get("Authorization"))
+	assert.Equal(t, "jira-helper", params["oauth_consumer_key"])
+	assert.Equal(t, "access-token", params["oauth_token"])
+	assert.Equal(t, "RSA-SHA1", params["oauth_signature_method"])
+	assert.Equal(t, "1643630400", params["oauth_timestamp"])
+
+	signature, err := base64.StdEncoding.DecodeString(params["oauth_signature"])
+	assert.NoError(t, err)
+	delete(params, "oauth_signature")
+	sum := sha1.Sum([]byte(oauth1SignatureBaseString(req, params)))
+	assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA1, sum[:], signature))
+}
+
+func TestOAuth1Flow(t *testing.T) {
+	key, _ := newTestRSAKey(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := parseOAuth1Header(t, r.Header.Get("Authorization"))
+
+		switch r.URL.Path {
+		case "/jira/plugins/servlet/oauth/request-token":
+			assert.Equal(t, "oob", params["oauth_callback"])
+			_, _ = fmt.Fprint(w, "oauth_token=request-token&oauth_token_secret=secret")
+		case "/jira/plugins/servlet/oauth/access-token":
+			if params["oauth_token"] != "request-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = fmt.Fprint(w, "oauth_problem=token_rejected")
+				return
+			}
+
+			assert.Equal(t, "verifier", params["oauth_verifier"])
+			_, _ = fmt.Fprint(w, "oauth_token=access-token&oauth_token_secret=secret")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	flow, err := NewOAuth1Flow(server.URL+"/jira", "jira-helper", key, server.Client())
+	assert.NoError(t, err)
+
+	requestToken, err := flow.RequestToken("oob")
+	assert.NoError(t, err)
+	assert.Equal(t, "request-token", requestToken)
+	assert.Equal(t, server.URL+"/jira/plugins/servlet/oauth/authorize?oauth_token=request-token", flow.AuthorizationURL(requestToken))
+
+	accessToken, err := flow.AccessToken(requestToken, "verifier")
+	assert.NoError(t, err)
+	assert.Equal(t, "access-token", accessToken)
+
+	_, err = flow.AccessToken("unknown", "verifier")
+	assert.EqualError(t, err, "could not obtain access token: request unsuccessful (401 Unauthorized): oauth_problem=token_rejected")
+}
+
+func TestSaveOAuth1Credentials(t *testing.T) {
+	_, keyPath := newTestRSAKey(t)
+	path := filepath.Join(t.TempDir(), "oauth1-credentials.json")
+	credentials := OAuth1Credentials{ConsumerKey: "jira-helper", PrivateKeyFile: keyPath, AccessToken: "access-token"}
+
+	assert.NoError(t, SaveOAuth1Credentials(path, credentials))
+	loaded, err := LoadOAuth1Credentials(path)
+	assert.NoError(t, err)
+	assert.Equal(t, credentials, *loaded)
+
+	authenticator, err := loaded.Authenticator()
+	assert.NoError(t, err)
+	assert.Equal(t, "access-token", authenticator.token)
+}
@@ -0,0 +1,390 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	AuthTypeOAuth2                  = "oauth2"
+	AuthTypeOAuth2ClientCredentials = "oauth2-client-credentials"
+
+	defaultOAuth2AuthURL  = "https://auth.atlassian.com/authorize"
+	defaultOAuth2TokenURL = "https://auth.atlassian.com/oauth/token"
+	defaultOAuth2APIURL   = "https://api.atlassian.com"
+	defaultOAuth2Audience = "api.atlassian.com"
+
+	// oauth2ExpiryLeeway is subtracted from the expiry of a token, so it is refreshed before it actually expires
+	oauth2ExpiryLeeway = 30 * time.Second
+)
+
+// OAuth2Config holds the settings of the OAuth 2.0 app used to authenticate against Jira Cloud. The URLs default to
+// the Atlassian endpoints when left empty.
+type OAuth2Config struct {
+	ClientId     string
+	ClientSecret string
+	Scopes       []string
+	RedirectURL  string
+	Audience     string
+	AuthURL      string
+	TokenURL     string
+	APIURL       string
+}
+
+// OAuth2Token represents an access token and optionally the refresh token to obtain a new one
+type OAuth2Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	TokenType    string    `json:"token_type,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
+	ExpiresIn    int       `json:"expires_in,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// Valid reports whether the token has an access token which is not (about to be) expired
+func (t *OAuth2Token) Valid() bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+
+	return t.Expiry.IsZero() || time.Now().Add(oauth2ExpiryLeeway).Before(t.Expiry)
+}
+
+// OAuth2Authenticator authenticates requests with an OAuth 2.0 access token. Expired tokens are refreshed with the
+// refresh token or, for the client credentials grant, by requesting a new token.
+type OAuth2Authenticator struct {
+	config            OAuth2Config
+	httpClient        HttpClient
+	clientCredentials bool
+	onToken           func(token OAuth2Token)
+
+	mu    sync.Mutex
+	token *OAuth2Token
+}
+
+// NewOAuth2ClientCredentialsAuthenticator creates an authenticator which uses the client credentials grant, e.g. for
+// service accounts. The access token is requested on the first request.
+func NewOAuth2ClientCredentialsAuthenticator(config OAuth2Config, httpClient HttpClient) (*OAuth2Authenticator, error) {
+	if config.ClientId == "" || config.ClientSecret == "" {
+		return nil, errors.New("client id and client secret are required for the client credentials grant")
+	}
+
+	return &OAuth2Authenticator{config: config.withDefaults(), httpClient: httpClient, clientCredentials: true}, nil
+}
+
+// NewOAuth2Authenticator creates an authenticator which uses the provided token, e.g. obtained with
+// ExchangeAuthorizationCode. When the token expires, it is refreshed with its refresh token.
+func NewOAuth2Authenticator(config OAuth2Config, token OAuth2Token, httpClient HttpClient) (*OAuth2Authenticator, error) {
+	if config.ClientId == "" {
+		return nil, errors.New("client id cannot be empty")
+	}
+
+	if token.AccessToken == "" && token.RefreshToken == "" {
+		return nil, errors.New("token must contain an access token or a refresh token")
+	}
+
+	return &OAuth2Authenticator{config: config.withDefaults(), httpClient: httpClient, token: &token}, nil
+}
+
+// OnToken registers a function which is called whenever a new token has been obtained, e.g. to store it
+func (a *OAuth2Authenticator) OnToken(fn func(token OAuth2Token)) {
+	a.onToken = fn
+}
+
+// Token returns a valid token, refreshing the current token when needed
+func (a *OAuth2Authenticator) Token() (OAuth2Token, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token.Valid() {
+		return *a.token, nil
+	}
+
+	values := url.Values{"client_id": {a.config.ClientId}}
+
+	if a.config.ClientSecret != "" {
+		values.Set("client_secret", a.config.ClientSecret)
+	}
+
+	switch {
+	case a.token != nil && a.token.RefreshToken != "":
+		values.Set("grant_type", "refresh_token")
+		values.Set("refresh_token", a.token.RefreshToken)
+	case a.clientCredentials:
+		values.Set("grant_type", "client_credentials")
+		values.Set("audience", a.config.Audience)
+
+		if len(a.config.Scopes) != 0 {
+			values.Set("scope", strings.Join(a.config.Scopes, " "))
+		}
+	default:
+		return OAuth2Token{}, errors.New("access token expired and no refresh token is available, please log in again")
+	}
+
+	token, err := requestOAuth2Token(a.httpClient, a.config.TokenURL, values)
+
+	if err != nil {
+		return OAuth2Token{}, err
+	}
+
+	if token.RefreshToken == "" && a.token != nil {
+		token.RefreshToken = a.token.RefreshToken
+	}
+
+	a.token = token
+
+	if a.onToken != nil {
+		a.onToken(*token)
+	}
+
+	return *token, nil
+}
+
+// Authenticate sets a valid access token in the authorization header of the provided request
+func (a *OAuth2Authenticator) Authenticate(req *http.Request) error {
+	token, err := a.Token()
+
+	if err != nil {
+		return fmt.Errorf("could not obtain oauth2 access token: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	return nil
+}
+
+// accessibleResource represents a site the access token grants access to
+type accessibleResource struct {
+	Id   string `json:"id"`
+	Url  string `json:"url"`
+	Name string `json:"name"`
+}
+
+// ResolveCloudId looks up the cloud id of the provided site, e.g. https://your-domain.atlassian.net, in the resources
+// the access token grants access to
+func (a *OAuth2Authenticator) ResolveCloudId(site string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, a.config.APIURL+"/oauth/token/accessible-resources", nil)
+
+	if err != nil {
+		return "", fmt.Errorf("could not create request: %w", err)
+	}
+
+	if err = a.Authenticate(req); err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	res, err := a.httpClient.Do(req)
+
+	if err != nil {
+		return "", fmt.Errorf("could not retrieve accessible resources: %w", err)
+	}
+
+	if res.StatusCode/100 != 2 {
+		return "", handleJiraError(res)
+	}
+
+	var resources []accessibleResource
+	data, err := ioutil.ReadAll(res.Body)
+	defer res.Body.Close()
+
+	if err != nil {
+		return "", fmt.Errorf("could not read accessible resources: %w", err)
+	}
+
+	if err = json.Unmarshal(data, &resources); err != nil {
+		return "", fmt.Errorf("could not process accessible resources: %w", err)
+	}
+
+	for _, resource := range resources {
+		if strings.EqualFold(strings.TrimSuffix(resource.Url, "/"), strings.TrimSuffix(site, "/")) || resource.Id == site {
+			return resource.Id, nil
+		}
+	}
+
+	return "", fmt.Errorf("the access token does not grant access to site %s", site)
+}
+
+// BaseURL resolves the cloud id of the provided site and returns the base url through which the Jira API of the site
+// is accessed with an OAuth 2.0 access token
+func (a *OAuth2Authenticator) BaseURL(site string) (string, error) {
+	cloudId, err := a.ResolveCloudId(site)
+
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/ex/jira/%s", a.config.APIURL, cloudId), nil
+}
+
+// NewPKCEVerifier generates a random code verifier for the authorization code grant with PKCE
+func NewPKCEVerifier() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate code verifier: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// PKCEChallenge returns the S256 code challenge for the provided code verifier
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthorizationURL returns the url the user visits to grant access, using the provided state and PKCE code verifier
+func (c OAuth2Config) AuthorizationURL(state, verifier string) string {
+	c = c.withDefaults()
+	values := url.Values{
+		"audience":              {c.Audience},
+		"client_id":             {c.ClientId},
+		"scope":                 {strings.Join(c.Scopes, " ")},
+		"redirect_uri":          {c.RedirectURL},
+		"state":                 {state},
+		"response_type":         {"code"},
+		"prompt":                {"consent"},
+		"code_challenge":        {PKCEChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	return c.AuthURL + "?" + values.Encode()
+}
+
+// ExchangeAuthorizationCode exchanges the authorization code received on the redirect url for a token
+func ExchangeAuthorizationCode(config OAuth2Config, code, verifier string, httpClient HttpClient) (*OAuth2Token, error) {
+	config = config.withDefaults()
+	values := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {config.ClientId},
+		"code":          {code},
+		"redirect_uri":  {config.RedirectURL},
+		"code_verifier": {verifier},
+	}
+
+	if config.ClientSecret != "" {
+		values.Set("client_secret", config.ClientSecret)
+	}
+
+	return requestOAuth2Token(httpClient, config.TokenURL, values)
+}
+
+// requestOAuth2Token posts the provided values to the token endpoint and returns the token from the response
+func requestOAuth2Token(httpClient HttpClient, tokenURL string, values url.Values) (*OAuth2Token, error) {
+	req, err := http.NewRequest(http.MethodPost, tokenURL, strings.NewReader(values.Encode()))
+
+	if err != nil {
+		return nil, fmt.Errorf("could not create token request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	res, err := httpClient.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not request token: %w", err)
+	}
+
+	data, err := ioutil.ReadAll(res.Body)
+	defer res.Body.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read token response: %w", err)
+	}
+
+	if res.StatusCode/100 != 2 {
+		var oauthErr struct {
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}
+
+		_ = json.Unmarshal(data, &oauthErr)
+		return nil, fmt.Errorf("token request unsuccessful (%s): %s %s", res.Status, oauthErr.Error, oauthErr.ErrorDescription)
+	}
+
+	var token OAuth2Token
+
+	if err = json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("could not process token response: %w", err)
+	}
+
+	if token.AccessToken == "" {
+		return nil, errors.New("token response does not contain an access token")
+	}
+
+	if token.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	return &token, nil
+}
+
+// withDefaults returns a copy of the config with the Atlassian endpoints set for the empty urls
+func (c OAuth2Config) withDefaults() OAuth2Config {
+	if c.AuthURL == "" {
+		c.AuthURL = defaultOAuth2AuthURL
+	}
+
+	if c.TokenURL == "" {
+		c.TokenURL = defaultOAuth2TokenURL
+	}
+
+	if c.APIURL == "" {
+		c.APIURL = defaultOAuth2APIURL
+	}
+
+	if c.Audience == "" {
+		c.Audience = defaultOAuth2Audience
+	}
+
+	c.APIURL = strings.TrimSuffix(c.APIURL, "/")
+	return c
+}
+
+// LoadOAuth2Token reads a token stored with SaveOAuth2Token
+func LoadOAuth2Token(path string) (*OAuth2Token, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read oauth2 token: %w", err)
+	}
+
+	var token OAuth2Token
+
+	if err = json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("could not process oauth2 token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// SaveOAuth2Token stores the token in the provided file, which is only readable by the current user
+func SaveOAuth2Token(path string, token OAuth2Token) error {
+	data, err := json.MarshalIndent(token, "", "  ")
+
+	if err != nil {
+		return fmt.Errorf("could not store oauth2 token: %w", err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("could not store oauth2 token: %w", err)
+	}
+
+	if err = ioutil.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("could not store oauth2 token: %w", err)
+	}
+
+	return nil
+}
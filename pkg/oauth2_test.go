@@ -0,0 +1,149 @@
+package pkg
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newFakeOAuth2Server starts a server which emulates the Atlassian token and accessible resources endpoints and the
+// Jira API of a single site
+func newFakeOAuth2Server(t *testing.T, tokenRequests *[]url.Values) *httptest.Server {
+	var server *httptest.Server
+	issued := 0
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/oauth/token":
+			assert.NoError(t, r.ParseForm())
+			*tokenRequests = append(*tokenRequests, r.PostForm)
+
+			if r.PostForm.Get("client_secret") != "s3cret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = fmt.Fprint(w, `{"error":"access_denied","error_description":"Unauthorized"}`)
+				return
+			}
+
+			issued++
+			_, _ = fmt.Fprintf(w, `{"access_token":"access-%d","refresh_token":"refresh-%d","expires_in":3600,"token_type":"Bearer"}`, issued, issued)
+		case "/oauth/token/accessible-resources":
+			_, _ = fmt.Fprint(w, `[{"id":"1324a887-45db-1bf4-1e99-ef0ff456d421","url":"https://your-domain.atlassian.net","name":"your-domain"}]`)
+		case "/ex/jira/1324a887-45db-1bf4-1e99-ef0ff456d421/rest/api/latest/issue/MB-1":
+			assert.Equal(t, fmt.Sprintf("Bearer access-%d", issued), r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOAuth2Authenticator_clientCredentials(t *testing.T) {
+	var tokenRequests []url.Values
+	server := newFakeOAuth2Server(t, &tokenRequests)
+	config := OAuth2Config{ClientId: "client", ClientSecret: "s3cret", TokenURL: server.URL + "/oauth/token", APIURL: server.URL}
+
+	authenticator, err := NewOAuth2ClientCredentialsAuthenticator(config, server.Client())
+	assert.NoError(t, err)
+
+	baseURL, err := authenticator.BaseURL("https://your-domain.atlassian.net/")
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/ex/jira/1324a887-45db-1bf4-1e99-ef0ff456d421", baseURL)
+
+	client, err := NewJiraClientWithAuthenticator(baseURL, authenticator, server.Client())
+	assert.NoError(t, err)
+	assert.NoError(t, client.AssignVersion("MB-1", "1.0.0"))
+
+	assert.Len(t, tokenRequests, 1, "the token should be reused until it expires")
+	assert.Equal(t, "client_credentials", tokenRequests[0].Get("grant_type"))
+	assert.Equal(t, "api.atlassian.com", tokenRequests[0].Get("audience"))
+}
+
+func TestOAuth2Authenticator_refresh(t *testing.T) {
+	var tokenRequests []url.Values
+	server := newFakeOAuth2Server(t, &tokenRequests)
+	config := OAuth2Config{ClientId: "client", ClientSecret: "s3cret", TokenURL: server.URL + "/oauth/token", APIURL: server.URL}
+	expired := OAuth2Token{AccessToken: "expired", RefreshToken: "refresh-0", Expiry: time.Now().Add(-time.Minute)}
+
+	authenticator, err := NewOAuth2Authenticator(config, expired, server.Client())
+	assert.NoError(t, err)
+
+	var stored []OAuth2Token
+	authenticator.OnToken(func(token OAuth2Token) {
+		stored = append(stored, token)
+	})
+
+	token, err := authenticator.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "access-1", token.AccessToken)
+	assert.True(t, token.Valid())
+	assert.Len(t, stored, 1)
+	assert.Equal(t, "refresh_token", tokenRequests[0].Get("grant_type"))
+	assert.Equal(t, "refresh-0", tokenRequests[0].Get("refresh_token"))
+}
+
+func TestOAuth2Authenticator_refreshDenied(t *testing.T) {
+	var tokenRequests []url.Values
+	server := newFakeOAuth2Server(t, &tokenRequests)
+	config := OAuth2Config{ClientId: "client", ClientSecret: "wrong", TokenURL: server.URL + "/oauth/token", APIURL: server.URL}
+	expired := OAuth2Token{AccessToken: "expired", RefreshToken: "refresh-0", Expiry: time.Now().Add(-time.Minute)}
+
+	authenticator, err := NewOAuth2Authenticator(config, expired, server.Client())
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	err = authenticator.Authenticate(req)
+	assert.EqualError(t, err, "could not obtain oauth2 access token: token request unsuccessful (401 Unauthorized): access_denied Unauthorized")
+}
+
+func TestOAuth2Authenticator_expiredWithoutRefreshToken(t *testing.T) {
+	config := OAuth2Config{ClientId: "client"}
+	authenticator, err := NewOAuth2Authenticator(config, OAuth2Token{AccessToken: "expired", Expiry: time.Now().Add(-time.Minute)}, nil)
+	assert.NoError(t, err)
+
+	_, err = authenticator.Token()
+	assert.EqualError(t, err, "access token expired and no refresh token is available, please log in again")
+}
+
+func TestExchangeAuthorizationCode(t *testing.T) {
+	var tokenRequests []url.Values
+	server := newFakeOAuth2Server(t, &tokenRequests)
+	config := OAuth2Config{ClientId: "client", ClientSecret: "s3cret", RedirectURL: "http://localhost:8085/callback", TokenURL: server.URL + "/oauth/token"}
+
+	token, err := ExchangeAuthorizationCode(config, "code", "verifier", server.Client())
+	assert.NoError(t, err)
+	assert.Equal(t, "access-1", token.AccessToken)
+	assert.Equal(t, "authorization_code", tokenRequests[0].Get("grant_type"))
+	assert.Equal(t, "verifier", tokenRequests[0].Get("code_verifier"))
+	assert.Equal(t, "http://localhost:8085/callback", tokenRequests[0].Get("redirect_uri"))
+}
+
+func TestOAuth2Config_AuthorizationURL(t *testing.T) {
+	config := OAuth2Config{ClientId: "client", Scopes: []string{"read:jira-work", "offline_access"}, RedirectURL: "http://localhost:8085/callback"}
+	u, err := url.Parse(config.AuthorizationURL("state", "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+	assert.NoError(t, err)
+	assert.Equal(t, "auth.atlassian.com", u.Host)
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", u.Query().Get("code_challenge"))
+	assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+	assert.Equal(t, "read:jira-work offline_access", u.Query().Get("scope"))
+}
+
+func TestSaveOAuth2Token(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jira-helper", "token.json")
+	token := OAuth2Token{AccessToken: "access", RefreshToken: "refresh", Expiry: time.Date(2022, 1, 31, 12, 0, 0, 0, time.UTC)}
+
+	assert.NoError(t, SaveOAuth2Token(path, token))
+	loaded, err := LoadOAuth2Token(path)
+	assert.NoError(t, err)
+	assert.Equal(t, token, *loaded)
+}
@@ -0,0 +1,293 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// defaultPageSize is the number of items requested per page, which Jira may lower
+const defaultPageSize = 50
+
+// Page is a single page of a paged Jira response. Jira uses two styles of paged responses: the search style, which
+// has a total and the items under a named key, e.g. issues or comments, and the page bean style, which has isLast and
+// the items under values. A response which is a plain array is a single, last page.
+type Page struct {
+	StartAt    int
+	MaxResults int
+	// Total is the total number of items, or -1 when the response does not have a total
+	Total  int
+	IsLast bool
+	Items  []json.RawMessage
+}
+
+// last reports whether there are no pages after this page
+func (p *Page) last() bool {
+	return p.IsLast || len(p.Items) == 0 || (p.Total >= 0 && p.StartAt+len(p.Items) >= p.Total)
+}
+
+// pageRequest creates the request for the page which starts at startAt
+type pageRequest func(ctx context.Context, startAt, maxResults int) (*http.Request, error)
+
+// Paginator requests the pages of a list or search endpoint. Use Iterate to loop over the items.
+type Paginator struct {
+	client   *JiraClient
+	request  pageRequest
+	itemsKey string
+	pageSize int
+	prefetch bool
+}
+
+// NewPaginator creates a paginator for a list endpoint which is requested with GET and the startAt and maxResults
+// query parameters, e.g. /project/MB/version. itemsKey is the key of the items in the response, e.g. values or
+// comments.
+func (c *JiraClient) NewPaginator(endpoint string, query url.Values, itemsKey string) *Paginator {
+	return &Paginator{
+		client:   c,
+		itemsKey: itemsKey,
+		pageSize: defaultPageSize,
+		request: func(ctx context.Context, startAt, maxResults int) (*http.Request, error) {
+			q := url.Values{}
+
+			for key, values := range query {
+				q[key] = values
+			}
+
+			q.Set("startAt", strconv.Itoa(startAt))
+			q.Set("maxResults", strconv.Itoa(maxResults))
+			return c.createRequest(ctx, http.MethodGet, apiEndpoint+endpoint+"?"+q.Encode(), nil)
+		},
+	}
+}
+
+// NewSearchPaginator creates a paginator for the issues matching the JQL query, with the provided fields
+func (c *JiraClient) NewSearchPaginator(jql string, fields ...string) *Paginator {
+	return c.newSearchPaginator(jql, "", fields)
+}
+
+// newSearchPaginator creates a search paginator which validates the query with the provided mode: strict, warn or
+// none. An empty mode uses the default of Jira, which is strict.
+func (c *JiraClient) newSearchPaginator(jql, validateQuery string, fields []string) *Paginator {
+	return &Paginator{
+		client:   c,
+		itemsKey: "issues",
+		pageSize: defaultPageSize,
+		request: func(ctx context.Context, startAt, maxResults int) (*http.Request, error) {
+			body := searchRequestBody{Jql: jql, StartAt: startAt, MaxResults: maxResults, Fields: fields, ValidateQuery: validateQuery}
+			return c.createRequest(ctx, http.MethodPost, apiEndpoint+"/search", body)
+		},
+	}
+}
+
+// WithPageSize sets the number of items to request per page
+func (p *Paginator) WithPageSize(size int) *Paginator {
+	if size > 0 {
+		p.pageSize = size
+	}
+
+	return p
+}
+
+// WithPrefetch makes the paginator request the next page while the items of the current page are processed. Without
+// it, a page is only requested when the items of the previous page are exhausted.
+func (p *Paginator) WithPrefetch() *Paginator {
+	p.prefetch = true
+	return p
+}
+
+// Iterate returns an iterator over the items of all pages. Close the iterator when stopping early, so a prefetched
+// page is cancelled.
+func (p *Paginator) Iterate(ctx context.Context) *Iterator {
+	ctx, cancel := context.WithCancel(ctx)
+	it := &Iterator{paginator: p, ctx: ctx, cancel: cancel}
+
+	if p.prefetch {
+		it.pending = it.fetch(0)
+	}
+
+	return it
+}
+
+// fetch requests the page which starts at startAt
+func (p *Paginator) fetch(ctx context.Context, startAt int) (*Page, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	req, err := p.request(ctx, startAt, p.pageSize)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var raw json.RawMessage
+
+	if err = p.client.doRequest(req, &raw); err != nil {
+		return nil, fmt.Errorf("could not get page at %d: %w", startAt, err)
+	}
+
+	page, err := parsePage(raw, p.itemsKey)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not get page at %d: %w", startAt, err)
+	}
+
+	return page, nil
+}
+
+// parsePage parses a paged response in either style, or a plain array
+func parsePage(data []byte, itemsKey string) (*Page, error) {
+	var items []json.RawMessage
+
+	if err := json.Unmarshal(data, &items); err == nil {
+		return &Page{MaxResults: len(items), Total: len(items), IsLast: true, Items: items}, nil
+	}
+
+	var response map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("invalid paged response: %w", err)
+	}
+
+	page := &Page{Total: -1}
+	fields := map[string]interface{}{"startAt": &page.StartAt, "maxResults": &page.MaxResults, "total": &page.Total, "isLast": &page.IsLast, itemsKey: &page.Items}
+
+	for key, target := range fields {
+		if value, ok := response[key]; ok {
+			if err := json.Unmarshal(value, target); err != nil {
+				return nil, fmt.Errorf("invalid %s in paged response: %w", key, err)
+			}
+		}
+	}
+
+	if _, ok := response[itemsKey]; !ok {
+		return nil, fmt.Errorf("paged response has no %s", itemsKey)
+	}
+
+	return page, nil
+}
+
+// pageResult is the outcome of a page request
+type pageResult struct {
+	page *Page
+	err  error
+}
+
+// Iterator loops over the items of the pages of a Paginator:
+//
+//	it := client.NewSearchPaginator("project = MB", "key").Iterate(ctx)
+//	defer it.Close()
+//
+//	for it.Next() {
+//		var issue struct{ Key string }
+//		err := it.Decode(&issue)
+//	}
+//
+//	err := it.Err()
+type Iterator struct {
+	paginator *Paginator
+	ctx       context.Context
+	cancel    context.CancelFunc
+	pending   chan pageResult
+	page      *Page
+	index     int
+	// next is the start of the next page, and done reports whether there is no next page
+	next int
+	done bool
+	err  error
+}
+
+// fetch requests the page which starts at startAt, in the background when prefetching is enabled
+func (it *Iterator) fetch(startAt int) chan pageResult {
+	result := make(chan pageResult, 1)
+	fetch := func() {
+		page, err := it.paginator.fetch(it.ctx, startAt)
+		result <- pageResult{page: page, err: err}
+	}
+
+	if it.paginator.prefetch {
+		go fetch()
+	} else {
+		fetch()
+	}
+
+	return result
+}
+
+// Next advances to the next item and reports whether there is one. It returns false at the end of the items and
+// when a page could not be requested, in which case Err returns the error.
+func (it *Iterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	it.index++
+
+	for it.page == nil || it.index >= len(it.page.Items) {
+		if it.pending == nil {
+			if it.done {
+				return false
+			}
+
+			it.pending = it.fetch(it.next)
+		}
+
+		result := <-it.pending
+		it.pending = nil
+
+		if result.err != nil {
+			it.err = result.err
+			return false
+		}
+
+		it.page, it.index = result.page, 0
+		it.next, it.done = result.page.StartAt+len(result.page.Items), result.page.last()
+
+		if it.paginator.prefetch && !it.done {
+			it.pending = it.fetch(it.next)
+		}
+	}
+
+	return true
+}
+
+// Value returns the current item as raw JSON
+func (it *Iterator) Value() json.RawMessage {
+	if it.page == nil || it.index >= len(it.page.Items) {
+		return nil
+	}
+
+	return it.page.Items[it.index]
+}
+
+// Decode unmarshalls the current item into the target
+func (it *Iterator) Decode(target interface{}) error {
+	value := it.Value()
+
+	if value == nil {
+		return errors.New("iterator has no current item")
+	}
+
+	if err := json.Unmarshal(value, target); err != nil {
+		return fmt.Errorf("could not decode item: %w", err)
+	}
+
+	return nil
+}
+
+// Err returns the error which stopped the iteration, if any
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// Close stops the iteration and cancels a prefetched page request
+func (it *Iterator) Close() {
+	it.cancel()
+	it.pending = nil
+	it.page = nil
+	it.done = true
+}
@@ -0,0 +1,210 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newPagedMockClient returns a mock http client which serves the items in pages with the provided response function
+// and records the startAt of every request
+func newPagedMockClient(t *testing.T, requested *[]int, response func(startAt, maxResults int) string) *JiraClient {
+	client, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", mockHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+		startAt, maxResults := 0, 0
+
+		if req.Method == http.MethodPost {
+			var body searchRequestBody
+			data, _ := ioutil.ReadAll(req.Body)
+			_ = json.Unmarshal(data, &body)
+			startAt, maxResults = body.StartAt, body.MaxResults
+		} else {
+			startAt, _ = strconv.Atoi(req.URL.Query().Get("startAt"))
+			maxResults, _ = strconv.Atoi(req.URL.Query().Get("maxResults"))
+		}
+
+		*requested = append(*requested, startAt)
+		return newMockResponse(http.StatusOK, response(startAt, maxResults)), nil
+	}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+// collectKeys iterates over all items and returns the values of their key or id
+func collectKeys(t *testing.T, it *Iterator) []string {
+	defer it.Close()
+	var keys []string
+
+	for it.Next() {
+		var item struct {
+			Key string `json:"key"`
+			Id  string `json:"id"`
+		}
+
+		assert.NoError(t, it.Decode(&item))
+		keys = append(keys, item.Key+item.Id)
+	}
+
+	assert.NoError(t, it.Err())
+	return keys
+}
+
+func TestPaginator_search(t *testing.T) {
+	var requested []int
+	client := newPagedMockClient(t, &requested, func(startAt, maxResults int) string {
+		var issues []string
+
+		// Jira may return fewer items than requested
+		for i := startAt; i < 5 && i < startAt+maxResults-1; i++ {
+			issues = append(issues, fmt.Sprintf(`{"key":"MB-%d"}`, i+1))
+		}
+
+		return fmt.Sprintf(`{"startAt":%d,"maxResults":%d,"total":5,"issues":[%s]}`, startAt, maxResults, strings.Join(issues, ","))
+	})
+
+	keys := collectKeys(t, client.NewSearchPaginator("project = MB", "key").WithPageSize(3).Iterate(context.Background()))
+	assert.Equal(t, []string{"MB-1", "MB-2", "MB-3", "MB-4", "MB-5"}, keys)
+	assert.Equal(t, []int{0, 2, 4}, requested)
+}
+
+func TestPaginator_isLast(t *testing.T) {
+	var requested []int
+	client := newPagedMockClient(t, &requested, func(startAt, maxResults int) string {
+		return fmt.Sprintf(`{"startAt":%d,"maxResults":2,"isLast":%t,"values":[{"id":"%d"},{"id":"%d"}]}`, startAt, startAt >= 2, startAt+1, startAt+2)
+	})
+
+	query := url.Values{"orderBy": {"name"}}
+	keys := collectKeys(t, client.NewPaginator("/project/MB/version", query, "values").WithPageSize(2).Iterate(context.Background()))
+	assert.Equal(t, []string{"1", "2", "3", "4"}, keys)
+	assert.Equal(t, []int{0, 2}, requested)
+}
+
+func TestPaginator_array(t *testing.T) {
+	var requested []int
+	client := newPagedMockClient(t, &requested, func(startAt, maxResults int) string {
+		return `[{"id":"1"},{"id":"2"}]`
+	})
+
+	keys := collectKeys(t, client.NewPaginator("/project/MB/versions", nil, "values").Iterate(context.Background()))
+	assert.Equal(t, []string{"1", "2"}, keys)
+	assert.Len(t, requested, 1)
+}
+
+func TestPaginator_empty(t *testing.T) {
+	var requested []int
+	client := newPagedMockClient(t, &requested, func(startAt, maxResults int) string {
+		return `{"startAt":0,"maxResults":50,"total":0,"comments":[]}`
+	})
+
+	keys := collectKeys(t, client.NewPaginator("/issue/MB-1/comment", nil, "comments").Iterate(context.Background()))
+	assert.Empty(t, keys)
+	assert.Len(t, requested, 1)
+}
+
+func TestPaginator_error(t *testing.T) {
+	client, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", mockHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Query().Get("startAt") == "0" {
+			return newMockResponse(http.StatusOK, `{"startAt":0,"maxResults":1,"total":2,"values":[{"id":"1"}]}`), nil
+		}
+
+		return newMockResponse(http.StatusTooManyRequests, `{"errorMessages":["Rate limit exceeded."]}`), nil
+	}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	it := client.NewPaginator("/project/MB/version", nil, "values").Iterate(context.Background())
+	defer it.Close()
+
+	assert.True(t, it.Next())
+	assert.Equal(t, json.RawMessage(`{"id":"1"}`), it.Value())
+	assert.False(t, it.Next())
+	assert.True(t, IsRateLimited(it.Err()))
+	assert.EqualError(t, it.Err(), "could not get page at 1: request unsuccessful (429 Too Many Requests): Rate limit exceeded.")
+}
+
+func TestPaginator_invalidResponse(t *testing.T) {
+	var requested []int
+	client := newPagedMockClient(t, &requested, func(startAt, maxResults int) string {
+		return `{"startAt":0,"total":1,"issues":[]}`
+	})
+
+	it := client.NewPaginator("/project/MB/version", nil, "values").Iterate(context.Background())
+	assert.False(t, it.Next())
+	assert.EqualError(t, it.Err(), "could not get page at 0: paged response has no values")
+}
+
+func TestPaginator_lazy(t *testing.T) {
+	var requested []int
+	client := newPagedMockClient(t, &requested, func(startAt, maxResults int) string {
+		return fmt.Sprintf(`{"startAt":%d,"maxResults":1,"total":3,"values":[{"id":"%d"}]}`, startAt, startAt+1)
+	})
+
+	it := client.NewPaginator("/project/MB/version", nil, "values").WithPageSize(1).Iterate(context.Background())
+	assert.Empty(t, requested, "no page is requested before Next")
+
+	assert.True(t, it.Next())
+	assert.Equal(t, json.RawMessage(`{"id":"1"}`), it.Value())
+	it.Close()
+
+	assert.False(t, it.Next())
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []int{0}, requested, "the next page is only requested when the first page is exhausted")
+}
+
+func TestPaginator_prefetch(t *testing.T) {
+	requests := make(chan *http.Request, 10)
+	client, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", mockHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+		requests <- req
+
+		if req.URL.Query().Get("startAt") == "0" {
+			return newMockResponse(http.StatusOK, `{"startAt":0,"maxResults":1,"total":3,"values":[{"id":"1"}]}`), nil
+		}
+
+		// Block the prefetched page until the iterator is closed
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	it := client.NewPaginator("/project/MB/version", nil, "values").WithPageSize(1).WithPrefetch().Iterate(context.Background())
+	assert.True(t, it.Next())
+
+	// The next page is requested while the first item is processed
+	<-requests
+	prefetched := <-requests
+	assert.Equal(t, "1", prefetched.URL.Query().Get("startAt"))
+
+	it.Close()
+	assert.Equal(t, context.Canceled, prefetched.Context().Err())
+	assert.False(t, it.Next())
+	assert.NoError(t, it.Err())
+}
+
+func TestPaginator_cancelled(t *testing.T) {
+	var requested []int
+	client := newPagedMockClient(t, &requested, func(startAt, maxResults int) string {
+		return `{"values":[]}`
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	it := client.NewPaginator("/project/MB/version", nil, "values").Iterate(ctx)
+	assert.False(t, it.Next())
+	assert.ErrorIs(t, it.Err(), context.Canceled)
+}
@@ -0,0 +1,204 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	PermissionBrowseProjects     = "BROWSE_PROJECTS"
+	PermissionAdministerProjects = "ADMINISTER_PROJECTS"
+	PermissionEditIssues         = "EDIT_ISSUES"
+	PermissionResolveIssues      = "RESOLVE_ISSUES"
+)
+
+// permissionUsages explains what the permissions are needed for
+var permissionUsages = map[string]string{
+	PermissionBrowseProjects:     "find the project, its versions and its issues",
+	PermissionAdministerProjects: "create, release, archive, merge or delete versions",
+	PermissionEditIssues:         "change the versions and fields of issues",
+	PermissionResolveIssues:      "change the fix versions of issues",
+}
+
+// OperationPermissions are the permissions the commands need in every project they change. Removing a version from
+// issues with unassignRelease needs ADMINISTER_PROJECTS as well when the version is unreleased or deleted afterwards.
+var OperationPermissions = map[string][]string{
+	"createRelease":      {PermissionBrowseProjects, PermissionAdministerProjects},
+	"assignRelease":      {PermissionBrowseProjects, PermissionEditIssues, PermissionResolveIssues},
+	"createAndAssign":    {PermissionBrowseProjects, PermissionAdministerProjects, PermissionEditIssues, PermissionResolveIssues},
+	"unassignRelease":    {PermissionBrowseProjects, PermissionEditIssues, PermissionResolveIssues},
+	"consolidateRelease": {PermissionBrowseProjects, PermissionAdministerProjects, PermissionEditIssues, PermissionResolveIssues},
+}
+
+// Permission is a permission of the user, as returned by the Jira mypermissions endpoint
+type Permission struct {
+	Id             string `json:"id"`
+	Key            string `json:"key"`
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	Description    string `json:"description"`
+	HavePermission bool   `json:"havePermission"`
+}
+
+// permissionsResponse represents the Jira mypermissions API response
+type permissionsResponse struct {
+	Permissions map[string]Permission `json:"permissions"`
+}
+
+// GetMyPermissions retrieves whether the user has the provided permissions in the project, by their key
+func (c *JiraClient) GetMyPermissions(project string, permissions ...string) (map[string]Permission, error) {
+	return c.GetMyPermissionsContext(context.Background(), project, permissions...)
+}
+
+// GetMyPermissionsContext is GetMyPermissions with a context which cancels the request
+func (c *JiraClient) GetMyPermissionsContext(ctx context.Context, project string, permissions ...string) (map[string]Permission, error) {
+	query := url.Values{"projectKey": {project}, "permissions": {strings.Join(permissions, ",")}}
+	req, err := c.createRequest(ctx, http.MethodGet, apiEndpoint+"/mypermissions?"+query.Encode(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var response permissionsResponse
+
+	if err = c.doRequest(req, &response); err != nil {
+		return nil, fmt.Errorf("could not retrieve permissions in project %s: %w", project, err)
+	}
+
+	return response.Permissions, nil
+}
+
+// RequiredPermissions returns the permissions the steps need, by project key. The project of a step which changes an
+// issue is taken from the key of the issue.
+func RequiredPermissions(steps []Step) map[string][]string {
+	required := map[string][]string{}
+	add := func(project string, permissions ...string) {
+		required[project] = removeDuplicates(append(required[project], append([]string{PermissionBrowseProjects}, permissions...)...))
+	}
+
+	for _, step := range steps {
+		switch s := step.(type) {
+		case *CreateVersionStep:
+			add(s.Project, PermissionAdministerProjects)
+		case *ReleaseVersionStep:
+			add(s.Project, PermissionAdministerProjects)
+		case *UnreleaseVersionStep:
+			add(s.Project, PermissionAdministerProjects)
+		case *ArchiveVersionStep:
+			add(s.Project, PermissionAdministerProjects)
+		case *MergeVersionStep:
+			add(s.Project, PermissionAdministerProjects)
+		case *DeleteVersionStep:
+			add(s.Project, PermissionAdministerProjects)
+		case *AssignVersionStep:
+			add(IssueProject(s.Issue), PermissionEditIssues, PermissionResolveIssues)
+		case *UnassignVersionStep:
+			add(IssueProject(s.Issue), PermissionEditIssues, PermissionResolveIssues)
+		case *UpdateIssueStep:
+			if fix := s.FixVersions; len(fix.Add)+len(fix.Remove)+len(fix.Set) != 0 {
+				add(IssueProject(s.Issue), PermissionEditIssues, PermissionResolveIssues)
+			} else {
+				add(IssueProject(s.Issue), PermissionEditIssues)
+			}
+		}
+	}
+
+	return required
+}
+
+// PermissionResult holds whether the user has a required permission in a project
+type PermissionResult struct {
+	Project string `json:"project" yaml:"project"`
+	Key     string `json:"key" yaml:"key"`
+	Name    string `json:"name" yaml:"name"`
+	Granted bool   `json:"granted" yaml:"granted"`
+	// NeededFor explains what the permission is needed for
+	NeededFor string `json:"neededFor,omitempty" yaml:"neededFor,omitempty"`
+}
+
+// PermissionCheck holds the outcome of the check of every required permission
+type PermissionCheck struct {
+	Permissions []PermissionResult
+}
+
+// Missing returns the permissions the user does not have
+func (p *PermissionCheck) Missing() []PermissionResult {
+	var missing []PermissionResult
+
+	for _, permission := range p.Permissions {
+		if !permission.Granted {
+			missing = append(missing, permission)
+		}
+	}
+
+	return missing
+}
+
+// Err returns an error which explains every missing permission, or nil when the user has all permissions
+func (p *PermissionCheck) Err() error {
+	missing := p.Missing()
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	explanations := make([]string, len(missing))
+
+	for i, permission := range missing {
+		explanations[i] = fmt.Sprintf("%s (%s) in project %s", permission.Name, permission.Key, permission.Project)
+
+		if permission.NeededFor != "" {
+			explanations[i] += ", needed to " + permission.NeededFor
+		}
+	}
+
+	return fmt.Errorf("%d of %d required permissions are missing: %s", len(missing), len(p.Permissions), strings.Join(explanations, "; "))
+}
+
+// CheckPermissions checks whether the user has the required permissions, by project key, with a single request per
+// project. Use RequiredPermissions or OperationPermissions for the required permissions.
+func (c *JiraClient) CheckPermissions(required map[string][]string) (*PermissionCheck, error) {
+	return c.CheckPermissionsContext(context.Background(), required)
+}
+
+// CheckPermissionsContext is CheckPermissions with a context which cancels the requests
+func (c *JiraClient) CheckPermissionsContext(ctx context.Context, required map[string][]string) (*PermissionCheck, error) {
+	projects := make([]string, 0, len(required))
+
+	for project := range required {
+		projects = append(projects, project)
+	}
+
+	sort.Strings(projects)
+	check := &PermissionCheck{}
+
+	for _, project := range projects {
+		keys := removeDuplicates(required[project])
+		permissions, err := c.GetMyPermissionsContext(ctx, project, keys...)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			permission, ok := permissions[key]
+			result := PermissionResult{Project: project, Key: key, Name: key, Granted: ok && permission.HavePermission, NeededFor: permissionUsages[key]}
+
+			if permission.Name != "" {
+				result.Name = permission.Name
+			}
+
+			if !result.Granted {
+				c.log().Warn("missing permission", "project", project, "permission", key)
+			}
+
+			check.Permissions = append(check.Permissions, result)
+		}
+	}
+
+	return check, nil
+}
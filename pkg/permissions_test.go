@@ -0,0 +1,82 @@
+package pkg
+
+import (
+	"github.com/marcelblijleven/jira-helper/pkg/jiratest/testserver"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestRequiredPermissions(t *testing.T) {
+	steps := CreateAndAssignSteps("1.0.0", "MB", "", []string{"MB-1", "OPS-1"}, nil, nil)
+	steps = append(steps,
+		&UpdateIssueStep{Issue: "DOC-1", AffectsVersions: VersionChange{Add: []string{"1.0.0"}}},
+		&DeleteVersionStep{Name: "0.9.0", Project: "MB"},
+	)
+
+	assert.Equal(t, map[string][]string{
+		"MB":  {PermissionBrowseProjects, PermissionAdministerProjects, PermissionEditIssues, PermissionResolveIssues},
+		"OPS": {PermissionBrowseProjects, PermissionEditIssues, PermissionResolveIssues},
+		"DOC": {PermissionBrowseProjects, PermissionEditIssues},
+	}, RequiredPermissions(steps))
+	assert.Empty(t, RequiredPermissions(nil))
+}
+
+func TestJiraClient_CheckPermissions(t *testing.T) {
+	server := testserver.New(t)
+	server.AddProject("MB")
+	server.AddProject("OPS")
+	server.DenyPermission("OPS", PermissionAdministerProjects, PermissionResolveIssues)
+	client, err := NewJiraClient(server.URL, "marcel@test.nl", "c0ffee", http.DefaultClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := client.CheckPermissions(map[string][]string{
+		"OPS": OperationPermissions["createAndAssign"],
+		"MB":  OperationPermissions["createRelease"],
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []PermissionResult{
+		{Project: "MB", Key: PermissionBrowseProjects, Name: "Browse Projects", Granted: true, NeededFor: "find the project, its versions and its issues"},
+		{Project: "MB", Key: PermissionAdministerProjects, Name: "Administer Projects", Granted: true, NeededFor: "create, release, archive, merge or delete versions"},
+		{Project: "OPS", Key: PermissionBrowseProjects, Name: "Browse Projects", Granted: true, NeededFor: "find the project, its versions and its issues"},
+		{Project: "OPS", Key: PermissionAdministerProjects, Name: "Administer Projects", NeededFor: "create, release, archive, merge or delete versions"},
+		{Project: "OPS", Key: PermissionEditIssues, Name: "Edit Issues", Granted: true, NeededFor: "change the versions and fields of issues"},
+		{Project: "OPS", Key: PermissionResolveIssues, Name: "Resolve Issues", NeededFor: "change the fix versions of issues"},
+	}, check.Permissions)
+	assert.Len(t, check.Missing(), 2)
+	assert.EqualError(t, check.Err(), "2 of 6 required permissions are missing: Administer Projects (ADMINISTER_PROJECTS) in project OPS, needed to create, release, archive, merge or delete versions; Resolve Issues (RESOLVE_ISSUES) in project OPS, needed to change the fix versions of issues")
+	assert.Len(t, server.Requests(), 2)
+	assert.Equal(t, "/mypermissions", server.Requests()[0].Path)
+}
+
+func TestJiraClient_CheckPermissions_granted(t *testing.T) {
+	server := testserver.New(t)
+	server.AddProject("MB")
+	client, err := NewJiraClient(server.URL, "marcel@test.nl", "c0ffee", http.DefaultClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := client.CheckPermissions(RequiredPermissions(CreateAndAssignSteps("1.0.0", "MB", "", []string{"MB-1"}, nil, nil)))
+	assert.NoError(t, err)
+	assert.Len(t, check.Permissions, 4)
+	assert.NoError(t, check.Err())
+}
+
+func TestJiraClient_CheckPermissions_unknownProject(t *testing.T) {
+	server := testserver.New(t)
+	client, err := NewJiraClient(server.URL, "marcel@test.nl", "c0ffee", http.DefaultClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.CheckPermissions(map[string][]string{"XX": {PermissionBrowseProjects}})
+	assert.True(t, IsNotFound(err))
+	assert.EqualError(t, err, "could not retrieve permissions in project XX: request unsuccessful (404 Not Found): No project could be found with key 'XX'.")
+}
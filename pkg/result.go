@@ -0,0 +1,278 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	StepCompleted     = "completed"
+	StepSkipped       = "skipped"
+	StepFailed        = "failed"
+	StepNotRun        = "not run"
+	StepRolledBack    = "rolled back"
+	StepNotRolledBack = "not rolled back"
+)
+
+const (
+	OutputText     = "text"
+	OutputJSON     = "json"
+	OutputYAML     = "yaml"
+	OutputTable    = "table"
+	OutputTemplate = "template"
+)
+
+// Result holds the outcome of a command: the versions it created or changed, the outcome of every step and the checked
+// permissions
+type Result struct {
+	RunId         string             `json:"runId,omitempty" yaml:"runId,omitempty"`
+	Versions      []Version          `json:"versions,omitempty" yaml:"versions,omitempty"`
+	Steps         []StepResult       `json:"steps,omitempty" yaml:"steps,omitempty"`
+	SkippedIssues []string           `json:"skippedIssues,omitempty" yaml:"skippedIssues,omitempty"`
+	Warnings      []string           `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	Permissions   []PermissionResult `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+}
+
+// StepResult holds the outcome of a single step. Issue is set for steps which change an issue.
+type StepResult struct {
+	Step        string `json:"step" yaml:"step"`
+	Description string `json:"description" yaml:"description"`
+	Issue       string `json:"issue,omitempty" yaml:"issue,omitempty"`
+	Status      string `json:"status" yaml:"status"`
+	Error       string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// issueStep is implemented by steps which change a single issue
+type issueStep interface {
+	issue() string
+}
+
+// versionStep is implemented by steps which create or change a version
+type versionStep interface {
+	version() *Version
+}
+
+// newStepResult creates the result of the step with the provided status and error
+func newStepResult(step Step, status string, err error) StepResult {
+	result := StepResult{Step: step.Id(), Description: step.Description(), Status: status}
+
+	if s, ok := step.(issueStep); ok {
+		result.Issue = s.issue()
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// WriteResult writes the result in the provided format: text, json, yaml, table or template=<go template>
+func WriteResult(w io.Writer, format string, result *Result) error {
+	if result == nil {
+		result = &Result{}
+	}
+
+	name, value := splitOutputFormat(format)
+
+	switch name {
+	case "", OutputText:
+		return writeText(w, result)
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	case OutputYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+
+		return encoder.Close()
+	case OutputTable:
+		return writeTable(w, result)
+	case OutputTemplate:
+		tmpl, err := template.New("output").Parse(value)
+
+		if err != nil {
+			return fmt.Errorf("invalid output template: %w", err)
+		}
+
+		if err = tmpl.Execute(w, result); err != nil {
+			return fmt.Errorf("could not render output template: %w", err)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q, use text, json, yaml, table or template=<go template>", format)
+	}
+}
+
+// ValidateOutputFormat checks the output format, so a typo can be reported before any change is made
+func ValidateOutputFormat(format string) error {
+	name, value := splitOutputFormat(format)
+
+	switch name {
+	case "", OutputText, OutputJSON, OutputYAML, OutputTable:
+		return nil
+	case OutputTemplate:
+		if _, err := template.New("output").Parse(value); err != nil {
+			return fmt.Errorf("invalid output template: %w", err)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q, use text, json, yaml, table or template=<go template>", format)
+	}
+}
+
+// splitOutputFormat splits the output format in its name and value, e.g. template and {{.RunId}}
+func splitOutputFormat(format string) (string, string) {
+	if i := strings.Index(format, "="); i != -1 {
+		return format[:i], format[i+1:]
+	}
+
+	return format, ""
+}
+
+// writeText writes the result as human-readable lines
+func writeText(w io.Writer, result *Result) error {
+	if result.RunId != "" {
+		if _, err := fmt.Fprintf(w, "run %s\n", result.RunId); err != nil {
+			return err
+		}
+	}
+
+	for _, version := range result.Versions {
+		if _, err := fmt.Fprintf(w, "version %q with id %q\n", version.Name, version.Id); err != nil {
+			return err
+		}
+	}
+
+	for _, step := range result.Steps {
+		line := fmt.Sprintf("%s: %s", step.Description, step.Status)
+
+		if step.Error != "" {
+			line += ": " + step.Error
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	for _, issue := range result.SkippedIssues {
+		if _, err := fmt.Fprintf(w, "skipped issue %s\n", issue); err != nil {
+			return err
+		}
+	}
+
+	for _, warning := range result.Warnings {
+		if _, err := fmt.Fprintf(w, "warning: %s\n", warning); err != nil {
+			return err
+		}
+	}
+
+	for _, permission := range result.Permissions {
+		line := fmt.Sprintf("permission %s in project %s: granted", permission.Name, permission.Project)
+
+		if !permission.Granted {
+			line = fmt.Sprintf("permission %s in project %s: missing, needed to %s", permission.Name, permission.Project, permission.NeededFor)
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeTable writes the result as aligned tables, separated by empty lines, with the same fields as writeText
+func writeTable(w io.Writer, result *Result) error {
+	// The first row of every table is its header
+	var tables [][][]string
+
+	if result.RunId != "" {
+		tables = append(tables, [][]string{{"RUN"}, {result.RunId}})
+	}
+
+	if len(result.Versions) != 0 {
+		rows := [][]string{{"VERSION", "ID", "RELEASED", "ARCHIVED", "SELF"}}
+
+		for _, v := range result.Versions {
+			rows = append(rows, []string{v.Name, v.Id, strconv.FormatBool(v.Released), strconv.FormatBool(v.Archived), v.Self})
+		}
+
+		tables = append(tables, rows)
+	}
+
+	if len(result.Steps) != 0 {
+		rows := [][]string{{"STEP", "ISSUE", "STATUS", "ERROR"}}
+
+		for _, s := range result.Steps {
+			rows = append(rows, []string{s.Description, s.Issue, s.Status, s.Error})
+		}
+
+		tables = append(tables, rows)
+	}
+
+	if len(result.SkippedIssues) != 0 {
+		rows := [][]string{{"SKIPPED ISSUE"}}
+
+		for _, issue := range result.SkippedIssues {
+			rows = append(rows, []string{issue})
+		}
+
+		tables = append(tables, rows)
+	}
+
+	if len(result.Warnings) != 0 {
+		rows := [][]string{{"WARNING"}}
+
+		for _, warning := range result.Warnings {
+			rows = append(rows, []string{warning})
+		}
+
+		tables = append(tables, rows)
+	}
+
+	if len(result.Permissions) != 0 {
+		rows := [][]string{{"PROJECT", "PERMISSION", "KEY", "GRANTED", "NEEDED TO"}}
+
+		for _, p := range result.Permissions {
+			rows = append(rows, []string{p.Project, p.Name, p.Key, strconv.FormatBool(p.Granted), p.NeededFor})
+		}
+
+		tables = append(tables, rows)
+	}
+
+	for i, rows := range tables {
+		if i != 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+		for _, row := range rows {
+			_, _ = fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package pkg
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var testResult = &Result{
+	Versions: []Version{{Self: "https://test.nu/rest/api/2/version/10000", Id: "10000", Name: "1.0.0", Released: true}},
+	Steps: []StepResult{
+		{Step: "create-version:MB:1.0.0", Description: "create version \"1.0.0\" in project MB", Status: StepCompleted},
+		{Step: "assign-version:MB-1:1.0.0", Description: "assign version \"1.0.0\" to MB-1", Issue: "MB-1", Status: StepFailed, Error: "not found"},
+	},
+}
+
+func TestWriteResult_text(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, WriteResult(&b, OutputText, testResult))
+	assert.Equal(t, `version "1.0.0" with id "10000"
+create version "1.0.0" in project MB: completed
+assign version "1.0.0" to MB-1: failed: not found
+`, b.String())
+}
+
+func TestWriteResult_json(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, WriteResult(&b, OutputJSON, &Result{RunId: "run", SkippedIssues: []string{"OPS-1"}}))
+	assert.Equal(t, `{
+  "runId": "run",
+  "skippedIssues": [
+    "OPS-1"
+  ]
+}
+`, b.String())
+}
+
+func TestWriteResult_yaml(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, WriteResult(&b, OutputYAML, &Result{Steps: testResult.Steps[1:]}))
+	assert.Equal(t, `steps:
+  - step: assign-version:MB-1:1.0.0
+    description: assign version "1.0.0" to MB-1
+    issue: MB-1
+    status: failed
+    error: not found
+`, b.String())
+}
+
+func TestWriteResult_table(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, WriteResult(&b, OutputTable, testResult))
+	assert.Equal(t, `VERSION  ID     RELEASED  ARCHIVED  SELF
+1.0.0    10000  true      false     https://test.nu/rest/api/2/version/10000
+
+STEP                                  ISSUE  STATUS     ERROR
+create version "1.0.0" in project MB         completed  
+assign version "1.0.0" to MB-1        MB-1   failed     not found
+`, b.String())
+}
+
+func TestWriteResult_permissions(t *testing.T) {
+	result := &Result{Permissions: []PermissionResult{
+		{Project: "MB", Key: PermissionEditIssues, Name: "Edit Issues", Granted: true, NeededFor: "change the versions and fields of issues"},
+		{Project: "MB", Key: PermissionAdministerProjects, Name: "Administer Projects", NeededFor: "create, release, archive, merge or delete versions"},
+	}}
+
+	var b bytes.Buffer
+	assert.NoError(t, WriteResult(&b, OutputText, result))
+	assert.Equal(t, `permission Edit Issues in project MB: granted
+permission Administer Projects in project MB: missing, needed to create, release, archive, merge or delete versions
+`, b.String())
+
+	b.Reset()
+	assert.NoError(t, WriteResult(&b, OutputTable, result))
+	assert.Equal(t, `PROJECT  PERMISSION           KEY                  GRANTED  NEEDED TO
+MB       Edit Issues          EDIT_ISSUES          true     change the versions and fields of issues
+MB       Administer Projects  ADMINISTER_PROJECTS  false    create, release, archive, merge or delete versions
+`, b.String())
+}
+
+func TestWriteResult_tableWarnings(t *testing.T) {
+	result := &Result{
+		RunId:         "20220301-1",
+		Steps:         testResult.Steps[:1],
+		SkippedIssues: []string{"OPS-1"},
+		Warnings:      []string{`version "1.0.0" is already released`},
+		Permissions: []PermissionResult{
+			{Project: "MB", Key: PermissionAdministerProjects, Name: "Administer Projects", NeededFor: "create, release, archive, merge or delete versions"},
+		},
+	}
+
+	var b bytes.Buffer
+	assert.NoError(t, WriteResult(&b, OutputTable, result))
+	assert.Equal(t, `RUN
+20220301-1
+
+STEP                                  ISSUE  STATUS     ERROR
+create version "1.0.0" in project MB         completed  
+
+SKIPPED ISSUE
+OPS-1
+
+WARNING
+version "1.0.0" is already released
+
+PROJECT  PERMISSION           KEY                  GRANTED  NEEDED TO
+MB       Administer Projects  ADMINISTER_PROJECTS  false    create, release, archive, merge or delete versions
+`, b.String())
+}
+
+func TestWriteResult_template(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, WriteResult(&b, "template={{range .Versions}}{{.Id}} {{.Self}}{{end}}", testResult))
+	assert.Equal(t, "10000 https://test.nu/rest/api/2/version/10000", b.String())
+
+	err := WriteResult(&b, "template={{.Unknown}}", testResult)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "could not render output template")
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	for _, format := range []string{"", "text", "json", "yaml", "table", "template={{.RunId}}"} {
+		assert.NoError(t, ValidateOutputFormat(format), format)
+	}
+
+	assert.EqualError(t, ValidateOutputFormat("xml"), `unknown output format "xml", use text, json, yaml, table or template=<go template>`)
+	err := ValidateOutputFormat("template={{.RunId")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid output template")
+	assert.Error(t, WriteResult(&bytes.Buffer{}, "xml", testResult))
+}
@@ -0,0 +1,44 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const searchPageSize = 100
+
+// SearchIssues returns the keys of all issues matching the provided JQL query
+func (c *JiraClient) SearchIssues(jql string) ([]string, error) {
+	return c.SearchIssuesContext(context.Background(), jql)
+}
+
+// SearchIssuesContext is SearchIssues with a context which cancels the requests
+func (c *JiraClient) SearchIssuesContext(ctx context.Context, jql string) ([]string, error) {
+	var keys []string
+	it := c.NewSearchPaginator(jql, "key").WithPageSize(searchPageSize).Iterate(ctx)
+	defer it.Close()
+
+	for it.Next() {
+		var issue struct {
+			Key string `json:"key"`
+		}
+
+		if err := it.Decode(&issue); err != nil {
+			return nil, fmt.Errorf("could not search issues: %w", err)
+		}
+
+		keys = append(keys, issue.Key)
+	}
+
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("could not search issues: %w", err)
+	}
+
+	return keys, nil
+}
+
+// quoteJQL quotes the provided value, so it can be used as a string in a JQL query
+func quoteJQL(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
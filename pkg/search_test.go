@@ -0,0 +1,49 @@
+package pkg
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestQuoteJQL(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"1.0.0", `"1.0.0"`},
+		{"", `""`},
+		{`Backend "1.0.0"`, `"Backend \"1.0.0\""`},
+		{`C:\releases`, `"C:\\releases"`},
+		{`\"`, `"\\\""`},
+		{`" OR project = OPS`, `"\" OR project = OPS"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			assert.Equal(t, test.expected, quoteJQL(test.value))
+		})
+	}
+}
+
+func TestJiraClient_SearchIssues_pages(t *testing.T) {
+	var requested []int
+	client := newPagedMockClient(t, &requested, func(startAt, maxResults int) string {
+		var issues []string
+
+		for i := startAt; i < startAt+maxResults && i < 150; i++ {
+			issues = append(issues, fmt.Sprintf(`{"key":"MB-%d"}`, i+1))
+		}
+
+		return fmt.Sprintf(`{"startAt":%d,"maxResults":%d,"total":150,"issues":[%s]}`, startAt, maxResults, strings.Join(issues, ","))
+	})
+
+	keys, err := client.SearchIssues(`project = "MB"`)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 100}, requested)
+	assert.Len(t, keys, 150)
+	assert.Equal(t, "MB-1", keys[0])
+	assert.Equal(t, "MB-101", keys[100])
+	assert.Equal(t, "MB-150", keys[149])
+}
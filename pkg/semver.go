@@ -0,0 +1,151 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// semVerPattern matches a semantic version as described on https://semver.org
+var semVerPattern = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+	`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+// SemVer holds the parts of a semantic version
+type SemVer struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Build      string
+}
+
+// ParseSemVer parses a semantic version, e.g. 1.4.0, 1.4.0-rc.1 or 1.4.0+build.5. A leading v, as used in tags, is
+// ignored.
+func ParseSemVer(version string) (SemVer, error) {
+	match := semVerPattern.FindStringSubmatch(strings.TrimPrefix(version, "v"))
+
+	if match == nil {
+		return SemVer{}, fmt.Errorf("invalid semantic version %q, expected MAJOR.MINOR.PATCH with an optional -prerelease and +build, e.g. 1.4.0 or v1.4.0-rc.1", version)
+	}
+
+	parts := make([]int, 3)
+
+	for i := range parts {
+		n, err := strconv.Atoi(match[i+1])
+
+		if err != nil {
+			return SemVer{}, fmt.Errorf("invalid semantic version %q: %w", version, err)
+		}
+
+		parts[i] = n
+	}
+
+	return SemVer{Major: parts[0], Minor: parts[1], Patch: parts[2], Prerelease: match[4], Build: match[5]}, nil
+}
+
+// String returns the version without a leading v
+func (v SemVer) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+
+	return s
+}
+
+// IsPrerelease reports whether the version is a pre-release, e.g. 1.4.0-rc.1
+func (v SemVer) IsPrerelease() bool {
+	return v.Prerelease != ""
+}
+
+// IsPrereleaseName reports whether the version name contains a semantic version which is a pre-release, e.g.
+// "Backend 1.4.0-rc.1"
+func IsPrereleaseName(name string) bool {
+	version, _, _, err := splitVersionName(name)
+	return err == nil && version.IsPrerelease()
+}
+
+// VersionTemplateData holds the values available in a version template
+type VersionTemplateData struct {
+	// Name is the name of the mapping, when the version is created for a mapping
+	Name    string
+	Project string
+	// Tag is the version as provided, e.g. v1.4.0-rc.1
+	Tag string
+	// Version is the normalised semantic version, e.g. 1.4.0-rc.1
+	Version    string
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Build      string
+	// Date is the current date, e.g. 2022-01-31
+	Date string
+}
+
+// NewVersionTemplateData strips the prefix from the tag and parses the remainder as a semantic version
+func NewVersionTemplateData(tag, prefix, project string) (*VersionTemplateData, error) {
+	version, err := ParseSemVer(strings.TrimPrefix(tag, prefix))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &VersionTemplateData{
+		Project:    project,
+		Tag:        tag,
+		Version:    version.String(),
+		Major:      version.Major,
+		Minor:      version.Minor,
+		Patch:      version.Patch,
+		Prerelease: version.Prerelease,
+		Build:      version.Build,
+		Date:       getDateString(),
+	}, nil
+}
+
+// Render renders the version template, e.g. "Backend {{.Version}}". The normalised version is returned when the
+// template is empty.
+func (d *VersionTemplateData) Render(versionTemplate string) (string, error) {
+	if versionTemplate == "" {
+		return d.Version, nil
+	}
+
+	tmpl, err := template.New("version").Option("missingkey=error").Parse(versionTemplate)
+
+	if err != nil {
+		return "", fmt.Errorf("invalid version template: %w", err)
+	}
+
+	var name bytes.Buffer
+
+	if err = tmpl.Execute(&name, d); err != nil {
+		return "", fmt.Errorf("could not render version template: %w", err)
+	}
+
+	if strings.TrimSpace(name.String()) == "" {
+		return "", fmt.Errorf("version template %q renders an empty version name", versionTemplate)
+	}
+
+	return name.String(), nil
+}
+
+// VersionName strips the prefix from the tag, validates it as a semantic version and renders the version template
+func VersionName(versionTemplate, tag, prefix, project string) (string, error) {
+	data, err := NewVersionTemplateData(tag, prefix, project)
+
+	if err != nil {
+		return "", err
+	}
+
+	return data.Render(versionTemplate)
+}
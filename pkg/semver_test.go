@@ -0,0 +1,85 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseSemVer(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected SemVer
+	}{
+		{"1.4.0", SemVer{Major: 1, Minor: 4}},
+		{"v10.20.30", SemVer{Major: 10, Minor: 20, Patch: 30}},
+		{"1.4.0-rc.1", SemVer{Major: 1, Minor: 4, Prerelease: "rc.1"}},
+		{"1.4.0-rc.1+build.5", SemVer{Major: 1, Minor: 4, Prerelease: "rc.1", Build: "build.5"}},
+		{"1.4.0+20220131", SemVer{Major: 1, Minor: 4, Build: "20220131"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.version, func(t *testing.T) {
+			version, err := ParseSemVer(test.version)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, version)
+		})
+	}
+}
+
+func TestParseSemVer_invalid(t *testing.T) {
+	for _, version := range []string{"", "1.4", "1.4.0.1", "01.4.0", "1.4.0-", "1.4.0-rc..1", "Backend 1.4.0", "version1.4.0"} {
+		t.Run(version, func(t *testing.T) {
+			_, err := ParseSemVer(version)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid semantic version")
+		})
+	}
+}
+
+func TestSemVer_String(t *testing.T) {
+	assert.Equal(t, "1.4.0", SemVer{Major: 1, Minor: 4}.String())
+	assert.Equal(t, "1.4.0-rc.1+build.5", SemVer{Major: 1, Minor: 4, Prerelease: "rc.1", Build: "build.5"}.String())
+	assert.True(t, SemVer{Prerelease: "rc.1"}.IsPrerelease())
+	assert.False(t, SemVer{Major: 1}.IsPrerelease())
+}
+
+func TestIsPrereleaseName(t *testing.T) {
+	assert.True(t, IsPrereleaseName("1.4.0-rc.1"))
+	assert.True(t, IsPrereleaseName("Backend v1.4.0-beta (pre-release)"))
+	assert.False(t, IsPrereleaseName("Backend 1.4.0"))
+	assert.False(t, IsPrereleaseName("1.4.0+build.5"))
+	assert.False(t, IsPrereleaseName("Sprint 5"))
+}
+
+func TestVersionName(t *testing.T) {
+	name, err := VersionName("Backend {{.Major}}.{{.Minor}}.{{.Patch}}", "v1.4.0", "", "MB")
+	assert.NoError(t, err)
+	assert.Equal(t, "Backend 1.4.0", name)
+
+	name, err = VersionName("", "release-v1.4.0-rc.1", "release-", "MB")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.4.0-rc.1", name)
+
+	name, err = VersionName("{{.Project}} {{.Version}}{{if .Prerelease}} (pre-release){{end}} {{.Tag}}", "v1.4.0-rc.1", "", "MB")
+	assert.NoError(t, err)
+	assert.Equal(t, "MB 1.4.0-rc.1 (pre-release) v1.4.0-rc.1", name)
+
+	name, err = VersionName("{{.Date}}", "1.4.0", "", "MB")
+	assert.NoError(t, err)
+	assert.Equal(t, getDateString(), name)
+
+	_, err = VersionName("{{.Version}}", "release-1.4.0", "", "MB")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid semantic version "release-1.4.0"`)
+
+	_, err = VersionName("{{.Version", "1.4.0", "", "MB")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid version template")
+
+	_, err = VersionName("{{.Missing}}", "1.4.0", "", "MB")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "could not render version template")
+
+	_, err = VersionName("{{if .Prerelease}}rc{{end}}", "1.4.0", "", "MB")
+	assert.EqualError(t, err, `version template "{{if .Prerelease}}rc{{end}}" renders an empty version name`)
+}
@@ -0,0 +1,629 @@
+package pkg
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Step is a single mutation against the Jira API which is executed as part of an Operation
+type Step interface {
+	// Id uniquely identifies the step within an operation
+	Id() string
+	// Description describes the step in a human-readable way
+	Description() string
+	// Do performs the mutation, the context cancels the requests of the step
+	Do(ctx context.Context, client *JiraClient) error
+	// Undo reverts the mutation performed by Do
+	Undo(ctx context.Context, client *JiraClient) error
+}
+
+// Operation executes steps in order and keeps track of the completed steps. When the operation is transactional, the
+// completed steps are undone in reverse order when a step fails. When the operation has a journal, completed steps
+// are recorded in it and steps which were completed in an earlier attempt of the run are skipped.
+type Operation struct {
+	client        *JiraClient
+	transactional bool
+	journal       *Journal
+	completed     []Step
+	// completedResults holds the index in results of every completed step
+	completedResults []int
+	steps            []Step
+	results          []StepResult
+	warnings         []string
+	skippedIssues    []string
+}
+
+// NewOperation creates an operation which executes its steps with the provided client
+func NewOperation(client *JiraClient, transactional bool) *Operation {
+	return &Operation{client: client, transactional: transactional}
+}
+
+// SetJournal sets the journal in which the operation records its completed steps
+func (o *Operation) SetJournal(journal *Journal) {
+	o.journal = journal
+}
+
+// Completed returns the steps which have been completed by the operation
+func (o *Operation) Completed() []Step {
+	return o.completed
+}
+
+// SkipIssues records issues which are left out of the operation on purpose, so they are reported in the result
+func (o *Operation) SkipIssues(reason string, issues ...string) {
+	for _, issue := range issues {
+		o.client.emit(Event{Type: EventIssueSkipped, Issue: issue, Reason: reason})
+		o.client.log().Info("skipping issue", "issue", issue, "reason", reason)
+	}
+
+	o.skippedIssues = append(o.skippedIssues, issues...)
+}
+
+// Run executes the provided steps in order and stops at the first step that fails. If the operation is transactional,
+// the steps completed so far are rolled back and a *RollbackError is returned. The outcome of every step is available
+// through Result.
+func (o *Operation) Run(steps []Step) error {
+	return o.RunContext(context.Background(), steps)
+}
+
+// RunContext is Run with a context. When the context is cancelled, the step in progress fails with the error of the
+// context and the remaining steps are not run. The completed steps of a transactional operation are still rolled back,
+// because the rollback does not use the cancelled context.
+func (o *Operation) RunContext(ctx context.Context, steps []Step) error {
+	for i, step := range steps {
+		if o.journal != nil {
+			completed, err := o.journal.IsCompleted(step)
+
+			if err != nil {
+				o.skipRemaining(steps[i:])
+				return err
+			}
+
+			if completed {
+				o.client.log().Info("skipping step, already completed", "step", step.Description(), "run", o.journal.RunId())
+
+				if s, ok := step.(issueStep); ok {
+					o.client.emit(Event{Type: EventIssueSkipped, Issue: s.issue(), Reason: "already completed in run " + o.journal.RunId()})
+				}
+
+				o.complete(step, StepSkipped)
+				continue
+			}
+		}
+
+		err := ctx.Err()
+
+		if err == nil {
+			err = step.Do(ctx, o.client)
+		}
+
+		if err != nil {
+			o.record(step, StepFailed, err)
+			o.skipRemaining(steps[i+1:])
+
+			if o.transactional {
+				return o.rollback(step, err)
+			}
+
+			return err
+		}
+
+		o.complete(step, StepCompleted)
+
+		if o.journal != nil {
+			if err := o.journal.RecordCompleted(step); err != nil {
+				o.skipRemaining(steps[i+1:])
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Result returns the outcome of the steps run by the operation and the versions created or changed by the steps
+// which were completed
+func (o *Operation) Result() *Result {
+	result := &Result{Steps: o.results, SkippedIssues: o.skippedIssues, Warnings: o.warnings}
+
+	if o.journal != nil {
+		result.RunId = o.journal.RunId()
+	}
+
+	for i, step := range o.steps {
+		status := o.results[i].Status
+
+		if status != StepCompleted && status != StepSkipped {
+			continue
+		}
+
+		if s, ok := step.(versionStep); ok && s.version() != nil {
+			result.Versions = append(result.Versions, *s.version())
+		}
+	}
+
+	return result
+}
+
+// record adds the outcome of the step to the results and returns its index
+func (o *Operation) record(step Step, status string, err error) int {
+	o.steps = append(o.steps, step)
+	o.results = append(o.results, newStepResult(step, status, err))
+	return len(o.results) - 1
+}
+
+// complete records the step as completed
+func (o *Operation) complete(step Step, status string) {
+	o.completed = append(o.completed, step)
+	o.completedResults = append(o.completedResults, o.record(step, status, nil))
+}
+
+// skipRemaining records the steps which were not run because an earlier step failed
+func (o *Operation) skipRemaining(steps []Step) {
+	for _, step := range steps {
+		o.record(step, StepNotRun, nil)
+	}
+}
+
+// rollback undoes the completed steps in reverse order. It stops at the first step which cannot be undone, because the
+// earlier steps may be needed by its changes, e.g. the version into which another version was merged, so they are kept.
+func (o *Operation) rollback(failed Step, err error) error {
+	rollbackErr := &RollbackError{Err: err, Failed: failed}
+
+	for i := len(o.completed) - 1; i >= 0; i-- {
+		step := o.completed[i]
+		result := &o.results[o.completedResults[i]]
+
+		if undoErr := step.Undo(context.Background(), o.client); undoErr != nil {
+			o.client.log().Error("could not roll back step", "step", step.Description(), "error", undoErr)
+			rollbackErr.NotRolledBack = append(rollbackErr.NotRolledBack, RollbackFailure{Step: step, Err: undoErr})
+			result.Status, result.Error = StepNotRolledBack, undoErr.Error()
+
+			for j := i - 1; j >= 0; j-- {
+				o.client.log().Warn("kept step", "step", o.completed[j].Description())
+				rollbackErr.Kept = append(rollbackErr.Kept, o.completed[j])
+			}
+
+			break
+		}
+
+		o.client.log().Info("rolled back step", "step", step.Description())
+		rollbackErr.RolledBack = append(rollbackErr.RolledBack, step)
+		result.Status = StepRolledBack
+
+		if o.journal != nil {
+			if journalErr := o.journal.RecordUndone(step); journalErr != nil {
+				warning := fmt.Sprintf("could not record rollback of %s: %s", step.Description(), journalErr)
+				o.client.log().Warn(warning)
+				o.warnings = append(o.warnings, warning)
+			}
+		}
+	}
+
+	o.completed = nil
+	o.completedResults = nil
+	return rollbackErr
+}
+
+// RollbackFailure holds a step which could not be undone and the reason why
+type RollbackFailure struct {
+	Step Step
+	Err  error
+}
+
+// RollbackError is returned by a transactional operation when one of its steps failed. It contains the steps which
+// were rolled back, the step which could not be rolled back and the earlier steps which were kept because of it.
+type RollbackError struct {
+	Err           error
+	Failed        Step
+	RolledBack    []Step
+	NotRolledBack []RollbackFailure
+	Kept          []Step
+}
+
+func (e *RollbackError) Error() string {
+	if notRolledBack := len(e.NotRolledBack) + len(e.Kept); notRolledBack != 0 {
+		return fmt.Sprintf("%s failed, %d of %d completed steps could not be rolled back: %s", e.Failed.Description(), notRolledBack, len(e.RolledBack)+notRolledBack, e.Err)
+	}
+
+	return fmt.Sprintf("%s failed, all completed steps were rolled back: %s", e.Failed.Description(), e.Err)
+}
+
+func (e *RollbackError) Unwrap() error {
+	return e.Err
+}
+
+// Report returns a human-readable report of the rollback
+func (e *RollbackError) Report() string {
+	var b strings.Builder
+
+	_, _ = fmt.Fprintf(&b, "%s failed: %s\n", e.Failed.Description(), e.Err)
+
+	if len(e.RolledBack) == 0 && len(e.NotRolledBack) == 0 {
+		b.WriteString("no steps were completed, nothing to roll back\n")
+		return b.String()
+	}
+
+	for _, step := range e.RolledBack {
+		_, _ = fmt.Fprintf(&b, "rolled back: %s\n", step.Description())
+	}
+
+	for _, failure := range e.NotRolledBack {
+		_, _ = fmt.Fprintf(&b, "could not roll back: %s: %s\n", failure.Step.Description(), failure.Err)
+	}
+
+	for _, step := range e.Kept {
+		_, _ = fmt.Fprintf(&b, "kept: %s\n", step.Description())
+	}
+
+	return b.String()
+}
+
+// CreateVersionStep creates a released fix version in a project, or an unreleased one when Unreleased is true or the
+// version is a pre-release. Undoing the step deletes the created version.
+type CreateVersionStep struct {
+	Name       string
+	Project    string
+	Unreleased bool
+	Version    *Version
+}
+
+func (s *CreateVersionStep) Id() string {
+	return fmt.Sprintf("create-version:%s:%s", s.Project, s.Name)
+}
+
+func (s *CreateVersionStep) Description() string {
+	return fmt.Sprintf("create version %q in project %s", s.Name, s.Project)
+}
+
+func (s *CreateVersionStep) Do(ctx context.Context, client *JiraClient) error {
+	version, err := client.createFixVersion(ctx, s.Name, s.Project, !s.Unreleased)
+
+	if err != nil {
+		return err
+	}
+
+	s.Version = version
+	return nil
+}
+
+func (s *CreateVersionStep) Undo(ctx context.Context, client *JiraClient) error {
+	if s.Version == nil {
+		return fmt.Errorf("the id of version %q is unknown", s.Name)
+	}
+
+	return client.DeleteVersionContext(ctx, s.Version.Id)
+}
+
+func (s *CreateVersionStep) version() *Version {
+	return s.Version
+}
+
+func (s *CreateVersionStep) MarshalState() ([]byte, error) {
+	return json.Marshal(s.Version)
+}
+
+func (s *CreateVersionStep) UnmarshalState(data []byte) error {
+	return json.Unmarshal(data, &s.Version)
+}
+
+// AssignVersionStep adds a fix version to an issue and sets the resolved field values. Undoing the step removes the
+// fix version from the issue again, the field values are left as they are.
+type AssignVersionStep struct {
+	Issue   string
+	Version string
+	Fields  map[string]interface{}
+}
+
+func (s *AssignVersionStep) Id() string {
+	return fmt.Sprintf("assign-version:%s:%s", s.Issue, s.Version)
+}
+
+func (s *AssignVersionStep) Description() string {
+	return fmt.Sprintf("assign version %q to %s", s.Version, s.Issue)
+}
+
+func (s *AssignVersionStep) Do(ctx context.Context, client *JiraClient) error {
+	if err := client.assignVersion(ctx, s.Issue, s.Version, s.Fields); err != nil {
+		return fmt.Errorf("error occurred while assign version to issue %s: %w", s.Issue, err)
+	}
+
+	return nil
+}
+
+func (s *AssignVersionStep) issue() string {
+	return s.Issue
+}
+
+func (s *AssignVersionStep) setIssue(key string) {
+	s.Issue = key
+}
+
+func (s *AssignVersionStep) Undo(ctx context.Context, client *JiraClient) error {
+	body, err := newUpdateRequestBody(VersionChange{Remove: []string{s.Version}}, VersionChange{}, nil)
+
+	if err != nil {
+		return err
+	}
+
+	return client.updateIssue(ctx, s.Issue, body)
+}
+
+// UpdateIssueStep adds, removes or replaces the versions of an issue and sets the resolved field values. Undoing the
+// step removes the added versions and adds the removed versions again. Replaced versions and field values cannot be
+// undone.
+type UpdateIssueStep struct {
+	Issue           string
+	FixVersions     VersionChange
+	AffectsVersions VersionChange
+	Fields          map[string]interface{}
+}
+
+// Id contains a digest of the changes, so a resumed run with other changes does not skip the step
+func (s *UpdateIssueStep) Id() string {
+	return fmt.Sprintf("update-issue:%s:%s", s.Issue, s.digest())
+}
+
+// digest returns a short, stable hash of the version changes and field values of the step
+func (s *UpdateIssueStep) digest() string {
+	// The keys of the fields are sorted by json.Marshal, so the same changes always have the same digest
+	data, err := json.Marshal(struct {
+		FixVersions     VersionChange
+		AffectsVersions VersionChange
+		Fields          map[string]interface{}
+	}{s.FixVersions, s.AffectsVersions, s.Fields})
+
+	if err != nil {
+		data = []byte(fmt.Sprintf("%+v", *s))
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+func (s *UpdateIssueStep) Description() string {
+	return fmt.Sprintf("update issue %s", s.Issue)
+}
+
+func (s *UpdateIssueStep) Do(ctx context.Context, client *JiraClient) error {
+	body, err := newUpdateRequestBody(s.FixVersions, s.AffectsVersions, s.Fields)
+
+	if err != nil {
+		return fmt.Errorf("could not create update issue request body: %w", err)
+	}
+
+	if err = client.updateIssue(ctx, s.Issue, body); err != nil {
+		return fmt.Errorf("error occurred while updating issue %s: %w", s.Issue, err)
+	}
+
+	client.issueUpdated(s.Issue)
+	return nil
+}
+
+func (s *UpdateIssueStep) issue() string {
+	return s.Issue
+}
+
+func (s *UpdateIssueStep) setIssue(key string) {
+	s.Issue = key
+}
+
+func (s *UpdateIssueStep) Undo(ctx context.Context, client *JiraClient) error {
+	if len(s.FixVersions.Set) != 0 || len(s.AffectsVersions.Set) != 0 {
+		return fmt.Errorf("replaced versions of issue %s cannot be restored", s.Issue)
+	}
+
+	fixVersions := VersionChange{Add: s.FixVersions.Remove, Remove: s.FixVersions.Add}
+	affectsVersions := VersionChange{Add: s.AffectsVersions.Remove, Remove: s.AffectsVersions.Add}
+
+	if len(fixVersions.Add)+len(fixVersions.Remove)+len(affectsVersions.Add)+len(affectsVersions.Remove) == 0 {
+		return fmt.Errorf("field values of issue %s cannot be restored", s.Issue)
+	}
+
+	body, err := newUpdateRequestBody(fixVersions, affectsVersions, nil)
+
+	if err != nil {
+		return err
+	}
+
+	return client.updateIssue(ctx, s.Issue, body)
+}
+
+// UnassignVersionStep removes a fix version from an issue. Undoing the step adds the fix version to the issue again.
+type UnassignVersionStep struct {
+	Issue   string
+	Version string
+}
+
+func (s *UnassignVersionStep) Id() string {
+	return fmt.Sprintf("unassign-version:%s:%s", s.Issue, s.Version)
+}
+
+func (s *UnassignVersionStep) Description() string {
+	return fmt.Sprintf("remove version %q from %s", s.Version, s.Issue)
+}
+
+func (s *UnassignVersionStep) Do(ctx context.Context, client *JiraClient) error {
+	body, err := newUpdateRequestBody(VersionChange{Remove: []string{s.Version}}, VersionChange{}, nil)
+
+	if err != nil {
+		return fmt.Errorf("could not create unassign version request body: %w", err)
+	}
+
+	if err = client.updateIssue(ctx, s.Issue, body); err != nil {
+		return fmt.Errorf("error occurred while removing version from issue %s: %w", s.Issue, err)
+	}
+
+	client.issueUpdated(s.Issue)
+	return nil
+}
+
+func (s *UnassignVersionStep) Undo(ctx context.Context, client *JiraClient) error {
+	return client.assignVersion(ctx, s.Issue, s.Version, nil)
+}
+
+func (s *UnassignVersionStep) issue() string {
+	return s.Issue
+}
+
+func (s *UnassignVersionStep) setIssue(key string) {
+	s.Issue = key
+}
+
+// UnreleaseVersionStep marks a version as unreleased. The version is looked up by name when the step is performed.
+// Undoing the step releases the version again with today as release date.
+type UnreleaseVersionStep struct {
+	Name    string
+	Project string
+	Version *Version
+}
+
+func (s *UnreleaseVersionStep) Id() string {
+	return fmt.Sprintf("unrelease-version:%s:%s", s.Project, s.Name)
+}
+
+func (s *UnreleaseVersionStep) Description() string {
+	return fmt.Sprintf("mark version %q in project %s as unreleased", s.Name, s.Project)
+}
+
+func (s *UnreleaseVersionStep) Do(ctx context.Context, client *JiraClient) error {
+	version, err := client.FindVersionContext(ctx, s.Project, s.Name)
+
+	if err != nil {
+		return err
+	}
+
+	if err = client.UnreleaseVersionContext(ctx, version.Id); err != nil {
+		return err
+	}
+
+	version.Released = false
+	s.Version = version
+	return nil
+}
+
+func (s *UnreleaseVersionStep) Undo(ctx context.Context, client *JiraClient) error {
+	if s.Version == nil {
+		return fmt.Errorf("the id of version %q is unknown", s.Name)
+	}
+
+	return client.ReleaseVersionContext(ctx, s.Version.Id)
+}
+
+func (s *UnreleaseVersionStep) version() *Version {
+	return s.Version
+}
+
+func (s *UnreleaseVersionStep) MarshalState() ([]byte, error) {
+	return json.Marshal(s.Version)
+}
+
+func (s *UnreleaseVersionStep) UnmarshalState(data []byte) error {
+	return json.Unmarshal(data, &s.Version)
+}
+
+// DeleteVersionStep deletes a version. The version is looked up by name when the step is performed. Deleting a
+// version cannot be undone.
+type DeleteVersionStep struct {
+	Name    string
+	Project string
+}
+
+func (s *DeleteVersionStep) Id() string {
+	return fmt.Sprintf("delete-version:%s:%s", s.Project, s.Name)
+}
+
+func (s *DeleteVersionStep) Description() string {
+	return fmt.Sprintf("delete version %q from project %s", s.Name, s.Project)
+}
+
+func (s *DeleteVersionStep) Do(ctx context.Context, client *JiraClient) error {
+	version, err := client.FindVersionContext(ctx, s.Project, s.Name)
+
+	if err != nil {
+		return err
+	}
+
+	return client.DeleteVersionContext(ctx, version.Id)
+}
+
+func (s *DeleteVersionStep) Undo(ctx context.Context, client *JiraClient) error {
+	return fmt.Errorf("deleted version %q cannot be restored", s.Name)
+}
+
+// CreateAndAssignSteps returns the steps to create the version in the project and to assign it to the provided issues
+// and the issues extracted from the release body with the default pattern, see JiraClient.ExtractIssues
+func CreateAndAssignSteps(version, project, releaseBody string, issues []string, filter []string, fields map[string]interface{}) []Step {
+	steps := []Step{&CreateVersionStep{Name: version, Project: project}}
+	return append(steps, AssignVersionSteps(version, releaseBody, issues, filter, fields)...)
+}
+
+// AssignVersionSteps returns the steps to assign the version to the provided issues and the issues extracted from the
+// release body with the default pattern, see JiraClient.ExtractIssues
+func AssignVersionSteps(version, releaseBody string, issues []string, filter []string, fields map[string]interface{}) []Step {
+	var steps []Step
+
+	for _, issue := range collectIssues(nil, releaseBody, issues, filter) {
+		steps = append(steps, &AssignVersionStep{Issue: issue, Version: version, Fields: fields})
+	}
+
+	return steps
+}
+
+// UpdateIssueSteps resolves the fields of the update and returns the steps to apply the update to the provided issues
+// and the issues extracted from the release body
+func UpdateIssueSteps(ctx context.Context, client *JiraClient, releaseBody string, issues []string, filter []string, update IssueUpdate) ([]Step, error) {
+	values, err := client.ResolveFieldValuesContext(ctx, update.Fields)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve fields: %w", err)
+	}
+
+	if _, err = newUpdateRequestBody(update.FixVersions, update.AffectsVersions, values); err != nil {
+		return nil, fmt.Errorf("could not create update issue request body: %w", err)
+	}
+
+	var steps []Step
+
+	for _, issue := range collectIssues(client, releaseBody, issues, filter) {
+		steps = append(steps, &UpdateIssueStep{
+			Issue:           issue,
+			FixVersions:     update.FixVersions,
+			AffectsVersions: update.AffectsVersions,
+			Fields:          values,
+		})
+	}
+
+	return steps, nil
+}
+
+// UnassignVersionSteps returns the steps to remove the fix version from the provided issues and the issues extracted
+// from the release body. When search is true, the issues in the project which have the fix version are included.
+func UnassignVersionSteps(ctx context.Context, client *JiraClient, releaseBody, version, project string, issues []string, filter []string, search bool) ([]Step, error) {
+	if version == "" {
+		return nil, errors.New("version cannot be empty")
+	}
+
+	if search {
+		jql := fmt.Sprintf("project = %s AND fixVersion = %s", quoteJQL(project), quoteJQL(version))
+		found, err := client.SearchIssuesContext(ctx, jql)
+
+		if err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, found...)
+	}
+
+	var steps []Step
+
+	for _, issue := range collectIssues(client, releaseBody, issues, filter) {
+		steps = append(steps, &UnassignVersionStep{Issue: issue, Version: version})
+	}
+
+	return steps, nil
+}
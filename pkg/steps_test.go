@@ -0,0 +1,228 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"github.com/marcelblijleven/jira-helper/pkg/jiratest"
+	"github.com/marcelblijleven/jira-helper/pkg/jiratest/testserver"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+// newStepsMockClient returns a mock http client which records all requests and fails the requests for which fail
+// returns true
+func newStepsMockClient(calledWith *[]string, fail func(req *http.Request) bool) mockHttpClientFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		data, _ := ioutil.ReadAll(req.Body)
+		*calledWith = append(*calledWith, req.Method+" "+req.URL.Path+" "+string(data))
+
+		if fail(req) {
+			return newMockResponse(http.StatusBadRequest, "{\"errorMessages\":[],\"errors\":{\"name\":\"Something went wrong.\"}}"), nil
+		}
+
+		if req.Method == http.MethodPost {
+			return newMockResponse(http.StatusCreated, "{\"id\":\"10000\",\"name\":\"1.0.0\"}"), nil
+		}
+
+		return newMockResponse(http.StatusNoContent, ""), nil
+	}
+}
+
+func TestOperation_Run(t *testing.T) {
+	var calledWith []string
+	client, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", newStepsMockClient(&calledWith, func(req *http.Request) bool {
+		return false
+	}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operation := NewOperation(client, true)
+	err = operation.Run(CreateAndAssignSteps("1.0.0", "MB", "MB-1 and MB-2", nil, nil, nil))
+	assert.NoError(t, err)
+	assert.Len(t, operation.Completed(), 3)
+	assert.Equal(t, "10000", operation.Completed()[0].(*CreateVersionStep).Version.Id)
+	assert.Len(t, calledWith, 3)
+
+	result := operation.Result()
+	assert.Equal(t, []Version{{Id: "10000", Name: "1.0.0"}}, result.Versions)
+	assert.Equal(t, []StepResult{
+		{Step: "create-version:MB:1.0.0", Description: "create version \"1.0.0\" in project MB", Status: StepCompleted},
+		{Step: "assign-version:MB-1:1.0.0", Description: "assign version \"1.0.0\" to MB-1", Issue: "MB-1", Status: StepCompleted},
+		{Step: "assign-version:MB-2:1.0.0", Description: "assign version \"1.0.0\" to MB-2", Issue: "MB-2", Status: StepCompleted},
+	}, result.Steps)
+}
+
+func TestOperation_Run_notTransactional(t *testing.T) {
+	var calledWith []string
+	client, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", newStepsMockClient(&calledWith, func(req *http.Request) bool {
+		return req.URL.Path == "/rest/api/latest/issue/MB-2"
+	}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operation := NewOperation(client, false)
+	err = operation.Run(CreateAndAssignSteps("1.0.0", "MB", "MB-1 and MB-2", nil, nil, nil))
+	assert.EqualError(t, err, "error occurred while assign version to issue MB-2: request unsuccessful (400 Bad Request): name: Something went wrong.")
+	assert.Len(t, operation.Completed(), 2)
+	assert.Len(t, calledWith, 3)
+
+	operation.SkipIssues("no mapping", "OPS-1")
+	result := operation.Result()
+	assert.Equal(t, []string{StepCompleted, StepCompleted, StepFailed}, stepStatuses(result))
+	assert.Equal(t, "error occurred while assign version to issue MB-2: request unsuccessful (400 Bad Request): name: Something went wrong.", result.Steps[2].Error)
+	assert.Equal(t, []string{"OPS-1"}, result.SkippedIssues)
+}
+
+func TestOperation_Run_rollback(t *testing.T) {
+	var calledWith []string
+	client, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", newStepsMockClient(&calledWith, func(req *http.Request) bool {
+		return req.URL.Path == "/rest/api/latest/issue/MB-3"
+	}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operation := NewOperation(client, true)
+	err = operation.Run(CreateAndAssignSteps("1.0.0", "MB", "MB-1, MB-2 and MB-3", nil, nil, nil))
+
+	var rollbackErr *RollbackError
+	assert.True(t, errors.As(err, &rollbackErr))
+	assert.Len(t, rollbackErr.RolledBack, 3)
+	assert.Empty(t, rollbackErr.NotRolledBack)
+	assert.Empty(t, operation.Completed())
+	assert.EqualError(t, err, "assign version \"1.0.0\" to MB-3 failed, all completed steps were rolled back: error occurred while assign version to issue MB-3: request unsuccessful (400 Bad Request): name: Something went wrong.")
+	assert.Equal(t, []string{
+		"POST /rest/api/latest/version {\"name\":\"1.0.0\",\"released\":true,\"releaseDate\":\"" + getDateString() + "\",\"project\":\"MB\"}",
+		"PUT /rest/api/latest/issue/MB-1 {\"update\":{\"fixVersions\":[{\"add\":{\"name\":\"1.0.0\"}}]}}",
+		"PUT /rest/api/latest/issue/MB-2 {\"update\":{\"fixVersions\":[{\"add\":{\"name\":\"1.0.0\"}}]}}",
+		"PUT /rest/api/latest/issue/MB-3 {\"update\":{\"fixVersions\":[{\"add\":{\"name\":\"1.0.0\"}}]}}",
+		"PUT /rest/api/latest/issue/MB-2 {\"update\":{\"fixVersions\":[{\"remove\":{\"name\":\"1.0.0\"}}]}}",
+		"PUT /rest/api/latest/issue/MB-1 {\"update\":{\"fixVersions\":[{\"remove\":{\"name\":\"1.0.0\"}}]}}",
+		"DELETE /rest/api/latest/version/10000 ",
+	}, calledWith)
+
+	result := operation.Result()
+	assert.Empty(t, result.Versions)
+	assert.Equal(t, []string{StepRolledBack, StepRolledBack, StepRolledBack, StepFailed}, stepStatuses(result))
+}
+
+func TestOperation_Run_rollbackJiratest(t *testing.T) {
+	server := testserver.New(t)
+	server.AddVersion("MB", jiratest.Version{Name: "0.9.0"})
+	server.AddIssue(jiratest.Issue{Key: "MB-1", FixVersions: []string{"0.9.0"}})
+	server.AddIssue(jiratest.Issue{Key: "MB-2"})
+	server.AddIssue(jiratest.Issue{Key: "MB-3"})
+	server.Fail(http.MethodPut, "/issue/MB-3", http.StatusTooManyRequests, 1)
+	client, err := NewJiraClient(server.URL, "marcel@test.nl", "c0ffee", http.DefaultClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewOperation(client, true).Run(CreateAndAssignSteps("1.0.0", "MB", "MB-1, MB-2 and MB-3", nil, nil, nil))
+	assert.True(t, IsRateLimited(err))
+
+	server.AssertNoVersion("MB", "1.0.0")
+	server.AssertFixVersions("MB-1", "0.9.0")
+	server.AssertFixVersions("MB-2")
+	server.AssertFixVersions("MB-3")
+}
+
+func TestOperation_Run_rollbackFailure(t *testing.T) {
+	var calledWith []string
+	client, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", newStepsMockClient(&calledWith, func(req *http.Request) bool {
+		return req.URL.Path == "/rest/api/latest/issue/MB-2" || req.Method == http.MethodDelete
+	}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operation := NewOperation(client, true)
+	err = operation.Run(CreateAndAssignSteps("1.0.0", "MB", "MB-1 and MB-2", nil, nil, nil))
+
+	var rollbackErr *RollbackError
+	assert.True(t, errors.As(err, &rollbackErr))
+	assert.Equal(t, `assign version "1.0.0" to MB-2 failed: error occurred while assign version to issue MB-2: request unsuccessful (400 Bad Request): name: Something went wrong.
+rolled back: assign version "1.0.0" to MB-1
+could not roll back: create version "1.0.0" in project MB: could not delete version 10000: request unsuccessful (400 Bad Request): name: Something went wrong.
+`, rollbackErr.Report())
+
+	result := operation.Result()
+	assert.Equal(t, []string{StepNotRolledBack, StepRolledBack, StepFailed}, stepStatuses(result))
+	assert.Equal(t, "could not delete version 10000: request unsuccessful (400 Bad Request): name: Something went wrong.", result.Steps[0].Error)
+}
+
+func TestOperation_RunContext_cancelled(t *testing.T) {
+	var calledWith []string
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mock := newStepsMockClient(&calledWith, func(req *http.Request) bool {
+		return false
+	})
+	client, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", mockHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/rest/api/latest/issue/MB-2" {
+			cancel()
+			return nil, req.Context().Err()
+		}
+
+		return mock(req)
+	}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operation := NewOperation(client, true)
+	err = operation.RunContext(ctx, CreateAndAssignSteps("1.0.0", "MB", "MB-1, MB-2 and MB-3", nil, nil, nil))
+
+	var rollbackErr *RollbackError
+	assert.True(t, errors.As(err, &rollbackErr))
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Len(t, rollbackErr.RolledBack, 2)
+	assert.Equal(t, []string{
+		"POST /rest/api/latest/version {\"name\":\"1.0.0\",\"released\":true,\"releaseDate\":\"" + getDateString() + "\",\"project\":\"MB\"}",
+		"PUT /rest/api/latest/issue/MB-1 {\"update\":{\"fixVersions\":[{\"add\":{\"name\":\"1.0.0\"}}]}}",
+		"PUT /rest/api/latest/issue/MB-1 {\"update\":{\"fixVersions\":[{\"remove\":{\"name\":\"1.0.0\"}}]}}",
+		"DELETE /rest/api/latest/version/10000 ",
+	}, calledWith)
+	assert.Equal(t, []string{StepRolledBack, StepRolledBack, StepFailed, StepNotRun}, stepStatuses(operation.Result()))
+}
+
+func TestOperation_RunContext_cancelledBeforeStart(t *testing.T) {
+	var calledWith []string
+	client, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", newStepsMockClient(&calledWith, func(req *http.Request) bool {
+		return false
+	}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	operation := NewOperation(client, false)
+	err = operation.RunContext(ctx, CreateAndAssignSteps("1.0.0", "MB", "MB-1", nil, nil, nil))
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Empty(t, calledWith)
+	assert.Equal(t, []string{StepFailed, StepNotRun}, stepStatuses(operation.Result()))
+}
+
+// stepStatuses returns the status of every step in the result
+func stepStatuses(result *Result) []string {
+	var statuses []string
+
+	for _, step := range result.Steps {
+		statuses = append(statuses, step.Status)
+	}
+
+	return statuses
+}
@@ -4,42 +4,75 @@ package pkg
 type releaseRequestBody struct {
 	Name        string `json:"name"`
 	Released    bool   `json:"released"`
-	ReleaseDate string `json:"releaseDate"`
+	ReleaseDate string `json:"releaseDate,omitempty"`
 	Project     string `json:"project"`
 }
 
-// assignRequestBody represents the Jira assign fixVersion API request body
-type assignRequestBody struct {
-	Update update `json:"update"`
+// updateRequestBody represents the Jira edit issue API request body
+type updateRequestBody struct {
+	Update update                 `json:"update"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
 }
 
+// update holds the operations for the version fields of an issue
 type update struct {
-	FixVersions []fixVersion `json:"fixVersions"`
+	FixVersions []versionOperation `json:"fixVersions,omitempty"`
+	Versions    []versionOperation `json:"versions,omitempty"`
 }
 
-type fixVersion struct {
-	Add addFixVersion `json:"add"`
+// versionOperation represents a single add, remove or set operation on a version field
+type versionOperation struct {
+	Add    *versionReference  `json:"add,omitempty"`
+	Remove *versionReference  `json:"remove,omitempty"`
+	Set    []versionReference `json:"set,omitempty"`
 }
 
-type addFixVersion struct {
+// versionReference references a version by its name
+type versionReference struct {
 	Name string `json:"name"`
 }
 
-type JiraError struct {
-	ErrorMessages []interface{} `json:"errorMessages"`
-	Errors        struct {
-		Name string `json:"name"`
-	} `json:"errors"`
+// VersionChange describes how the versions in a version field (fix versions or affects versions) of an issue should
+// change. Set replaces all versions in the field and cannot be combined with Add or Remove.
+type VersionChange struct {
+	Add    []string
+	Remove []string
+	Set    []string
 }
 
-// createResponse represents the response from the Jira api when calling the create fix version endpoint
-type createResponse struct {
-	Self            string `json:"self"`
-	Id              string `json:"id"`
-	Name            string `json:"name"`
-	Archived        bool   `json:"archived"`
-	Released        bool   `json:"released"`
-	ReleaseDate     string `json:"releaseDate"`
-	UserReleaseDate string `json:"userReleaseDate"`
-	ProjectId       int    `json:"projectId"`
+// IssueUpdate describes the changes to apply to an issue
+type IssueUpdate struct {
+	FixVersions     VersionChange
+	AffectsVersions VersionChange
+	Fields          map[string]string
+}
+
+// Version represents a (fix) version as returned by the Jira version endpoints
+type Version struct {
+	Self            string `json:"self" yaml:"self"`
+	Id              string `json:"id" yaml:"id"`
+	Name            string `json:"name" yaml:"name"`
+	Description     string `json:"description,omitempty" yaml:"description,omitempty"`
+	Archived        bool   `json:"archived" yaml:"archived"`
+	Released        bool   `json:"released" yaml:"released"`
+	ReleaseDate     string `json:"releaseDate" yaml:"releaseDate"`
+	UserReleaseDate string `json:"userReleaseDate" yaml:"userReleaseDate"`
+	ProjectId       int    `json:"projectId" yaml:"projectId"`
+}
+
+// versionUpdateRequestBody represents the Jira update version API request body
+type versionUpdateRequestBody struct {
+	Released    *bool  `json:"released,omitempty"`
+	Archived    *bool  `json:"archived,omitempty"`
+	ReleaseDate string `json:"releaseDate,omitempty"`
+}
+
+// searchRequestBody represents the Jira search API request body
+type searchRequestBody struct {
+	Jql        string   `json:"jql"`
+	StartAt    int      `json:"startAt"`
+	MaxResults int      `json:"maxResults"`
+	Fields     []string `json:"fields"`
+	// ValidateQuery is strict, warn or none. With warn, unknown issue keys are reported as warnings instead of errors.
+	ValidateQuery string `json:"validateQuery,omitempty"`
 }
@@ -0,0 +1,394 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redacted replaces the values of sensitive headers and secrets in a trace
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders are the headers of which the value is never traced
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// TraceEntry holds a request made by the client and its response. Status is 0 and Err is set when no response was
+// received.
+type TraceEntry struct {
+	Start           time.Time
+	Duration        time.Duration
+	Method          string
+	URL             string
+	RequestHeaders  http.Header
+	RequestBody     []byte
+	Status          int
+	ResponseHeaders http.Header
+	ResponseBody    []byte
+	Err             error
+}
+
+// Tracer receives every request made by the client together with its response. The sensitive headers and secrets are
+// redacted before the entry is passed to the tracer.
+type Tracer interface {
+	Trace(entry TraceEntry) error
+}
+
+// SetTracer sets the tracer which receives every request and response. The provided secrets, e.g. the token, are
+// redacted from the urls, headers and bodies.
+func (c *JiraClient) SetTracer(tracer Tracer, secrets ...string) {
+	c.tracer = tracer
+	c.secrets = secrets
+}
+
+// trace passes the request and its response to the tracer. The response body is read to trace it, so it is replaced
+// with a copy which can be read again.
+func (c *JiraClient) trace(req *http.Request, res *http.Response, err error, start time.Time) {
+	entry := TraceEntry{
+		Start:          start,
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestHeaders: req.Header,
+		RequestBody:    requestBody(req),
+		Err:            err,
+	}
+
+	if res != nil {
+		data, readErr := ioutil.ReadAll(res.Body)
+		_ = res.Body.Close()
+		res.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+		entry.Status, entry.ResponseHeaders, entry.ResponseBody = res.StatusCode, res.Header, data
+
+		if readErr != nil {
+			entry.Err = readErr
+		}
+	}
+
+	entry.Duration = time.Since(start)
+
+	if traceErr := c.tracer.Trace(redactTraceEntry(entry, c.secrets)); traceErr != nil {
+		c.log().Warn("could not write trace", "error", traceErr)
+	}
+}
+
+// requestBody returns a copy of the body of the request
+func requestBody(req *http.Request) []byte {
+	if req.GetBody == nil {
+		return nil
+	}
+
+	body, err := req.GetBody()
+
+	if err != nil {
+		return nil
+	}
+
+	defer body.Close()
+	data, _ := ioutil.ReadAll(body)
+	return data
+}
+
+// redactTraceEntry returns a copy of the entry in which the sensitive headers and the secrets are redacted
+func redactTraceEntry(entry TraceEntry, secrets []string) TraceEntry {
+	entry.URL = redactText(entry.URL, secrets)
+	entry.RequestHeaders = redactHeaders(entry.RequestHeaders, secrets)
+	entry.RequestBody = []byte(redactText(string(entry.RequestBody), secrets))
+	entry.ResponseHeaders = redactHeaders(entry.ResponseHeaders, secrets)
+	entry.ResponseBody = []byte(redactText(string(entry.ResponseBody), secrets))
+
+	if entry.Err != nil {
+		entry.Err = errors.New(redactText(entry.Err.Error(), secrets))
+	}
+
+	return entry
+}
+
+// redactHeaders returns a copy of the headers in which the values of the sensitive headers and the secrets are
+// redacted
+func redactHeaders(headers http.Header, secrets []string) http.Header {
+	if headers == nil {
+		return nil
+	}
+
+	redactedHeaders := make(http.Header, len(headers))
+
+	for name, values := range headers {
+		for _, value := range values {
+			redactedHeaders.Add(name, redactText(value, secrets))
+		}
+	}
+
+	for _, name := range sensitiveHeaders {
+		if redactedHeaders.Get(name) != "" {
+			redactedHeaders.Set(name, redacted)
+		}
+	}
+
+	return redactedHeaders
+}
+
+// oauth2FormFields matches the values of the OAuth 2.0 secrets in form encoded bodies and query strings
+var oauth2FormFields = regexp.MustCompile(`(^|[?&])(access_token|refresh_token|code|code_verifier|client_secret)=[^&\s]*`)
+
+// oauth2JSONFields matches the values of the OAuth 2.0 secrets in json bodies, e.g. the response of the token endpoint
+var oauth2JSONFields = regexp.MustCompile(`"(access_token|refresh_token|code_verifier|client_secret)"(\s*):(\s*)"[^"]*"`)
+
+// redactText replaces every occurrence of the secrets and the values of the OAuth 2.0 token fields in the text
+func redactText(text string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, redacted)
+		}
+	}
+
+	text = oauth2FormFields.ReplaceAllString(text, "${1}${2}="+redacted)
+	return oauth2JSONFields.ReplaceAllString(text, `"${1}"${2}:${3}"`+redacted+`"`)
+}
+
+// TextTracer writes every request and response in a human-readable format
+type TextTracer struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// NewTextTracer creates a tracer which writes to w
+func NewTextTracer(w io.Writer) *TextTracer {
+	return &TextTracer{w: w}
+}
+
+func (t *TextTracer) Trace(entry TraceEntry) error {
+	var b strings.Builder
+
+	_, _ = fmt.Fprintf(&b, "--> %s %s\n", entry.Method, entry.URL)
+	writeTraceHeaders(&b, entry.RequestHeaders)
+	writeTraceBody(&b, entry.RequestBody)
+
+	if entry.Status == 0 {
+		_, _ = fmt.Fprintf(&b, "<-- failed (%s): %s\n\n", entry.Duration.Round(time.Millisecond), entry.Err)
+	} else {
+		_, _ = fmt.Fprintf(&b, "<-- %d %s (%s)\n", entry.Status, http.StatusText(entry.Status), entry.Duration.Round(time.Millisecond))
+		writeTraceHeaders(&b, entry.ResponseHeaders)
+		writeTraceBody(&b, entry.ResponseBody)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err := io.WriteString(t.w, b.String())
+	return err
+}
+
+// writeTraceHeaders writes the headers sorted by name
+func writeTraceHeaders(b *strings.Builder, headers http.Header) {
+	names := make([]string, 0, len(headers))
+
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		_, _ = fmt.Fprintf(b, "%s: %s\n", name, strings.Join(headers[name], ", "))
+	}
+}
+
+// writeTraceBody writes the body after an empty line, which marks the end of the headers
+func writeTraceBody(b *strings.Builder, body []byte) {
+	b.WriteString("\n")
+
+	if len(body) != 0 {
+		b.Write(body)
+		b.WriteString("\n\n")
+	}
+}
+
+// HARTracer records every request and response in a HAR file, which can be opened in the network tab of the browser
+// developer tools. The file is written after every request, so it is complete when the command is interrupted.
+type HARTracer struct {
+	path    string
+	entries []harEntry
+	mu      sync.Mutex
+}
+
+// NewHARTracer creates a tracer which writes the HAR file to the provided path
+func NewHARTracer(path string) *HARTracer {
+	return &HARTracer{path: path}
+}
+
+func (t *HARTracer) Trace(entry TraceEntry) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.entries = append(t.entries, newHAREntry(entry))
+
+	data, err := json.MarshalIndent(harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "jira-helper", Version: ""},
+		Entries: t.entries,
+	}}, "", "  ")
+
+	if err != nil {
+		return fmt.Errorf("could not marshall har file: %w", err)
+	}
+
+	if err = ioutil.WriteFile(t.path, data, 0600); err != nil {
+		return fmt.Errorf("could not write har file: %w", err)
+	}
+
+	return nil
+}
+
+// harFile and the types below are the subset of the HAR 1.2 format which is written by the HARTracer
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// newHAREntry converts the trace entry to a HAR entry. The whole duration is reported as waiting time.
+func newHAREntry(entry TraceEntry) harEntry {
+	milliseconds := float64(entry.Duration) / float64(time.Millisecond)
+	har := harEntry{
+		StartedDateTime: entry.Start.Format(time.RFC3339Nano),
+		Time:            milliseconds,
+		Request: harRequest{
+			Method:      entry.Method,
+			URL:         entry.URL,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(entry.RequestHeaders),
+			QueryString: harQueryString(entry.URL),
+			HeadersSize: -1,
+			BodySize:    len(entry.RequestBody),
+		},
+		Response: harResponse{
+			Status:      entry.Status,
+			StatusText:  http.StatusText(entry.Status),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(entry.ResponseHeaders),
+			Content: harContent{
+				Size:     len(entry.ResponseBody),
+				MimeType: entry.ResponseHeaders.Get("Content-Type"),
+				Text:     string(entry.ResponseBody),
+			},
+			HeadersSize: -1,
+			BodySize:    len(entry.ResponseBody),
+		},
+		Timings: harTimings{Send: 0, Wait: milliseconds, Receive: 0},
+	}
+
+	if len(entry.RequestBody) != 0 {
+		har.Request.PostData = &harPostData{MimeType: entry.RequestHeaders.Get("Content-Type"), Text: string(entry.RequestBody)}
+	}
+
+	if entry.Err != nil {
+		har.Error = entry.Err.Error()
+	}
+
+	return har
+}
+
+// harHeaders converts the headers to HAR name value pairs, sorted by name
+func harHeaders(headers http.Header) []harNameValue {
+	values := []harNameValue{}
+
+	for name, headerValues := range headers {
+		for _, value := range headerValues {
+			values = append(values, harNameValue{Name: name, Value: value})
+		}
+	}
+
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].Name < values[j].Name
+	})
+
+	return values
+}
+
+// harQueryString returns the query parameters of the url as HAR name value pairs, sorted by name
+func harQueryString(rawURL string) []harNameValue {
+	values := []harNameValue{}
+	u, err := url.Parse(rawURL)
+
+	if err != nil {
+		return values
+	}
+
+	return append(values, harHeaders(http.Header(u.Query()))...)
+}
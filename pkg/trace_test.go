@@ -0,0 +1,136 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// tracerFunc allows a function to be used as a Tracer
+type tracerFunc func(entry TraceEntry) error
+
+func (f tracerFunc) Trace(entry TraceEntry) error {
+	return f(entry)
+}
+
+func TestJiraClient_SetTracer(t *testing.T) {
+	mockClient := NewMockHttpClient(t, 400)
+	jiraClient, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", mockClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var entries []TraceEntry
+	jiraClient.SetTracer(tracerFunc(func(entry TraceEntry) error {
+		entries = append(entries, entry)
+		return nil
+	}), "c0ffee", "MB")
+
+	_, err = jiraClient.CreateFixVersion("1.0.0", "MB")
+	assert.EqualError(t, err, "could not create fix version: request unsuccessful (Bad request): name: A version with this name already exists in this project.")
+
+	assert.Len(t, entries, 1)
+	assert.Equal(t, http.MethodPost, entries[0].Method)
+	assert.Equal(t, "https://test.nu/rest/api/latest/version", entries[0].URL)
+	assert.Equal(t, redacted, entries[0].RequestHeaders.Get("Authorization"))
+	assert.Contains(t, string(entries[0].RequestBody), `"project":"[REDACTED]"`)
+	assert.Equal(t, 400, entries[0].Status)
+	assert.Contains(t, string(entries[0].ResponseBody), "A version with this name already exists")
+}
+
+func TestRedactTraceEntry(t *testing.T) {
+	entry := redactTraceEntry(TraceEntry{
+		URL:            "https://test.nu/rest/api/latest/search?token=s3cret",
+		RequestHeaders: http.Header{"Authorization": {"Basic abc"}, "X-Token": {"s3cret"}},
+		RequestBody:    []byte(`{"secret":"s3cret"}`),
+		Err:            errors.New("could not send s3cret"),
+	}, []string{"s3cret", ""})
+
+	assert.Equal(t, "https://test.nu/rest/api/latest/search?token=[REDACTED]", entry.URL)
+	assert.Equal(t, http.Header{"Authorization": {redacted}, "X-Token": {redacted}}, entry.RequestHeaders)
+	assert.Equal(t, `{"secret":"[REDACTED]"}`, string(entry.RequestBody))
+	assert.EqualError(t, entry.Err, "could not send [REDACTED]")
+	assert.Nil(t, entry.ResponseHeaders)
+}
+
+func TestTextTracer_Trace(t *testing.T) {
+	var b bytes.Buffer
+	tracer := NewTextTracer(&b)
+
+	assert.NoError(t, tracer.Trace(TraceEntry{
+		Duration:        1500 * time.Microsecond,
+		Method:          http.MethodPut,
+		URL:             "https://test.nu/rest/api/latest/issue/MB-1",
+		RequestHeaders:  http.Header{"Content-Type": {"application/json"}, "Authorization": {redacted}},
+		RequestBody:     []byte(`{"update":{}}`),
+		Status:          400,
+		ResponseHeaders: http.Header{"Content-Type": {"application/json"}},
+		ResponseBody:    []byte(`{"errors":{"fixVersions":"invalid"}}`),
+	}))
+	assert.NoError(t, tracer.Trace(TraceEntry{
+		Duration: 2 * time.Millisecond,
+		Method:   http.MethodGet,
+		URL:      "https://test.nu/rest/api/latest/field",
+		Err:      errors.New("connection refused"),
+	}))
+
+	assert.Equal(t, `--> PUT https://test.nu/rest/api/latest/issue/MB-1
+Authorization: [REDACTED]
+Content-Type: application/json
+
+{"update":{}}
+
+<-- 400 Bad Request (2ms)
+Content-Type: application/json
+
+{"errors":{"fixVersions":"invalid"}}
+
+--> GET https://test.nu/rest/api/latest/field
+
+<-- failed (2ms): connection refused
+
+`, b.String())
+}
+
+func TestHARTracer_Trace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.har")
+	tracer := NewHARTracer(path)
+	start := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, tracer.Trace(TraceEntry{
+		Start:           start,
+		Duration:        25 * time.Millisecond,
+		Method:          http.MethodPost,
+		URL:             "https://test.nu/rest/api/latest/search?validate=true",
+		RequestHeaders:  http.Header{"Content-Type": {"application/json"}},
+		RequestBody:     []byte(`{"jql":"project = MB"}`),
+		Status:          200,
+		ResponseHeaders: http.Header{"Content-Type": {"application/json"}},
+		ResponseBody:    []byte(`{"issues":[]}`),
+	}))
+	assert.NoError(t, tracer.Trace(TraceEntry{Start: start, Method: http.MethodGet, URL: "https://test.nu", Err: errors.New("timeout")}))
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	var har harFile
+	assert.NoError(t, json.Unmarshal(data, &har))
+	assert.Equal(t, "1.2", har.Log.Version)
+	assert.Len(t, har.Log.Entries, 2)
+
+	entry := har.Log.Entries[0]
+	assert.Equal(t, "2022-03-01T12:00:00Z", entry.StartedDateTime)
+	assert.Equal(t, float64(25), entry.Time)
+	assert.Equal(t, []harNameValue{{Name: "validate", Value: "true"}}, entry.Request.QueryString)
+	assert.Equal(t, &harPostData{MimeType: "application/json", Text: `{"jql":"project = MB"}`}, entry.Request.PostData)
+	assert.Equal(t, "OK", entry.Response.StatusText)
+	assert.Equal(t, harContent{Size: 13, MimeType: "application/json", Text: `{"issues":[]}`}, entry.Response.Content)
+	assert.Equal(t, "timeout", har.Log.Entries[1].Error)
+}
@@ -0,0 +1,135 @@
+package pkg
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TransportConfig holds the proxy and TLS settings of the http client which is used to reach Jira
+type TransportConfig struct {
+	// Proxy is the url of the proxy to use. When empty, the proxy is taken from the HTTPS_PROXY, HTTP_PROXY and
+	// NO_PROXY environment variables.
+	Proxy string
+	// CACert is a PEM file with certificates which are trusted in addition to the system certificates
+	CACert string
+	// ClientCert and ClientKey are PEM files with the client certificate and its key, used for mutual TLS
+	ClientCert string
+	ClientKey  string
+	// MinTLSVersion is the minimum TLS version, e.g. 1.2
+	MinTLSVersion string
+	// Insecure disables the verification of the certificate of the server
+	Insecure bool
+}
+
+// tlsVersions maps the supported minimum TLS versions to their crypto/tls constant
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// NewHttpClient creates an http client with the transport built from the config, in which every request times out
+// after the provided duration
+func NewHttpClient(config TransportConfig, timeout time.Duration) (*http.Client, error) {
+	transport, err := config.Transport()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// Transport builds an http transport with the proxy and TLS settings of the config, based on the default transport
+func (c TransportConfig) Transport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+
+	if c.Proxy != "" {
+		proxy, err := url.Parse(c.Proxy)
+
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy url %q, expected e.g. http://proxy.example.com:8080", c.Proxy)
+		}
+
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	tlsConfig, err := c.tlsConfig()
+
+	if err != nil {
+		return nil, err
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// tlsConfig builds the TLS config with the CA certificates, client certificate and minimum TLS version of the config
+func (c TransportConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.Insecure}
+
+	if c.MinTLSVersion != "" {
+		version, ok := tlsVersions[c.MinTLSVersion]
+
+		if !ok {
+			return nil, fmt.Errorf("unsupported minimum TLS version %q, supported versions are 1.0, 1.1, 1.2 and 1.3", c.MinTLSVersion)
+		}
+
+		tlsConfig.MinVersion = version
+	}
+
+	if c.CACert != "" {
+		pool, err := loadCertPool(c.CACert)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.ClientCert != "" || c.ClientKey != "" {
+		if c.ClientCert == "" || c.ClientKey == "" {
+			return nil, errors.New("the client certificate and client key must be provided together")
+		}
+
+		certificate, err := tls.LoadX509KeyPair(c.ClientCert, c.ClientKey)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not load client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+// loadCertPool returns the system certificate pool with the certificates of the provided PEM file added to it
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read CA certificate: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,160 @@
+package pkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeServerCertificate writes the certificate of the test server to a PEM file and returns its path
+func writeServerCertificate(t *testing.T, server *httptest.Server) string {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// writeClientCertificate creates a self-signed client certificate, writes it and its key to PEM files and returns
+// the certificate and the paths of both files
+func writeClientCertificate(t *testing.T) (*x509.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "jira-helper"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+
+	if err = ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return certificate, certPath, keyPath
+}
+
+func TestTransportConfig_Transport_proxy(t *testing.T) {
+	transport, err := TransportConfig{Proxy: "http://proxy.example.com:8080"}.Transport()
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest(http.MethodGet, "https://test.nu", nil)
+	proxy, err := transport.Proxy(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://proxy.example.com:8080", proxy.String())
+
+	_, err = TransportConfig{Proxy: "proxy.example.com"}.Transport()
+	assert.EqualError(t, err, `invalid proxy url "proxy.example.com", expected e.g. http://proxy.example.com:8080`)
+}
+
+func TestTransportConfig_Transport_invalid(t *testing.T) {
+	_, err := TransportConfig{MinTLSVersion: "1.4"}.Transport()
+	assert.EqualError(t, err, `unsupported minimum TLS version "1.4", supported versions are 1.0, 1.1, 1.2 and 1.3`)
+
+	_, err = TransportConfig{ClientCert: "client.pem"}.Transport()
+	assert.EqualError(t, err, "the client certificate and client key must be provided together")
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("not a certificate"), 0600))
+	_, err = TransportConfig{CACert: path}.Transport()
+	assert.EqualError(t, err, "no certificates found in "+path)
+
+	transport, err := TransportConfig{MinTLSVersion: "1.3"}.Transport()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), transport.TLSClientConfig.MinVersion)
+}
+
+func TestNewHttpClient_caCert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client, err := NewHttpClient(TransportConfig{}, time.Second)
+	assert.NoError(t, err)
+	_, err = client.Get(server.URL)
+	assert.Error(t, err)
+
+	client, err = NewHttpClient(TransportConfig{CACert: writeServerCertificate(t, server)}, time.Second)
+	assert.NoError(t, err)
+	res, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	client, err = NewHttpClient(TransportConfig{Insecure: true}, time.Second)
+	assert.NoError(t, err)
+	res, err = client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+}
+
+func TestNewHttpClient_clientCert(t *testing.T) {
+	certificate, certPath, keyPath := writeClientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(certificate)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	caCert := writeServerCertificate(t, server)
+
+	client, err := NewHttpClient(TransportConfig{CACert: caCert}, time.Second)
+	assert.NoError(t, err)
+	_, err = client.Get(server.URL)
+	assert.Error(t, err)
+
+	client, err = NewHttpClient(TransportConfig{CACert: caCert, ClientCert: certPath, ClientKey: keyPath}, time.Second)
+	assert.NoError(t, err)
+	res, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+}
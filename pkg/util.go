@@ -1,11 +1,9 @@
 package pkg
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"regexp"
 	"time"
 )
@@ -15,8 +13,9 @@ func getDateString() string {
 	return time.Now().Format("2006-01-02")
 }
 
-// newReleaseRequestBody creates a release request body with the provided version name and project id
-func newReleaseRequestBody(versionName, projectID string) (*releaseRequestBody, error) {
+// newReleaseRequestBody creates a release request body with the provided version name and project id. A released
+// version is released today, an unreleased version has no release date.
+func newReleaseRequestBody(versionName, projectID string, released bool) (*releaseRequestBody, error) {
 	if versionName == "" {
 		return nil, errors.New("version versionName cannot be empty")
 	}
@@ -25,23 +24,83 @@ func newReleaseRequestBody(versionName, projectID string) (*releaseRequestBody,
 		return nil, errors.New("project ID cannot be empty")
 	}
 
-	return &releaseRequestBody{
-		Name:        versionName,
-		Released:    true,
-		ReleaseDate: getDateString(),
-		Project:     projectID,
-	}, nil
+	body := &releaseRequestBody{Name: versionName, Released: released, Project: projectID}
+
+	if released {
+		body.ReleaseDate = getDateString()
+	}
+
+	return body, nil
 }
 
-// newAssignRequestBody creates an assign fixVersion request body with the provided version
-func newAssignRequestBody(version string) (*assignRequestBody, error) {
+// newAssignRequestBody creates an assign fixVersion request body with the provided version and field values
+func newAssignRequestBody(version string, fields map[string]interface{}) (*updateRequestBody, error) {
 	if version == "" {
 		return nil, errors.New("version cannot be empty")
 	}
 
-	f := fixVersion{Add: addFixVersion{Name: version}}
-	b := &assignRequestBody{Update: update{FixVersions: []fixVersion{f}}}
-	return b, nil
+	return newUpdateRequestBody(VersionChange{Add: []string{version}}, VersionChange{}, fields)
+}
+
+// newUpdateRequestBody creates an edit issue request body with the provided version changes and field values
+func newUpdateRequestBody(fixVersions, affectsVersions VersionChange, fields map[string]interface{}) (*updateRequestBody, error) {
+	fixVersionOperations, err := newVersionOperations(fixVersions)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid fix versions: %w", err)
+	}
+
+	affectsVersionOperations, err := newVersionOperations(affectsVersions)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid affects versions: %w", err)
+	}
+
+	if fixVersionOperations == nil && affectsVersionOperations == nil && len(fields) == 0 {
+		return nil, errors.New("no changes provided")
+	}
+
+	return &updateRequestBody{
+		Update: update{FixVersions: fixVersionOperations, Versions: affectsVersionOperations},
+		Fields: fields,
+	}, nil
+}
+
+// newVersionOperations converts the version change into the operations for a version field
+func newVersionOperations(change VersionChange) ([]versionOperation, error) {
+	if len(change.Set) != 0 && (len(change.Add) != 0 || len(change.Remove) != 0) {
+		return nil, errors.New("replacing versions cannot be combined with adding or removing versions")
+	}
+
+	for _, names := range [][]string{change.Set, change.Add, change.Remove} {
+		for _, name := range names {
+			if name == "" {
+				return nil, errors.New("version cannot be empty")
+			}
+		}
+	}
+
+	if len(change.Set) != 0 {
+		var references []versionReference
+
+		for _, name := range removeDuplicates(change.Set) {
+			references = append(references, versionReference{Name: name})
+		}
+
+		return []versionOperation{{Set: references}}, nil
+	}
+
+	var operations []versionOperation
+
+	for _, name := range removeDuplicates(change.Add) {
+		operations = append(operations, versionOperation{Add: &versionReference{Name: name}})
+	}
+
+	for _, name := range removeDuplicates(change.Remove) {
+		operations = append(operations, versionOperation{Remove: &versionReference{Name: name}})
+	}
+
+	return operations, nil
 }
 
 // removeDuplicates can be used to filter out duplicates from the provided slice of string
@@ -92,41 +151,102 @@ func filterSlice(main []string, filter []string) []string {
 	return filtered
 }
 
-// extractIssuesFromText gathers all issue numbers from the provided text
-func extractIssuesFromText(text string) []string {
-	r := regexp.MustCompile("[A-Z]+-[0-9]+")
-	return r.FindAllString(text, -1)
+// defaultIssuePattern is the pattern used to find issue numbers in a body of text, unless the client has another one
+var defaultIssuePattern = regexp.MustCompile("[A-Z]+-[0-9]+")
+
+// SetIssuePattern sets the pattern used to find issue numbers in a release body. A nil pattern restores the default.
+func (c *JiraClient) SetIssuePattern(pattern *regexp.Regexp) {
+	c.issuePattern = pattern
+}
+
+// ExtractIssues gathers all issue numbers matching the issue pattern of the client from the provided text
+func (c *JiraClient) ExtractIssues(text string) []string {
+	pattern := defaultIssuePattern
+
+	if c != nil && c.issuePattern != nil {
+		pattern = c.issuePattern
+	}
+
+	return extractIssuesFromText(pattern, text)
+}
+
+// extractIssuesFromText gathers all issue numbers matching the pattern from the provided text
+func extractIssuesFromText(pattern *regexp.Regexp, text string) []string {
+	return pattern.FindAllString(text, -1)
 }
 
 // AssignVersions extracts the issues from  the provided release body and calls the AssignVersion endpoint of the
 // jira client.
-func AssignVersions(releaseBody, version string, client *JiraClient, issues []string, filter []string) error {
-	issues = append(issues, extractIssuesFromText(releaseBody)...)
-	issues = removeDuplicates(issues)
-	issues = filterSlice(issues, filter)
+func AssignVersions(releaseBody, version string, client *JiraClient, issues []string, filter []string) (*Result, error) {
+	return AssignVersionsContext(context.Background(), releaseBody, version, client, issues, filter)
+}
 
-	for _, issue := range issues {
-		if err := client.AssignVersion(issue, version); err != nil {
-			return fmt.Errorf("error occurred while assign version to issue %s: %w", issue, err)
-		}
+// AssignVersionsContext is AssignVersions with a context which cancels the requests
+func AssignVersionsContext(ctx context.Context, releaseBody, version string, client *JiraClient, issues []string, filter []string) (*Result, error) {
+	return AssignVersionsWithFieldsContext(ctx, releaseBody, version, client, issues, filter, nil)
+}
+
+// AssignVersionsWithFields behaves like AssignVersions, but also sets the provided fields on every issue. The fields
+// are resolved before any issue is updated, so an unknown field or invalid value does not result in a partial update.
+func AssignVersionsWithFields(releaseBody, version string, client *JiraClient, issues []string, filter []string, fields map[string]string) (*Result, error) {
+	return AssignVersionsWithFieldsContext(context.Background(), releaseBody, version, client, issues, filter, fields)
+}
+
+// AssignVersionsWithFieldsContext is AssignVersionsWithFields with a context which cancels the requests
+func AssignVersionsWithFieldsContext(ctx context.Context, releaseBody, version string, client *JiraClient, issues []string, filter []string, fields map[string]string) (*Result, error) {
+	values, err := client.ResolveFieldValuesContext(ctx, fields)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve fields: %w", err)
 	}
 
-	return nil
+	operation := NewOperation(client, false)
+	err = operation.RunContext(ctx, AssignVersionSteps(version, "", collectIssues(client, releaseBody, issues, filter), nil, values))
+	return operation.Result(), err
 }
 
-// handleJiraError retrieves and formats the error from the Jira api response
-func handleJiraError(res *http.Response) error {
-	var jiraError JiraError
-	data, readErr := ioutil.ReadAll(res.Body)
-	defer res.Body.Close()
+// UpdateIssues extracts the issues from the provided release body and applies the provided update to each of them
+// and the provided issues
+func UpdateIssues(releaseBody string, client *JiraClient, issues []string, filter []string, update IssueUpdate) (*Result, error) {
+	return UpdateIssuesContext(context.Background(), releaseBody, client, issues, filter, update)
+}
+
+// UpdateIssuesContext is UpdateIssues with a context which cancels the requests
+func UpdateIssuesContext(ctx context.Context, releaseBody string, client *JiraClient, issues []string, filter []string, update IssueUpdate) (*Result, error) {
+	steps, err := UpdateIssueSteps(ctx, client, releaseBody, issues, filter, update)
 
-	if readErr != nil {
-		return fmt.Errorf("request unsuccessful (%s), could not read response: %w", res.Status, readErr)
+	if err != nil {
+		return nil, err
 	}
 
-	if unmarshallErr := json.Unmarshal(data, &jiraError); unmarshallErr != nil {
-		return fmt.Errorf("request unsuccessful (%s), could not read response: %w", res.Status, unmarshallErr)
+	operation := NewOperation(client, false)
+	err = operation.RunContext(ctx, steps)
+	return operation.Result(), err
+}
+
+// UnassignVersions removes the fix version from the provided issues and the issues extracted from the release body.
+// When search is true, the fix version is also removed from all issues in the project which have the fix version.
+func UnassignVersions(releaseBody, version, project string, client *JiraClient, issues []string, filter []string, search bool) (*Result, error) {
+	return UnassignVersionsContext(context.Background(), releaseBody, version, project, client, issues, filter, search)
+}
+
+// UnassignVersionsContext is UnassignVersions with a context which cancels the requests
+func UnassignVersionsContext(ctx context.Context, releaseBody, version, project string, client *JiraClient, issues []string, filter []string, search bool) (*Result, error) {
+	steps, err := UnassignVersionSteps(ctx, client, releaseBody, version, project, issues, filter, search)
+
+	if err != nil {
+		return nil, err
 	}
 
-	return fmt.Errorf("request unsuccessful (%s): %s", res.Status, jiraError.Errors.Name)
+	operation := NewOperation(client, false)
+	err = operation.RunContext(ctx, steps)
+	return operation.Result(), err
+}
+
+// collectIssues combines the provided issues with the issues the client extracts from the release body and removes
+// duplicates and filtered issues. A nil client extracts the issues with the default pattern.
+func collectIssues(client *JiraClient, releaseBody string, issues []string, filter []string) []string {
+	issues = append(issues, client.ExtractIssues(releaseBody)...)
+	issues = removeDuplicates(issues)
+	return filterSlice(issues, filter)
 }
@@ -2,10 +2,13 @@ package pkg
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"regexp"
 	"testing"
 )
 
@@ -15,7 +18,7 @@ For changes in this version, see the changelog
 
 Merge commit that triggered this release: feat: marcel introduces c0ffee (MB-1337, HB-1338)`
 
-	result := extractIssuesFromText(body)
+	result := extractIssuesFromText(defaultIssuePattern, body)
 	assert.Equal(t, []string{"MB-1337", "HB-1338"}, result)
 }
 
@@ -25,10 +28,44 @@ For changes in this version, see the changelog
 
 Merge commit that triggered this release: feat: marcel introduces c0ffee (MB-1337, MB-1337)`
 
-	result := extractIssuesFromText(body)
+	result := extractIssuesFromText(defaultIssuePattern, body)
 	assert.Equal(t, []string{"MB-1337", "MB-1337"}, result)
 }
 
+func Test_newReleaseRequestBody(t *testing.T) {
+	body, err := newReleaseRequestBody("1.4.0", "MB", true)
+	assert.NoError(t, err)
+	assert.Equal(t, &releaseRequestBody{Name: "1.4.0", Released: true, ReleaseDate: getDateString(), Project: "MB"}, body)
+
+	body, err = newReleaseRequestBody("1.4.0-rc.1", "MB", false)
+	assert.NoError(t, err)
+	assert.Equal(t, &releaseRequestBody{Name: "1.4.0-rc.1", Project: "MB"}, body)
+
+	_, err = newReleaseRequestBody("", "MB", true)
+	assert.Error(t, err)
+}
+
+func TestJiraClient_ExtractIssues(t *testing.T) {
+	client, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", http.DefaultClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := "feat: marcel introduces c0ffee (MB-1337, MB2-7)"
+	assert.Equal(t, []string{"MB-1337"}, client.ExtractIssues(body))
+
+	client.SetIssuePattern(regexp.MustCompile("[A-Z][A-Z0-9]+-[0-9]+"))
+	assert.Equal(t, []string{"MB-1337", "MB2-7"}, client.ExtractIssues(body))
+
+	steps, err := UnassignVersionSteps(context.Background(), client, body, "1.0.0", "MB", nil, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"unassign-version:MB-1337:1.0.0", "unassign-version:MB2-7:1.0.0"}, stepIds(steps))
+
+	client.SetIssuePattern(nil)
+	assert.Equal(t, []string{"MB-1337"}, client.ExtractIssues(body))
+}
+
 func Test_removeDuplicates(t *testing.T) {
 	type args struct {
 		items []string
@@ -80,7 +117,7 @@ For changes in this version, see the changelog
 
 Merge commit that triggered this release: feat: marcel introduces c0ffee (MB-1337)`
 
-	err = AssignVersions(releaseBody, "My first version", jiraClient, nil, nil)
+	_, err = AssignVersions(releaseBody, "My first version", jiraClient, nil, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, mockClient.CalledTimes)
 	assert.Equal(t, "{\"update\":{\"fixVersions\":[{\"add\":{\"name\":\"My first version\"}}]}}", mockClient.CalledWith[0])
@@ -99,7 +136,7 @@ For changes in this version, see the changelog
 
 Merge commit that triggered this release: feat: marcel introduces c0ffee (MB-1337, MB-1338)`
 
-	err = AssignVersions(releaseBody, "My first version", jiraClient, nil, nil)
+	_, err = AssignVersions(releaseBody, "My first version", jiraClient, nil, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, 2, mockClient.CalledTimes)
 	assert.Equal(t, []string{
@@ -116,7 +153,7 @@ func TestAssignVersions_singleIssue(t *testing.T) {
 		log.Fatalln(err)
 	}
 
-	err = AssignVersions("", "My first version", jiraClient, []string{"MB-1234"}, nil)
+	_, err = AssignVersions("", "My first version", jiraClient, []string{"MB-1234"}, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, mockClient.CalledTimes)
 	assert.Equal(t, []string{
@@ -124,6 +161,23 @@ func TestAssignVersions_singleIssue(t *testing.T) {
 	}, mockClient.CalledWith)
 }
 
+func TestAssignVersionsContext_cancelled(t *testing.T) {
+	mockClient := NewMockHttpClient(t, 201)
+	jiraClient, err := NewJiraClient("https://test.nu", "marcel@test.nu", "c0ffee", mockClient)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := AssignVersionsContext(ctx, "", "My first version", jiraClient, []string{"MB-1234", "MB-1235"}, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, mockClient.CalledTimes)
+	assert.Len(t, result.Steps, 2)
+}
+
 func TestAssignVersions_duplicateIssue(t *testing.T) {
 	mockClient := NewMockHttpClient(t, 201)
 	jiraClient, err := NewJiraClient("https://test.nu", "marcel@test.nu", "c0ffee", mockClient)
@@ -132,7 +186,7 @@ func TestAssignVersions_duplicateIssue(t *testing.T) {
 		log.Fatalln(err)
 	}
 
-	err = AssignVersions("", "My first version", jiraClient, []string{"MB-1234", "MB-1234"}, nil)
+	_, err = AssignVersions("", "My first version", jiraClient, []string{"MB-1234", "MB-1234"}, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, mockClient.CalledTimes)
 	assert.Equal(t, []string{
@@ -153,7 +207,7 @@ For changes in this version, see the changelog
 
 Merge commit that triggered this release: feat: marcel introduces c0ffee (MB-1337, MB-1338)`
 
-	err = AssignVersions(releaseBody, "My first version", jiraClient, []string{"MB-1339", "MB-1340"}, nil)
+	_, err = AssignVersions(releaseBody, "My first version", jiraClient, []string{"MB-1339", "MB-1340"}, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, 4, mockClient.CalledTimes)
 	assert.Equal(t, []string{
@@ -177,7 +231,7 @@ For changes in this version, see the changelog
 
 Merge commit that triggered this release: feat: marcel introduces c0ffee (MB-1337, MB-1338)`
 
-	err = AssignVersions(releaseBody, "My first version", jiraClient, []string{"MB-1337", "MB-1338"}, nil)
+	_, err = AssignVersions(releaseBody, "My first version", jiraClient, []string{"MB-1337", "MB-1338"}, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, 2, mockClient.CalledTimes)
 	assert.Equal(t, []string{
@@ -190,14 +244,14 @@ func Test_handleJiraError(t *testing.T) {
 	body := bytes.NewReader([]byte("{\"errorMessages\":[],\"errors\":{\"name\":\"A version with this name already exists in this project.\"}}"))
 	res := &http.Response{Status: "Bad request", StatusCode: http.StatusBadRequest, Body: ioutil.NopCloser(body)}
 	err := handleJiraError(res)
-	assert.EqualError(t, err, "request unsuccessful (Bad request): A version with this name already exists in this project.")
+	assert.EqualError(t, err, "request unsuccessful (Bad request): name: A version with this name already exists in this project.")
 }
 
 func Test_handleJiraError_unreadableResponse(t *testing.T) {
 	body := bytes.NewReader([]byte("test body"))
 	res := &http.Response{Status: "Bad request", StatusCode: http.StatusBadRequest, Body: ioutil.NopCloser(body)}
 	err := handleJiraError(res)
-	assert.EqualError(t, err, "request unsuccessful (Bad request), could not read response: invalid character 'e' in literal true (expecting 'r')")
+	assert.EqualError(t, err, "request unsuccessful (Bad request): test body")
 }
 
 func Test_filterSlice(t *testing.T) {
@@ -250,3 +304,114 @@ func Test_filterSlice(t *testing.T) {
 		})
 	}
 }
+
+func Test_newUpdateRequestBody(t *testing.T) {
+	tests := []struct {
+		name            string
+		fixVersions     VersionChange
+		affectsVersions VersionChange
+		want            string
+		wantErr         string
+	}{
+		{
+			name:        "add multiple fix versions",
+			fixVersions: VersionChange{Add: []string{"1.2.5", "1.3.1"}},
+			want:        "{\"update\":{\"fixVersions\":[{\"add\":{\"name\":\"1.2.5\"}},{\"add\":{\"name\":\"1.3.1\"}}]}}",
+		},
+		{
+			name:            "add and remove affects versions",
+			affectsVersions: VersionChange{Add: []string{"1.2.4"}, Remove: []string{"1.2.3"}},
+			want:            "{\"update\":{\"versions\":[{\"add\":{\"name\":\"1.2.4\"}},{\"remove\":{\"name\":\"1.2.3\"}}]}}",
+		},
+		{
+			name:            "replace fix versions and add affects version",
+			fixVersions:     VersionChange{Set: []string{"1.3.1"}},
+			affectsVersions: VersionChange{Add: []string{"1.3.0"}},
+			want:            "{\"update\":{\"fixVersions\":[{\"set\":[{\"name\":\"1.3.1\"}]}],\"versions\":[{\"add\":{\"name\":\"1.3.0\"}}]}}",
+		},
+		{
+			name:        "replace combined with remove",
+			fixVersions: VersionChange{Set: []string{"1.3.1"}, Remove: []string{"1.3.0"}},
+			wantErr:     "invalid fix versions: replacing versions cannot be combined with adding or removing versions",
+		},
+		{
+			name:            "empty version name",
+			affectsVersions: VersionChange{Remove: []string{""}},
+			wantErr:         "invalid affects versions: version cannot be empty",
+		},
+		{
+			name:    "no changes",
+			wantErr: "no changes provided",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newUpdateRequestBody(tt.fixVersions, tt.affectsVersions, nil)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			data, err := json.Marshal(got)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(data))
+		})
+	}
+}
+
+func TestUpdateIssues(t *testing.T) {
+	mockClient := NewMockHttpClient(t, 201)
+	jiraClient, err := NewJiraClient("https://test.nu", "marcel@test.nu", "c0ffee", mockClient)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	update := IssueUpdate{
+		FixVersions:     VersionChange{Add: []string{"1.2.5", "1.3.1"}},
+		AffectsVersions: VersionChange{Remove: []string{"1.2.4"}},
+	}
+
+	_, err = UpdateIssues("Backport of MB-1337", jiraClient, []string{"MB-1338"}, nil, update)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, mockClient.CalledTimes)
+	assert.Equal(t, []string{
+		"{\"update\":{\"fixVersions\":[{\"add\":{\"name\":\"1.2.5\"}},{\"add\":{\"name\":\"1.3.1\"}}],\"versions\":[{\"remove\":{\"name\":\"1.2.4\"}}]}}",
+		"{\"update\":{\"fixVersions\":[{\"add\":{\"name\":\"1.2.5\"}},{\"add\":{\"name\":\"1.3.1\"}}],\"versions\":[{\"remove\":{\"name\":\"1.2.4\"}}]}}",
+	}, mockClient.CalledWith)
+}
+
+func TestUnassignVersions_search(t *testing.T) {
+	var searchedWith string
+	var calledWith []string
+	mockClient := mockHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+		data, _ := ioutil.ReadAll(req.Body)
+
+		if req.URL.Path == "/rest/api/latest/search" {
+			searchedWith = string(data)
+			return newMockResponse(http.StatusOK, "{\"startAt\":0,\"maxResults\":100,\"total\":2,\"issues\":[{\"key\":\"MB-1\"},{\"key\":\"MB-2\"}]}"), nil
+		}
+
+		calledWith = append(calledWith, req.URL.Path+" "+string(data))
+		return newMockResponse(http.StatusNoContent, ""), nil
+	})
+	jiraClient, err := NewJiraClient("https://test.nu", "marcel@test.nu", "c0ffee", mockClient)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	result, err := UnassignVersions("Reverts MB-3", "1.0.0", "MB", jiraClient, nil, []string{"MB-2"}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []StepResult{
+		{Step: "unassign-version:MB-1:1.0.0", Description: "remove version \"1.0.0\" from MB-1", Issue: "MB-1", Status: StepCompleted},
+		{Step: "unassign-version:MB-3:1.0.0", Description: "remove version \"1.0.0\" from MB-3", Issue: "MB-3", Status: StepCompleted},
+	}, result.Steps)
+	assert.Equal(t, "{\"jql\":\"project = \\\"MB\\\" AND fixVersion = \\\"1.0.0\\\"\",\"startAt\":0,\"maxResults\":100,\"fields\":[\"key\"]}", searchedWith)
+	assert.Equal(t, []string{
+		"/rest/api/latest/issue/MB-1 {\"update\":{\"fixVersions\":[{\"remove\":{\"name\":\"1.0.0\"}}]}}",
+		"/rest/api/latest/issue/MB-3 {\"update\":{\"fixVersions\":[{\"remove\":{\"name\":\"1.0.0\"}}]}}",
+	}, calledWith)
+}
@@ -0,0 +1,291 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// validationChunkSize is the number of issue keys per search, which keeps the JQL query short enough for Jira
+const validationChunkSize = 50
+
+// validationFields are the fields requested for the validated issues
+var validationFields = []string{"project", "issuetype"}
+
+// IssueValidationOptions are the checks on the validated issues besides their existence
+type IssueValidationOptions struct {
+	// Projects are the keys of the projects the issues must belong to. Every project is accepted when it is empty.
+	Projects []string
+	// Types are the names of the issue types which are accepted, e.g. Story and Bug. Every type is accepted when it is
+	// empty.
+	Types []string
+}
+
+// ValidatedIssue is the outcome of the validation of an issue key
+type ValidatedIssue struct {
+	// Key is the key as it was provided
+	Key string `json:"key" yaml:"key"`
+	// CurrentKey is the key of the issue in Jira, which differs from Key when the issue was moved to another project
+	CurrentKey string `json:"currentKey,omitempty" yaml:"currentKey,omitempty"`
+	Project    string `json:"project,omitempty" yaml:"project,omitempty"`
+	Type       string `json:"type,omitempty" yaml:"type,omitempty"`
+	// Problem explains why the issue is invalid and is empty for valid issues
+	Problem string `json:"problem,omitempty" yaml:"problem,omitempty"`
+}
+
+// Renamed reports whether the issue has another key in Jira than the provided key
+func (i ValidatedIssue) Renamed() bool {
+	return i.CurrentKey != "" && i.CurrentKey != i.Key
+}
+
+// IssueValidation holds the outcome of the validation of every issue key
+type IssueValidation struct {
+	Issues []ValidatedIssue
+}
+
+// Invalid returns the issues which have a problem
+func (v *IssueValidation) Invalid() []ValidatedIssue {
+	var invalid []ValidatedIssue
+
+	for _, issue := range v.Issues {
+		if issue.Problem != "" {
+			invalid = append(invalid, issue)
+		}
+	}
+
+	return invalid
+}
+
+// Renamed returns the valid issues which have another key in Jira than the provided key
+func (v *IssueValidation) Renamed() []ValidatedIssue {
+	var renamed []ValidatedIssue
+
+	for _, issue := range v.Issues {
+		if issue.Problem == "" && issue.Renamed() {
+			renamed = append(renamed, issue)
+		}
+	}
+
+	return renamed
+}
+
+// Err returns an error which lists every invalid issue, or nil when all issues are valid
+func (v *IssueValidation) Err() error {
+	invalid := v.Invalid()
+
+	if len(invalid) == 0 {
+		return nil
+	}
+
+	problems := make([]string, len(invalid))
+
+	for i, issue := range invalid {
+		problems[i] = fmt.Sprintf("%s %s", issue.Key, issue.Problem)
+	}
+
+	return fmt.Errorf("%d of %d issues are invalid: %s", len(invalid), len(v.Issues), strings.Join(problems, "; "))
+}
+
+// Apply returns the steps without the steps of invalid issues, in which the keys of renamed issues are replaced with
+// their current key
+func (v *IssueValidation) Apply(steps []Step) []Step {
+	issues := map[string]ValidatedIssue{}
+
+	for _, issue := range v.Issues {
+		issues[issue.Key] = issue
+	}
+
+	var result []Step
+	seen := map[string]bool{}
+
+	for _, step := range steps {
+		s, ok := step.(renamableStep)
+
+		if !ok {
+			result = append(result, step)
+			continue
+		}
+
+		if issue, ok := issues[s.issue()]; ok && issue.Problem != "" {
+			continue
+		} else if ok && issue.Renamed() {
+			s.setIssue(issue.CurrentKey)
+		}
+
+		// An issue can be provided with both its old and its current key
+		if !seen[step.Id()] {
+			seen[step.Id()] = true
+			result = append(result, step)
+		}
+	}
+
+	return result
+}
+
+// renamableStep is implemented by steps which change a single issue, of which the key can be replaced
+type renamableStep interface {
+	issueStep
+	setIssue(key string)
+}
+
+// StepIssues returns the keys of the issues which are changed by the steps, without duplicates
+func StepIssues(steps []Step) []string {
+	var issues []string
+
+	for _, step := range steps {
+		if s, ok := step.(issueStep); ok {
+			issues = append(issues, s.issue())
+		}
+	}
+
+	return removeDuplicates(issues)
+}
+
+// ValidateIssues checks that the issues exist and are visible to the user, and that they belong to one of the
+// projects and have one of the types of the options. The issues are searched in chunks with a single JQL query per
+// chunk. Issues which were moved to another project are followed to their current key.
+func (c *JiraClient) ValidateIssues(keys []string, options IssueValidationOptions) (*IssueValidation, error) {
+	return c.ValidateIssuesContext(context.Background(), keys, options)
+}
+
+// ValidateIssuesContext is ValidateIssues with a context which cancels the requests
+func (c *JiraClient) ValidateIssuesContext(ctx context.Context, keys []string, options IssueValidationOptions) (*IssueValidation, error) {
+	keys = removeDuplicates(keys)
+	found := map[string]issueSummary{}
+
+	for start := 0; start < len(keys); start += validationChunkSize {
+		end := start + validationChunkSize
+
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		if err := c.searchIssueSummaries(ctx, keys[start:end], found); err != nil {
+			return nil, fmt.Errorf("could not validate issues: %w", err)
+		}
+	}
+
+	validation := &IssueValidation{}
+
+	for _, key := range keys {
+		summary, ok := found[strings.ToUpper(key)]
+
+		// The search returns moved issues with their current key, so they are looked up one by one
+		if !ok {
+			var err error
+			summary, ok, err = c.getIssueSummary(ctx, key)
+
+			if err != nil {
+				return nil, fmt.Errorf("could not validate issue %s: %w", key, err)
+			}
+		}
+
+		issue := ValidatedIssue{Key: key}
+
+		if ok {
+			issue.CurrentKey, issue.Project, issue.Type = summary.Key, summary.Fields.Project.Key, summary.Fields.IssueType.Name
+			issue.Problem = options.problem(issue)
+		} else {
+			issue.Problem = "does not exist or is not visible to the user"
+		}
+
+		switch {
+		case issue.Problem != "":
+			c.log().Warn("invalid issue", "issue", key, "problem", issue.Problem)
+		case issue.Renamed():
+			c.log().Warn("issue was moved", "issue", key, "key", issue.CurrentKey)
+		}
+
+		validation.Issues = append(validation.Issues, issue)
+	}
+
+	return validation, nil
+}
+
+// problem returns why the issue does not match the options, or an empty string when it does
+func (o IssueValidationOptions) problem(issue ValidatedIssue) string {
+	if len(o.Projects) != 0 && !containsFold(o.Projects, issue.Project) {
+		return fmt.Sprintf("belongs to project %s instead of %s", issue.Project, strings.Join(o.Projects, " or "))
+	}
+
+	if len(o.Types) != 0 && !containsFold(o.Types, issue.Type) {
+		return fmt.Sprintf("has type %s instead of %s", issue.Type, strings.Join(o.Types, " or "))
+	}
+
+	return ""
+}
+
+// issueSummary holds the fields of an issue which are validated
+type issueSummary struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Project struct {
+			Key string `json:"key"`
+		} `json:"project"`
+		IssueType struct {
+			Name string `json:"name"`
+		} `json:"issuetype"`
+	} `json:"fields"`
+}
+
+// searchIssueSummaries searches the issues with the provided keys and adds them to found by their current key. The
+// query is validated with warn, so unknown keys do not fail the search.
+func (c *JiraClient) searchIssueSummaries(ctx context.Context, keys []string, found map[string]issueSummary) error {
+	quoted := make([]string, len(keys))
+
+	for i, key := range keys {
+		quoted[i] = quoteJQL(key)
+	}
+
+	jql := fmt.Sprintf("key in (%s)", strings.Join(quoted, ", "))
+	it := c.newSearchPaginator(jql, "warn", validationFields).Iterate(ctx)
+	defer it.Close()
+
+	for it.Next() {
+		var summary issueSummary
+
+		if err := it.Decode(&summary); err != nil {
+			return err
+		}
+
+		found[strings.ToUpper(summary.Key)] = summary
+	}
+
+	return it.Err()
+}
+
+// getIssueSummary requests the issue with the provided key. It reports false when the issue does not exist or is not
+// visible to the user.
+func (c *JiraClient) getIssueSummary(ctx context.Context, key string) (issueSummary, bool, error) {
+	endpoint := fmt.Sprintf("%s/issue/%s?fields=%s", apiEndpoint, url.PathEscape(key), strings.Join(validationFields, ","))
+	req, err := c.createRequest(ctx, http.MethodGet, endpoint, nil)
+
+	if err != nil {
+		return issueSummary{}, false, err
+	}
+
+	var summary issueSummary
+
+	if err = c.doRequest(req, &summary); err != nil {
+		if IsNotFound(err) {
+			return issueSummary{}, false, nil
+		}
+
+		return issueSummary{}, false, err
+	}
+
+	return summary, true, nil
+}
+
+// containsFold reports whether the values contain the value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
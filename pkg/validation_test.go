@@ -0,0 +1,113 @@
+package pkg
+
+import (
+	"fmt"
+	"github.com/marcelblijleven/jira-helper/pkg/jiratest"
+	"github.com/marcelblijleven/jira-helper/pkg/jiratest/testserver"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+// newValidationServer returns a fake Jira with valid, moved and invalid issues and a client for it
+func newValidationServer(t *testing.T) (*testserver.Server, *JiraClient) {
+	server := testserver.New(t)
+	server.AddIssue(jiratest.Issue{Key: "MB-1", Type: "Story"})
+	server.AddIssue(jiratest.Issue{Key: "MB-2", Type: "Epic"})
+	server.AddIssue(jiratest.Issue{Key: "MB-10", Type: "Bug", MovedFrom: []string{"MB-3"}})
+	server.AddIssue(jiratest.Issue{Key: "XX-5", Type: "Bug", MovedFrom: []string{"MB-4"}})
+	server.AddIssue(jiratest.Issue{Key: "XX-1", Type: "Story"})
+	client, err := NewJiraClient(server.URL, "marcel@test.nl", "c0ffee", http.DefaultClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return server, client
+}
+
+func TestJiraClient_ValidateIssues(t *testing.T) {
+	server, client := newValidationServer(t)
+
+	validation, err := client.ValidateIssues([]string{"MB-1", "MB-2", "MB-3", "MB-4", "MB-9", "XX-1", "MB-1"}, IssueValidationOptions{
+		Projects: []string{"MB"},
+		Types:    []string{"Story", "bug"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []ValidatedIssue{
+		{Key: "MB-1", CurrentKey: "MB-1", Project: "MB", Type: "Story"},
+		{Key: "MB-2", CurrentKey: "MB-2", Project: "MB", Type: "Epic", Problem: "has type Epic instead of Story or bug"},
+		{Key: "MB-3", CurrentKey: "MB-10", Project: "MB", Type: "Bug"},
+		{Key: "MB-4", CurrentKey: "XX-5", Project: "XX", Type: "Bug", Problem: "belongs to project XX instead of MB"},
+		{Key: "MB-9", Problem: "does not exist or is not visible to the user"},
+		{Key: "XX-1", CurrentKey: "XX-1", Project: "XX", Type: "Story", Problem: "belongs to project XX instead of MB"},
+	}, validation.Issues)
+	assert.Equal(t, []ValidatedIssue{{Key: "MB-3", CurrentKey: "MB-10", Project: "MB", Type: "Bug"}}, validation.Renamed())
+	assert.Len(t, validation.Invalid(), 4)
+	assert.EqualError(t, validation.Err(), "4 of 6 issues are invalid: MB-2 has type Epic instead of Story or bug; MB-4 belongs to project XX instead of MB; MB-9 does not exist or is not visible to the user; XX-1 belongs to project XX instead of MB")
+
+	// A single search, and a lookup for every key which the search did not return under that key
+	var paths []string
+
+	for _, request := range server.Requests() {
+		paths = append(paths, request.Method+" "+request.Path)
+	}
+
+	assert.Equal(t, []string{"POST /search", "GET /issue/MB-3", "GET /issue/MB-4", "GET /issue/MB-9"}, paths)
+	assert.Contains(t, server.Requests()[0].Body, `"validateQuery":"warn"`)
+}
+
+func TestJiraClient_ValidateIssues_chunks(t *testing.T) {
+	server := testserver.New(t)
+	var keys []string
+
+	for i := 1; i <= 120; i++ {
+		keys = append(keys, server.AddIssue(jiratest.Issue{Key: fmt.Sprintf("MB-%d", i)}).Key)
+	}
+
+	client, err := NewJiraClient(server.URL, "marcel@test.nl", "c0ffee", http.DefaultClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validation, err := client.ValidateIssues(keys, IssueValidationOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, validation.Err())
+	assert.Len(t, validation.Issues, 120)
+	assert.Len(t, server.Requests(), 3)
+}
+
+func TestJiraClient_ValidateIssues_error(t *testing.T) {
+	server, client := newValidationServer(t)
+	server.Fail(http.MethodPost, "/search", http.StatusUnauthorized, 1)
+
+	_, err := client.ValidateIssues([]string{"MB-1"}, IssueValidationOptions{})
+	assert.True(t, IsUnauthorized(err))
+	assert.EqualError(t, err, "could not validate issues: could not get page at 0: request unsuccessful (401 Unauthorized): Unauthorized")
+}
+
+func TestIssueValidation_Apply(t *testing.T) {
+	validation := &IssueValidation{Issues: []ValidatedIssue{
+		{Key: "MB-1", CurrentKey: "MB-1"},
+		{Key: "MB-3", CurrentKey: "MB-10"},
+		{Key: "MB-9", Problem: "does not exist or is not visible to the user"},
+		{Key: "MB-10", CurrentKey: "MB-10"},
+	}}
+
+	steps := validation.Apply(CreateAndAssignSteps("1.0.0", "MB", "", []string{"MB-1", "MB-3", "MB-9", "MB-10"}, nil, nil))
+	assert.Equal(t, []string{"create-version:MB:1.0.0", "assign-version:MB-1:1.0.0", "assign-version:MB-10:1.0.0"}, stepIds(steps))
+	assert.Equal(t, []string{"MB-1", "MB-10"}, StepIssues(steps))
+}
+
+// stepIds returns the id of every step
+func stepIds(steps []Step) []string {
+	var ids []string
+
+	for _, step := range steps {
+		ids = append(ids, step.Id())
+	}
+
+	return ids
+}
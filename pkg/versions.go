@@ -0,0 +1,173 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// GetProjectVersions retrieves all versions of the provided project
+func (c *JiraClient) GetProjectVersions(project string) ([]Version, error) {
+	return c.GetProjectVersionsContext(context.Background(), project)
+}
+
+// GetProjectVersionsContext is GetProjectVersions with a context which cancels the request
+func (c *JiraClient) GetProjectVersionsContext(ctx context.Context, project string) ([]Version, error) {
+	if project == "" {
+		return nil, errors.New("project cannot be empty")
+	}
+
+	endpoint := fmt.Sprintf("%s/project/%s/versions", apiEndpoint, url.PathEscape(project))
+	req, err := c.createRequest(ctx, http.MethodGet, endpoint, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []Version
+
+	if err = c.doRequest(req, &versions); err != nil {
+		return nil, fmt.Errorf("could not retrieve versions of project %s: %w", project, err)
+	}
+
+	return versions, nil
+}
+
+// FindVersion looks up the version with the provided name in the project
+func (c *JiraClient) FindVersion(project, name string) (*Version, error) {
+	return c.FindVersionContext(context.Background(), project, name)
+}
+
+// FindVersionContext is FindVersion with a context which cancels the request
+func (c *JiraClient) FindVersionContext(ctx context.Context, project, name string) (*Version, error) {
+	versions, err := c.GetProjectVersionsContext(ctx, project)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, version := range versions {
+		if version.Name == name {
+			return &version, nil
+		}
+	}
+
+	return nil, fmt.Errorf("version %q does not exist in project %s", name, project)
+}
+
+// ReleaseVersion marks the version with the provided id as released with today as release date
+func (c *JiraClient) ReleaseVersion(id string) error {
+	return c.ReleaseVersionContext(context.Background(), id)
+}
+
+// ReleaseVersionContext is ReleaseVersion with a context which cancels the request
+func (c *JiraClient) ReleaseVersionContext(ctx context.Context, id string) error {
+	released := true
+	return c.updateVersion(ctx, id, versionUpdateRequestBody{Released: &released, ReleaseDate: getDateString()})
+}
+
+// UnreleaseVersion marks the version with the provided id as unreleased
+func (c *JiraClient) UnreleaseVersion(id string) error {
+	return c.UnreleaseVersionContext(context.Background(), id)
+}
+
+// UnreleaseVersionContext is UnreleaseVersion with a context which cancels the request
+func (c *JiraClient) UnreleaseVersionContext(ctx context.Context, id string) error {
+	released := false
+	return c.updateVersion(ctx, id, versionUpdateRequestBody{Released: &released})
+}
+
+// ArchiveVersion marks the version with the provided id as archived
+func (c *JiraClient) ArchiveVersion(id string) error {
+	return c.ArchiveVersionContext(context.Background(), id)
+}
+
+// ArchiveVersionContext is ArchiveVersion with a context which cancels the request
+func (c *JiraClient) ArchiveVersionContext(ctx context.Context, id string) error {
+	archived := true
+	return c.updateVersion(ctx, id, versionUpdateRequestBody{Archived: &archived})
+}
+
+// UnarchiveVersion marks the version with the provided id as not archived
+func (c *JiraClient) UnarchiveVersion(id string) error {
+	return c.UnarchiveVersionContext(context.Background(), id)
+}
+
+// UnarchiveVersionContext is UnarchiveVersion with a context which cancels the request
+func (c *JiraClient) UnarchiveVersionContext(ctx context.Context, id string) error {
+	archived := false
+	return c.updateVersion(ctx, id, versionUpdateRequestBody{Archived: &archived})
+}
+
+// MergeVersion moves the issues of the version with the provided id to the target version and deletes the version
+func (c *JiraClient) MergeVersion(id, targetId string) error {
+	return c.MergeVersionContext(context.Background(), id, targetId)
+}
+
+// MergeVersionContext is MergeVersion with a context which cancels the request
+func (c *JiraClient) MergeVersionContext(ctx context.Context, id, targetId string) error {
+	if id == "" || targetId == "" {
+		return errors.New("version id cannot be empty")
+	}
+
+	endpoint := fmt.Sprintf("%s/version/%s/mergeto/%s", apiEndpoint, url.PathEscape(id), url.PathEscape(targetId))
+	req, err := c.createRequest(ctx, http.MethodPut, endpoint, nil)
+
+	if err != nil {
+		return err
+	}
+
+	if err = c.doRequest(req, nil); err != nil {
+		return fmt.Errorf("could not merge version %s into %s: %w", id, targetId, err)
+	}
+
+	return nil
+}
+
+// DeleteVersion deletes the version with the provided id. Issues which have the version as fix version or affects
+// version will have the version removed.
+func (c *JiraClient) DeleteVersion(id string) error {
+	return c.DeleteVersionContext(context.Background(), id)
+}
+
+// DeleteVersionContext is DeleteVersion with a context which cancels the request
+func (c *JiraClient) DeleteVersionContext(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("version id cannot be empty")
+	}
+
+	endpoint := fmt.Sprintf("%s/version/%s", apiEndpoint, url.PathEscape(id))
+	req, err := c.createRequest(ctx, http.MethodDelete, endpoint, nil)
+
+	if err != nil {
+		return err
+	}
+
+	if err = c.doRequest(req, nil); err != nil {
+		return fmt.Errorf("could not delete version %s: %w", id, err)
+	}
+
+	return nil
+}
+
+// updateVersion calls the version endpoint to update the version with the provided id
+func (c *JiraClient) updateVersion(ctx context.Context, id string, body versionUpdateRequestBody) error {
+	if id == "" {
+		return errors.New("version id cannot be empty")
+	}
+
+	endpoint := fmt.Sprintf("%s/version/%s", apiEndpoint, url.PathEscape(id))
+	req, err := c.createRequest(ctx, http.MethodPut, endpoint, body)
+
+	if err != nil {
+		return err
+	}
+
+	if err = c.doRequest(req, nil); err != nil {
+		return fmt.Errorf("could not update version %s: %w", id, err)
+	}
+
+	return nil
+}
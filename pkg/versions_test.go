@@ -0,0 +1,128 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+const projectVersionsResponse = `[
+	{"self":"https://test.nu/rest/api/latest/version/10000","id":"10000","name":"1.0.0","archived":false,"released":true,"projectId":10000},
+	{"self":"https://test.nu/rest/api/latest/version/10001","id":"10001","name":"1.1.0","archived":false,"released":true,"projectId":10000}
+]`
+
+func TestJiraClient_FindVersion(t *testing.T) {
+	var calledPath string
+	mockClient := mockHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+		calledPath = req.URL.Path
+		return newMockResponse(http.StatusOK, projectVersionsResponse), nil
+	})
+	jiraClient, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", mockClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := jiraClient.FindVersion("MB", "1.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "10001", version.Id)
+	assert.Equal(t, "/rest/api/latest/project/MB/versions", calledPath)
+
+	_, err = jiraClient.FindVersion("MB", "2.0.0")
+	assert.EqualError(t, err, "version \"2.0.0\" does not exist in project MB")
+}
+
+func TestJiraClient_FindVersionContext(t *testing.T) {
+	mockClient := mockHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+
+		return newMockResponse(http.StatusOK, projectVersionsResponse), nil
+	})
+	jiraClient, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", mockClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	version, err := jiraClient.FindVersionContext(ctx, "MB", "1.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "10001", version.Id)
+
+	cancel()
+	_, err = jiraClient.FindVersionContext(ctx, "MB", "1.1.0")
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestJiraClient_UnreleaseVersion(t *testing.T) {
+	mockClient := NewMockHttpClient(t, 204)
+	jiraClient, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", mockClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = jiraClient.UnreleaseVersion("10001")
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPut, mockClient.CalledMethod)
+	assert.Equal(t, "{\"released\":false}", mockClient.CalledWith[0])
+}
+
+func TestJiraClient_DeleteVersion(t *testing.T) {
+	var calledMethod, calledPath string
+	mockClient := mockHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+		calledMethod = req.Method
+		calledPath = req.URL.Path
+		data, _ := ioutil.ReadAll(req.Body)
+		assert.Empty(t, data)
+		return newMockResponse(http.StatusNoContent, ""), nil
+	})
+	jiraClient, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", mockClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, jiraClient.DeleteVersion("10001"))
+	assert.Equal(t, http.MethodDelete, calledMethod)
+	assert.Equal(t, "/rest/api/latest/version/10001", calledPath)
+	assert.EqualError(t, jiraClient.DeleteVersion(""), "version id cannot be empty")
+}
+
+func TestJiraClient_ArchiveVersion(t *testing.T) {
+	mockClient := NewMockHttpClient(t, 204)
+	jiraClient, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", mockClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, jiraClient.ArchiveVersion("10001"))
+	assert.Equal(t, "{\"archived\":true}", mockClient.CalledWith[0])
+	assert.NoError(t, jiraClient.UnarchiveVersion("10001"))
+	assert.Equal(t, "{\"archived\":false}", mockClient.CalledWith[1])
+}
+
+func TestJiraClient_MergeVersion(t *testing.T) {
+	var calledMethod, calledPath string
+	mockClient := mockHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+		calledMethod = req.Method
+		calledPath = req.URL.Path
+		return newMockResponse(http.StatusNoContent, ""), nil
+	})
+	jiraClient, err := NewJiraClient("https://test.nu", "marcel@test.nl", "c0ffee", mockClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, jiraClient.MergeVersion("10002", "10001"))
+	assert.Equal(t, http.MethodPut, calledMethod)
+	assert.Equal(t, "/rest/api/latest/version/10002/mergeto/10001", calledPath)
+	assert.EqualError(t, jiraClient.MergeVersion("10002", ""), "version id cannot be empty")
+}